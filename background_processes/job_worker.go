@@ -0,0 +1,84 @@
+package backgroundprocesses
+
+import (
+	"context"
+	"fmt"
+	"new/services"
+	"sync"
+	"time"
+)
+
+// JobWorker забирает задачи из очереди Redis и обрабатывает их
+type JobWorker struct {
+	Jobs        *services.JobService
+	Concurrency int // Количество одновременно обрабатываемых задач
+}
+
+// Run запускает обработку очереди до отмены ctx и ждет завершения начатых задач.
+// Периодически возвращает в очередь брошенные задачи
+func (w *JobWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.requeueStale(ctx)
+	}()
+
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	for ctx.Err() == nil {
+		taskID, ok, err := w.Jobs.Dequeue(ctx, 5*time.Second)
+		if ctx.Err() != nil {
+			if ok {
+				// Задача уже снята с очереди — возвращаем ее обратно
+				if err := w.Jobs.Enqueue(context.WithoutCancel(ctx), taskID); err != nil {
+					fmt.Printf("Ошибка возврата задачи %d в очередь: %v\n", taskID, err)
+				}
+			}
+			return
+		}
+		if err != nil {
+			fmt.Printf("Ошибка чтения очереди заданий: %v\n", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(taskID uint) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := w.Jobs.ProcessTask(ctx, taskID); err != nil {
+				fmt.Printf("Ошибка обработки задачи %d: %v\n", taskID, err)
+			}
+		}(taskID)
+	}
+}
+
+// requeueStale сразу и затем раз в минуту возвращает в очередь задачи, брошенные остановленными обработчиками
+func (w *JobWorker) requeueStale(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		if n, err := w.Jobs.RequeueStale(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("Ошибка при восстановлении очереди заданий: %v\n", err)
+		} else if n > 0 {
+			fmt.Printf("Возвращено в очередь брошенных задач: %d\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
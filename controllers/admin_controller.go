@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"new/dto"
+	"new/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// AdminController — административные операции: пользователи, кеши, история
+type AdminController struct {
+	Access *services.AccessService
+	Admin  *services.AdminService
+}
+
+// ListUsers godoc
+// @Summary      Список пользователей
+// @Description  Возвращает пользователей с ролями и дополнительными разрешениями. Требует users:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Success      200  {array}   dto.AdminUserDTO
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/users [get]
+func (c *AdminController) ListUsers(ctx *gin.Context) {
+	users, err := c.Access.ListUsers()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, users)
+}
+
+// SetUserRole godoc
+// @Summary      Назначить роль
+// @Description  Назначает пользователю роль user, staff или admin и отзывает его токены. Требует users:manage
+// @Tags         admin
+// @Accept       json
+// @Security BearerAuth
+// @Param        id     path  int             true  "ID пользователя"
+// @Param        input  body  dto.SetRoleDTO  true  "Роль"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/users/{id}/role [put]
+func (c *AdminController) SetUserRole(ctx *gin.Context) {
+	var input dto.SetRoleDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := parseUint(ctx.Param("id"))
+	if userID == ctx.GetUint("userID") {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "нельзя изменить собственную роль"})
+		return
+	}
+
+	if err := c.Access.SetRole(userID, input.Role); err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SetUserPermissions godoc
+// @Summary      Дополнительные разрешения
+// @Description  Заменяет набор разрешений, выданных пользователю сверх роли. Требует users:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id     path  int                    true  "ID пользователя"
+// @Param        input  body  dto.SetPermissionsDTO  true  "Разрешения"
+// @Success      200  {object}  dto.SetPermissionsDTO
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/users/{id}/permissions [put]
+func (c *AdminController) SetUserPermissions(ctx *gin.Context) {
+	var input dto.SetPermissionsDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	permissions, err := c.Access.SetPermissions(parseUint(ctx.Param("id")), input.Permissions)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.SetPermissionsDTO{Permissions: permissions})
+}
+
+// InspectCache godoc
+// @Summary      Просмотр кеша
+// @Description  Возвращает количество ключей Redis с префиксом и первые limit ключей с TTL. Требует cache:inspect
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        prefix  query  string  true   "Префикс ключей, например llm:desc:"
+// @Param        limit   query  int     false  "Максимум ключей в ответе (по умолчанию 100)"
+// @Success      200  {object}  dto.CacheInspectDTO
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/cache [get]
+func (c *AdminController) InspectCache(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit < 0 {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "некорректное значение limit"})
+		return
+	}
+
+	result, err := c.Admin.InspectCache(ctx.Query("prefix"), limit)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// PurgeCache godoc
+// @Summary      Очистка кеша
+// @Description  Удаляет ключи Redis с префиксом. Требует cache:purge
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        prefix  query  string  true  "Префикс ключей, например llm:user:"
+// @Success      200  {object}  dto.PurgeResultDTO
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/cache [delete]
+func (c *AdminController) PurgeCache(ctx *gin.Context) {
+	deleted, err := c.Admin.PurgeCache(ctx.Query("prefix"))
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.PurgeResultDTO{Deleted: deleted})
+}
+
+// PurgeHistory godoc
+// @Summary      Очистка истории
+// @Description  Удаляет историю мест всех пользователей или одного пользователя, при необходимости только записи старше before. Требует history:purge
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        user_id  query  int     false  "ID пользователя"
+// @Param        before   query  string  false  "Удалять записи старше этого момента (RFC 3339)"
+// @Success      200  {object}  dto.PurgeResultDTO
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/history [delete]
+func (c *AdminController) PurgeHistory(ctx *gin.Context) {
+	var before time.Time
+	if value := ctx.Query("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "некорректное значение before: " + err.Error()})
+			return
+		}
+		before = parsed
+	}
+
+	deleted, err := c.Admin.PurgeHistory(parseUint(ctx.Query("user_id")), before)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.PurgeResultDTO{Deleted: deleted})
+}
+
+// respondAdminError переводит ошибки сервисов в HTTP-статусы
+func respondAdminError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: "не найдено"})
+	case errors.Is(err, services.ErrUnknownRole),
+		errors.Is(err, services.ErrUnknownPermission),
+		errors.Is(err, services.ErrCachePrefix):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
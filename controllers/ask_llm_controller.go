@@ -32,7 +32,7 @@ func (controller *AskLLMController) AskLLMQuestion(c *gin.Context) {
 		return
 	}
 
-	message, err := controller.Service.AskLLMQuestion(question)
+	message, err := controller.Service.AskLLMQuestion(c.Request.Context(), question)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ask question"})
 		return
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"new/models"
+	"new/services"
+	"new/services/blobstore"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AudioController — контроллер для выдачи сохранённого аудио
+type AudioController struct {
+	Service *services.AudioCacheService
+}
+
+// GetAudio godoc
+// @Summary      Получить аудио
+// @Description  Отдаёт сохранённое аудио бинарным потоком. Поддерживает Range-запросы (206 Partial Content), ETag и If-None-Match
+// @Tags         audio
+// @Produce      octet-stream
+// @Param        id     path    string  true   "Идентификатор аудио (хеш содержимого)"
+// @Param        Range  header  string  false  "Диапазон байт, например bytes=0-1023"
+// @Success      200  {string}  binary  "Бинарные данные аудиофайла"
+// @Success      206  {string}  binary  "Часть аудиофайла"
+// @Success      304  {string}  string  "Не изменилось"
+// @Failure      404  {object}  ErrorResponse
+// @Failure      416  {string}  string  "Некорректный диапазон"
+// @Failure      500  {object}  ErrorResponse
+// @Router       /audio/{id} [get]
+func (c *AudioController) GetAudio(ctx *gin.Context) {
+	asset, err := c.Service.FindByHash(ctx.Request.Context(), ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: "Аудио не найдено"})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	serveAudio(ctx, c.Service, asset)
+}
+
+// serveAudio отдаёт аудио из хранилища с заголовками кеширования.
+// Range, If-Range, If-None-Match и HEAD обрабатывает http.ServeContent
+func serveAudio(ctx *gin.Context, service *services.AudioCacheService, asset *models.AudioAsset) {
+	obj, err := service.Store.Open(asset.StorageKey)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: "Аудио не найдено в хранилище"})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer obj.Close()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", asset.ContentType)
+	header.Set("ETag", `"`+asset.Hash+`"`)
+	// Содержимое адресуется хешем и никогда не меняется
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("X-Audio-ID", asset.Hash)
+	header.Set("X-Audio-URL", service.URL(asset))
+
+	http.ServeContent(ctx.Writer, ctx.Request, "", asset.CreatedAt, obj)
+}
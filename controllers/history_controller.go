@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"new/dto"
 	"new/services"
+	"new/services/osm"
+	"new/services/tts"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +18,12 @@ type PlaceErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// OSMInputErrorResponse — ошибка разбора входных данных с перечнем неверных элементов
+type OSMInputErrorResponse struct {
+	Error    string             `json:"error"`
+	Elements []osm.ElementError `json:"elements,omitempty"`
+}
+
 // PlaceController — контроллер для обработки запросов на места
 type PlaceController struct {
 	Service *services.PlaceService
@@ -29,7 +40,7 @@ type PlaceController struct {
 // @Router       /users/history [get]
 func (c *PlaceController) GetUserHistory(ctx *gin.Context) {
 	userID := ctx.GetUint("userID") // Предполагается, что userID извлекается из middleware
-	history, err := c.Service.GetUserHistory(userID)
+	history, err := c.Service.GetUserHistory(ctx.Request.Context(), userID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, PlaceErrorResponse{Error: err.Error()})
 		return
@@ -39,12 +50,12 @@ func (c *PlaceController) GetUserHistory(ctx *gin.Context) {
 
 // GenerateAudio godoc
 // @Summary      Сгенерировать аудио
-// @Description  Генерирует аудиофайл в формате MP3
+// @Description  Генерирует аудиофайл с выбранными голосом, языком, скоростью и форматом (mp3, ogg, wav)
 // @Tags         audio
 // @Accept       json
 // @Produce      octet-stream
 // @Param        input body dto.AudioDTO true "Текст для генерации аудио"
-// @Success      200  {string}  binary  "Бинарные данные аудиофайла"
+// @Success      200  {string}  binary  "Бинарные данные аудиофайла, ссылка на сохранённое аудио — в заголовке X-Audio-URL"
 // @Failure      400  {object}  PlaceErrorResponse
 // @Failure      500  {object}  PlaceErrorResponse
 // @Router       /audio/generate [post]
@@ -61,14 +72,31 @@ func (c *PlaceController) GenerateAudioFromText(ctx *gin.Context) {
 		return
 	}
 
-	audioData, err := c.Service.AudioGenerate(request.Message)
+	opts := tts.Options{
+		Voice:    request.Voice,
+		Language: request.Language,
+		Speed:    request.Speed,
+		Format:   request.Format,
+	}
+	// Без кеша аудио отдаём результат синтеза напрямую
+	if c.Service.Audio == nil {
+		audio, err := c.Service.SynthesizeAudio(ctx.Request.Context(), request.Provider, request.Message, opts)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, PlaceErrorResponse{Error: "Ошибка генерации: " + err.Error()})
+			return
+		}
+		ctx.Data(http.StatusOK, audio.ContentType, audio.Data)
+		return
+	}
+
+	asset, err := c.Service.CachedAudio(ctx.Request.Context(), request.Provider, request.Message, opts)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, PlaceErrorResponse{Error: "Ошибка генерации: " + err.Error()})
 		return
 	}
 
 	// Отправляем аудио как бинарный поток
-	ctx.JSON(http.StatusOK, audioData)
+	serveAudio(ctx, c.Service.Audio, asset)
 }
 
 // ProcessJSON godoc
@@ -79,7 +107,7 @@ func (c *PlaceController) GenerateAudioFromText(ctx *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        input  body      dto.ProcessPlacesDTO  true  "JSON-файл с местами"
-// @Success      200    {array}   map[string]interface{}
+// @Success      200    {array}   dto.PlaceResult
 // @Failure      400    {object}  PlaceErrorResponse
 // @Failure      500    {object}  PlaceErrorResponse
 // @Router       /process-json [post]
@@ -114,12 +142,12 @@ func (c *PlaceController) GenerateAudioFromText(ctx *gin.Context) {
 
 // GetCachedResponse godoc
 // @Summary      Получить закешированный ответ
-// @Description  Возвращает закешированный ответ из Redis для конкретного пользователя и места
+// @Description  Возвращает закешированный ответ из Redis: персональный вариант для предпочтений пользователя или общее описание места
 // @Tags         places
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        input  body      dto.AddPlaceDTO  true  "Запрос с названием места"
+// @Param        input  body      dto.CachedResponseDTO  true  "Запрос с названием места и, при наличии, объектом OSM"
 // @Success      200    {object}  PlaceErrorResponse "Верный формат"
 // @Failure      400    {object}  PlaceErrorResponse "Неверный формат запроса"
 // @Failure      401    {object}  PlaceErrorResponse "Пользователь не авторизован"
@@ -127,7 +155,7 @@ func (c *PlaceController) GenerateAudioFromText(ctx *gin.Context) {
 // @Failure      500    {object}  PlaceErrorResponse "Ошибка сервера"
 // @Router       /cached-response [post]
 func (c *PlaceController) GetCachedResponse(ctx *gin.Context) {
-	var input dto.AddPlaceDTO
+	var input dto.CachedResponseDTO
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат запроса"})
 		return
@@ -138,6 +166,15 @@ func (c *PlaceController) GetCachedResponse(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Поле 'place_name' обязательно"})
 		return
 	}
+	// Без языка в запросе используется язык из профиля пользователя
+	if input.Language != "" {
+		language, err := services.ResolveLanguage(input.Language)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.Language = language
+	}
 
 	// Извлекаем userID из JWT-токена
 	userID, exists := ctx.Get("userID")
@@ -157,7 +194,7 @@ func (c *PlaceController) GetCachedResponse(ctx *gin.Context) {
 	println("Получен запрос на поиск закешированного ответа для пользователя:", userIDUint, "и места:", input.PlaceName)
 
 	// Получаем закешированный ответ
-	response, err := c.Service.GetCachedResponse(userIDUint, input.PlaceName)
+	response, err := c.Service.GetCachedResponse(ctx.Request.Context(), userIDUint, input)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -169,25 +206,35 @@ func (c *PlaceController) GetCachedResponse(ctx *gin.Context) {
 
 // ProcessJSONNoAuth godoc
 // @Summary      Обработать JSON-файл с местами
-// @Description  Обрабатывает JSON-файл с объектами мест и отправляет их на заглушку
+// @Description  Обрабатывает объекты мест и отправляет их LLM-провайдеру из конфигурации.
+// @Description  Принимает {"json_data": [...]}, ответ Overpass API в JSON ({"elements": [...]}) или OSM XML (application/xml)
 // @Tags         places
-// @Accept       json
+// @Accept       json,xml
 // @Produce      json
+// @Param        provider  query  string  false  "Имя LLM-провайдера (fastapi, mistral, openai)"
+// @Param        audience  query  string  false  "Аудитория для варианта шаблона промпта, например kids"
+// @Param        lang      query  string  false  "Язык описаний (ru, en, de); без параметра — из Accept-Language"
 // @Param        input  body      dto.ProcessPlacesDTO  true  "JSON-файл с местами"
-// @Success      200    {array}   map[string]interface{}
-// @Failure      400    {object}  PlaceErrorResponse
+// @Success      200    {array}   dto.PlaceResult
+// @Failure      400    {object}  OSMInputErrorResponse
+// @Failure      413    {object}  OSMInputErrorResponse
 // @Failure      500    {object}  PlaceErrorResponse
 // @Router       /process-json-noauth [post]
 func (c *PlaceController) ProcessJSONNoAuth(ctx *gin.Context) {
-	var input dto.ProcessPlacesDTO
-	// Проверяем и парсим тело запроса
-	if err := ctx.ShouldBindJSON(&input); err != nil {
+	// Проверяем и разбираем тело запроса в формате, указанном в Content-Type
+	objects, ok := bindOSMObjects(ctx)
+	if !ok {
+		return
+	}
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, PlaceErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// Вызываем сервис для обработки JSON-файла
-	results, err := c.Service.ProcessJSONNoAuth(input.JSONData)
+	opts := services.DescribeOptions{Provider: ctx.Query("provider"), Audience: ctx.Query("audience"), Language: language}
+	results, err := c.Service.ProcessJSONNoAuth(ctx.Request.Context(), opts, objects)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, PlaceErrorResponse{Error: err.Error()})
 		return
@@ -198,25 +245,33 @@ func (c *PlaceController) ProcessJSONNoAuth(ctx *gin.Context) {
 
 // ProcessJSONMistral godoc
 // @Summary      Обработать JSON-файл с местами
-// @Description  Обрабатывает JSON-файл с объектами мест и отправляет их на Mistral
+// @Description  Обрабатывает объекты мест и отправляет их на Mistral.
+// @Description  Принимает {"json_data": [...]}, ответ Overpass API в JSON ({"elements": [...]}) или OSM XML (application/xml)
 // @Tags         places
-// @Accept       json
+// @Accept       json,xml
 // @Produce      json
+// @Param        lang   query     string  false  "Язык описаний (ru, en, de); без параметра — из Accept-Language"
 // @Param        input  body      dto.ProcessPlacesDTO  true  "JSON-файл с местами"
-// @Success      200    {array}   map[string]interface{}
-// @Failure      400    {object}  PlaceErrorResponse
+// @Success      200    {array}   dto.PlaceResult
+// @Failure      400    {object}  OSMInputErrorResponse
+// @Failure      413    {object}  OSMInputErrorResponse
 // @Failure      500    {object}  PlaceErrorResponse
 // @Router       /process-json-mistral [post]
 func (c *PlaceController) ProcessJSONMistral(ctx *gin.Context) {
-	var input dto.ProcessPlacesDTO
-	// Проверяем и парсим тело запроса
-	if err := ctx.ShouldBindJSON(&input); err != nil {
+	// Проверяем и разбираем тело запроса в формате, указанном в Content-Type
+	objects, ok := bindOSMObjects(ctx)
+	if !ok {
+		return
+	}
+
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, PlaceErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// Вызываем сервис для обработки JSON-файла
-	results, err := c.Service.ProcessJSONMistral(input.JSONData)
+	results, err := c.Service.ProcessJSONNoAuth(ctx.Request.Context(), services.DescribeOptions{Provider: "mistral", Language: language}, objects)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, PlaceErrorResponse{Error: err.Error()})
 		return
@@ -224,3 +279,32 @@ func (c *PlaceController) ProcessJSONMistral(ctx *gin.Context) {
 	// Возвращаем результаты
 	ctx.JSON(http.StatusOK, results)
 }
+
+// bindOSMObjects разбирает тело запроса с объектами OSM: {"json_data": [...]}, Overpass JSON или OSM XML.
+// При ошибке отвечает 400 со списком неверных элементов, если тело больше OSM_MAX_BODY_BYTES — 413
+func bindOSMObjects(ctx *gin.Context) ([]dto.OSMObject, bool) {
+	limit := osm.MaxInputBytes()
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, OSMInputErrorResponse{
+				Error: fmt.Sprintf("тело запроса больше %d байт", limit),
+			})
+			return nil, false
+		}
+		ctx.JSON(http.StatusBadRequest, OSMInputErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	objects, err := osm.Decode(ctx.GetHeader("Content-Type"), body)
+	if err != nil {
+		response := OSMInputErrorResponse{Error: err.Error()}
+		var validationErr *osm.ValidationError
+		if errors.As(err, &validationErr) {
+			response.Elements = validationErr.Elements
+		}
+		ctx.JSON(http.StatusBadRequest, response)
+		return nil, false
+	}
+	return objects, true
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobController — контроллер для асинхронных заданий на обработку мест
+type JobController struct {
+	Service *services.JobService
+}
+
+// CreateJob godoc
+// @Summary      Создать задание на обработку мест
+// @Description  Сохраняет задание и ставит каждое место в очередь. Результаты доступны по /jobs/{id}/results по мере готовности.
+// @Description  Принимает {"json_data": [...]}, ответ Overpass API в JSON ({"elements": [...]}) или OSM XML (application/xml)
+// @Tags         jobs
+// @Accept       json,xml
+// @Produce      json
+// @Param        provider  query  string  false  "Имя LLM-провайдера (fastapi, mistral, openai)"
+// @Param        lang      query  string  false  "Язык описаний (ru, en, de); без параметра — из Accept-Language"
+// @Param        input  body      dto.ProcessPlacesDTO  true  "JSON-файл с местами"
+// @Success      202    {object}  models.Job
+// @Failure      400    {object}  OSMInputErrorResponse
+// @Failure      413    {object}  OSMInputErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /jobs [post]
+func (c *JobController) CreateJob(ctx *gin.Context) {
+	objects, ok := bindOSMObjects(ctx)
+	if !ok {
+		return
+	}
+
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	job, err := c.Service.CreateJob(ctx.Request.Context(), ctx.Query("provider"), language, objects)
+	if errors.Is(err, services.ErrInvalidJob) {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/api/jobs/%d", job.ID))
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+// GetJob godoc
+// @Summary      Получить статус задания
+// @Description  Возвращает статус задания и количество обработанных, неудачных и пропущенных мест
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path      int  true  "ID задания"
+// @Success      200  {object}  models.Job
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /jobs/{id} [get]
+func (c *JobController) GetJob(ctx *gin.Context) {
+	job, err := c.Service.GetJob(parseUint(ctx.Param("id")))
+	if err != nil {
+		respondJobError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// GetJobResults godoc
+// @Summary      Получить результаты задания
+// @Description  Возвращает результаты по каждому месту в порядке входного массива, в том числе частичные
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path      int  true  "ID задания"
+// @Success      200  {array}   dto.JobTaskResultDTO
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /jobs/{id}/results [get]
+func (c *JobController) GetJobResults(ctx *gin.Context) {
+	results, err := c.Service.GetJobResults(parseUint(ctx.Param("id")))
+	if err != nil {
+		respondJobError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, results)
+}
+
+// respondJobError переводит ошибки заданий в HTTP-статусы
+func respondJobError(ctx *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
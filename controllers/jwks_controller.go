@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+	"new/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController — публикация открытых ключей подписи JWT для других сервисов
+type JWKSController struct{}
+
+// GetJWKS — GET /.well-known/jwks.json: открытые ключи RS256/EdDSA.
+// Маршрут вне /api, поэтому не описан в Swagger
+func (controller *JWKSController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.CurrentKeyring().JWKS())
+}
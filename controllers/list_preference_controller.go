@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"new/dto"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// ListPreferenceController — каталог интересов: публичный список и администрирование
+type ListPreferenceController struct {
+	Service *services.ListPreferenceService
+}
+
+// GetCatalog godoc
+// @Summary      Каталог интересов
+// @Description  Возвращает действующие элементы каталога в порядке отображения. Язык берется из lang или заголовка Accept-Language; при отсутствии перевода возвращается исходное название
+// @Tags         preferences
+// @Produce      json
+// @Param        lang  query  string  false  "Язык названий, например en"
+// @Success      200  {array}   dto.CatalogItemDTO
+// @Failure      400  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /preferences/catalog [get]
+func (c *ListPreferenceController) GetCatalog(ctx *gin.Context) {
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	items, err := c.Service.Catalog(language)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, items)
+}
+
+// ListCatalog godoc
+// @Summary      Каталог интересов (администрирование)
+// @Description  Возвращает все элементы каталога, включая выведенные, с переводами. Требует preferences:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Success      200  {array}   models.ListPreference
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog [get]
+func (c *ListPreferenceController) ListCatalog(ctx *gin.Context) {
+	items, err := c.Service.List()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+// CreateCatalogEntry godoc
+// @Summary      Добавить элемент каталога
+// @Description  Создает элемент каталога интересов, при необходимости с переводами. Требует preferences:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        input  body  dto.CreateListPreferenceDTO  true  "Элемент каталога"
+// @Success      201  {object}  models.ListPreference
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog [post]
+func (c *ListPreferenceController) CreateCatalogEntry(ctx *gin.Context) {
+	var input dto.CreateListPreferenceDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	item, err := c.Service.Create(input)
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, item)
+}
+
+// RenameCatalogEntry godoc
+// @Summary      Переименовать элемент каталога
+// @Description  Меняет исходное название элемента. Требует preferences:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id     path  int                          true  "ID элемента"
+// @Param        input  body  dto.RenameListPreferenceDTO  true  "Новое название"
+// @Success      200  {object}  models.ListPreference
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/{id} [patch]
+func (c *ListPreferenceController) RenameCatalogEntry(ctx *gin.Context) {
+	var input dto.RenameListPreferenceDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	item, err := c.Service.Rename(parseUint(ctx.Param("id")), input.Name)
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// SetCatalogTags godoc
+// @Summary      Теги OSM элемента каталога
+// @Description  Задает условия на теги OSM (например historic=*,tourism=museum|gallery), по которым места ранжируются по интересам пользователя. Требует preferences:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id     path  int                        true  "ID элемента"
+// @Param        input  body  dto.ListPreferenceTagsDTO  true  "Условия на теги"
+// @Success      200  {object}  models.ListPreference
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/{id}/tags [put]
+func (c *ListPreferenceController) SetCatalogTags(ctx *gin.Context) {
+	var input dto.ListPreferenceTagsDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	item, err := c.Service.SetTags(parseUint(ctx.Param("id")), input.OSMTags)
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// ReorderCatalog godoc
+// @Summary      Изменить порядок каталога
+// @Description  Ставит перечисленные элементы в начало в указанном порядке, остальные — следом. Требует preferences:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        input  body  dto.ReorderListPreferencesDTO  true  "Порядок ID"
+// @Success      200  {array}   models.ListPreference
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/order [put]
+func (c *ListPreferenceController) ReorderCatalog(ctx *gin.Context) {
+	var input dto.ReorderListPreferencesDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	items, err := c.Service.Reorder(input.IDs)
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+// SetCatalogTranslations godoc
+// @Summary      Переводы элемента каталога
+// @Description  Добавляет или меняет названия по языкам; пустое название удаляет перевод. Требует preferences:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id     path  int                                true  "ID элемента"
+// @Param        input  body  dto.ListPreferenceTranslationsDTO  true  "Переводы"
+// @Success      200  {object}  models.ListPreference
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/{id}/translations [put]
+func (c *ListPreferenceController) SetCatalogTranslations(ctx *gin.Context) {
+	var input dto.ListPreferenceTranslationsDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	item, err := c.Service.SetTranslations(parseUint(ctx.Param("id")), input.Translations)
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// RetireCatalogEntry godoc
+// @Summary      Вывести элемент из каталога
+// @Description  Скрывает элемент из публичного каталога; уже выбранные пользователями предпочтения сохраняются. Требует preferences:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        id  path  int  true  "ID элемента"
+// @Success      200  {object}  models.ListPreference
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/{id} [delete]
+func (c *ListPreferenceController) RetireCatalogEntry(ctx *gin.Context) {
+	item, err := c.Service.Retire(parseUint(ctx.Param("id")))
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// RestoreCatalogEntry godoc
+// @Summary      Вернуть элемент в каталог
+// @Description  Отменяет вывод элемента из каталога. Требует preferences:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        id  path  int  true  "ID элемента"
+// @Success      200  {object}  models.ListPreference
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/preferences/catalog/{id}/restore [post]
+func (c *ListPreferenceController) RestoreCatalogEntry(ctx *gin.Context) {
+	item, err := c.Service.Restore(parseUint(ctx.Param("id")))
+	if err != nil {
+		respondCatalogError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// respondCatalogError переводит ошибки каталога в HTTP-статусы
+func respondCatalogError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrCatalogNameTaken):
+		ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrCatalogNameRequired), errors.Is(err, services.ErrInvalidLanguage), errors.Is(err, services.ErrInvalidTags):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"new/dto"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NearbyController — контроллер поиска уже описанных мест рядом с точкой
+type NearbyController struct {
+	Service *services.OSMPlaceService
+}
+
+// GetNearbyPlaces godoc
+// @Summary      Места рядом
+// @Description  Возвращает уже обработанные места в радиусе от точки с сохранёнными описаниями и ссылками на аудио, ближайшие первыми. Язык берётся из lang или Accept-Language
+// @Tags         places
+// @Produce      json
+// @Param        lat       query  number  true   "Широта"
+// @Param        lon       query  number  true   "Долгота"
+// @Param        radius    query  number  false  "Радиус в метрах (по умолчанию 500, не больше 5000)"
+// @Param        lang      query  string  false  "Язык описаний (ru, en, pt-br)"
+// @Param        provider  query  string  false  "Только описания этого LLM-провайдера"
+// @Param        limit     query  int     false  "Максимум мест (по умолчанию 50, не больше 200)"
+// @Success      200  {array}   dto.NearbyPlace
+// @Failure      400  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /places/nearby [get]
+func (c *NearbyController) GetNearbyPlaces(ctx *gin.Context) {
+	lat, latErr := strconv.ParseFloat(ctx.Query("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(ctx.Query("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "параметры lat и lon обязательны"})
+		return
+	}
+	radius, err := strconv.ParseFloat(ctx.DefaultQuery("radius", "0"), 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "некорректный радиус"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "0"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "некорректный лимит"})
+		return
+	}
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	places, err := c.Service.Nearby(ctx.Request.Context(), dto.NearbyQuery{
+		Lat:      lat,
+		Lon:      lon,
+		Radius:   radius,
+		Language: language,
+		Provider: ctx.Query("provider"),
+		Limit:    limit,
+	})
+	if errors.Is(err, services.ErrInvalidNearbyQuery) || errors.Is(err, services.ErrInvalidLanguage) {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, places)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"new/dto"
@@ -30,6 +31,8 @@ type PreferenceController struct {
 // @Param        input  body      dto.CreatePreferenceDTO  true  "Данные предпочтения"
 // @Success      201    {object}  models.Preference
 // @Failure      400    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse  "Элемент каталога не найден или выведен"
+// @Failure      409    {object}  ErrorResponse  "Предпочтение уже выбрано"
 // @Failure      500    {object}  ErrorResponse
 // @Router       /preferences [post]
 func (c *PreferenceController) CreatePreference(ctx *gin.Context) {
@@ -57,7 +60,13 @@ func (c *PreferenceController) CreatePreference(ctx *gin.Context) {
 
 	// Вызываем сервис для создания предпочтения
 	preference, err := c.Service_prefernse.CreatePreference(userIDUint, input)
-	if err != nil {
+	if errors.Is(err, services.ErrCatalogEntryNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrPreferenceExists) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"new/dto"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// PromptController — администрирование шаблонов промптов
+type PromptController struct {
+	Service *services.PromptTemplateService
+}
+
+// ListPrompts godoc
+// @Summary      Шаблоны промптов
+// @Description  Возвращает все версии шаблонов промптов, новые версии первыми. Требует prompts:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        name  query  string  false  "Имя шаблона, например describe"
+// @Success      200  {array}   models.PromptTemplate
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/prompts [get]
+func (c *PromptController) ListPrompts(ctx *gin.Context) {
+	templates, err := c.Service.List(ctx.Query("name"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, templates)
+}
+
+// CreatePrompt godoc
+// @Summary      Новая версия шаблона промпта
+// @Description  Сохраняет новую версию варианта шаблона (имя, язык, аудитория). Шаблон проверяется на тестовых данных. Доступны переменные .Name, .Address, .City, .Tags, .Interests, .Language, .Audience и функции join, lower, upper, default, tags. Требует prompts:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        input  body  dto.CreatePromptTemplateDTO  true  "Шаблон"
+// @Success      201  {object}  models.PromptTemplate
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/prompts [post]
+func (c *PromptController) CreatePrompt(ctx *gin.Context) {
+	var input dto.CreatePromptTemplateDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tpl, err := c.Service.Create(ctx.GetUint("userID"), input)
+	if err != nil {
+		respondPromptError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, tpl)
+}
+
+// ActivatePrompt godoc
+// @Summary      Активировать версию шаблона
+// @Description  Делает версию активной для её варианта; новые описания будут кешироваться под этой версией. Требует prompts:manage
+// @Tags         admin
+// @Produce      json
+// @Security BearerAuth
+// @Param        id  path  int  true  "ID версии шаблона"
+// @Success      200  {object}  models.PromptTemplate
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/prompts/{id}/activate [post]
+func (c *PromptController) ActivatePrompt(ctx *gin.Context) {
+	tpl, err := c.Service.Activate(parseUint(ctx.Param("id")))
+	if err != nil {
+		respondPromptError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tpl)
+}
+
+// PreviewPrompt godoc
+// @Summary      Предпросмотр шаблона промпта
+// @Description  Подставляет данные места в сохранённую версию или переданный текст шаблона, не обращаясь к LLM. Требует prompts:manage
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        input  body  dto.PreviewPromptDTO  true  "Шаблон и данные"
+// @Success      200  {object}  dto.PreviewPromptResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /admin/prompts/preview [post]
+func (c *PromptController) PreviewPrompt(ctx *gin.Context) {
+	var input dto.PreviewPromptDTO
+	if err := ctx.ShouldBindBodyWith(&input, binding.JSON); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	text, err := c.Service.Preview(input)
+	if err != nil {
+		respondPromptError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.PreviewPromptResponse{Prompt: text})
+}
+
+// respondPromptError переводит ошибки шаблонов в HTTP-статусы
+func respondPromptError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidTemplate), errors.Is(err, services.ErrInvalidLanguage):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
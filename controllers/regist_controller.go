@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"new/dto"
 	"new/services"
+	"new/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
 )
 
 // ErrorResponse — структура для ответа об ошибке
@@ -16,13 +20,27 @@ type ErrorResponse struct {
 
 // TokenResponse — структура для ответа с токеном
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`         // Access-токен
+	RefreshToken string `json:"refresh_token"` // Одноразовый refresh-токен
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Время жизни access-токена в секундах
+}
+
+// newTokenResponse формирует ответ из пары токенов
+func newTokenResponse(pair *services.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(pair.ExpiresAt).Seconds()),
+	}
 }
 
 // RegistController — контроллер для обработки запросов на регистрацию и вход
 type RegistController struct {
 	Service_regist *services.RegistService
 	Service_auth   *services.AuthService
+	Service_tokens *services.TokenService
 }
 
 // RegisterUser godoc
@@ -44,7 +62,10 @@ func (controller *RegistController) RegisterUser(c *gin.Context) {
 	}
 
 	user, err := controller.Service_regist.RegisterUser(userDTO)
-	if err != nil {
+	if errors.Is(err, services.ErrInvalidLanguage) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -59,7 +80,7 @@ func (controller *RegistController) RegisterUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param login body dto.LoginDTO true "User login data"
-// @Success 200 {object} TokenResponse "JWT token"
+// @Success 200 {object} TokenResponse "Access and refresh tokens"
 // @Failure 400 {object} ErrorResponse "Invalid input"
 // @Failure 401 {object} ErrorResponse "Unauthorized - invalid credentials"
 // @Router /login [post]
@@ -70,12 +91,119 @@ func (controller *RegistController) LoginUser(c *gin.Context) {
 		return
 	}
 
-	token, err := controller.Service_auth.AuthenticateUser(loginDTO)
+	pair, err := controller.Service_auth.AuthenticateUser(loginDTO)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Теперь возвращаем TokenResponse вместо gin.H
-	c.JSON(http.StatusOK, TokenResponse{Token: token})
+	c.JSON(http.StatusOK, newTokenResponse(pair))
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access/refresh pair. Each refresh token can be used once; reusing it revokes the whole token family
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body dto.RefreshTokenDTO true "Refresh token"
+// @Success 200 {object} TokenResponse "New access and refresh tokens"
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /token/refresh [post]
+func (controller *RegistController) RefreshToken(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+	if err := c.ShouldBindBodyWith(&refreshDTO, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	pair, err := controller.Service_tokens.Refresh(refreshDTO.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newTokenResponse(pair))
+}
+
+// Logout godoc
+// @Summary Logout user
+// @Description Revoke the current access token and its refresh token family
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logout body dto.LogoutDTO false "Refresh token to revoke"
+// @Success 204 "Logged out"
+// @Failure 400 {object} ErrorResponse "Invalid input"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /logout [post]
+func (controller *RegistController) Logout(c *gin.Context) {
+	var logoutDTO dto.LogoutDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindBodyWith(&logoutDTO, binding.JSON); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	claims, ok := c.MustGet("tokenClaims").(*utils.AccessClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid token"})
+		return
+	}
+
+	if err := controller.Service_tokens.Logout(claims, logoutDTO.RefreshToken); err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetLocale godoc
+// @Summary Set user locale
+// @Description Set the default language of place descriptions and audio for the current user. An empty locale resets it to the server default
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param locale body dto.LocaleDTO true "Language code, e.g. en or pt-br"
+// @Success 200 {object} models.User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid language code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /users/locale [put]
+func (controller *RegistController) SetLocale(c *gin.Context) {
+	var localeDTO dto.LocaleDTO
+	if err := c.ShouldBindBodyWith(&localeDTO, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := controller.Service_regist.SetLocale(c.GetUint("userID"), localeDTO.Locale)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidLanguage):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
 }
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"new/dto"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TourController — контроллер построения пеших экскурсий
+type TourController struct {
+	Service *services.PlaceService
+}
+
+// CreateTour godoc
+// @Summary      Построить пешую экскурсию
+// @Description  Отбирает места по интересам пользователя и упорядочивает их в маршрут в пределах бюджета времени или расстояния (ближайший сосед и 2-opt). Для каждой остановки возвращает описание и аудио, между остановками — текст и аудио перехода. Места берутся из тела запроса (те же форматы, что у /process-json-noauth), а без тела — из уже описанных мест в радиусе от lat/lon. Без бюджета экскурсия рассчитывается на 45 минут
+// @Tags         tours
+// @Accept       json,xml
+// @Produce      json
+// @Security     BearerAuth
+// @Param        input         body   dto.ProcessPlacesDTO  false  "Объекты OSM"
+// @Param        lat           query  number  false  "Широта начальной точки (обязательна без тела запроса)"
+// @Param        lon           query  number  false  "Долгота начальной точки (обязательна без тела запроса)"
+// @Param        radius        query  number  false  "Радиус поиска сохранённых мест, м (по умолчанию 1000)"
+// @Param        duration      query  number  false  "Бюджет времени, мин"
+// @Param        distance      query  number  false  "Бюджет длины маршрута, м"
+// @Param        stop_minutes  query  number  false  "Время на одной остановке, мин (по умолчанию 5)"
+// @Param        max_stops     query  int     false  "Максимум остановок (по умолчанию 10, не больше 20)"
+// @Param        lang          query  string  false  "Язык описаний; по умолчанию язык из профиля"
+// @Success      200  {object}  dto.Tour
+// @Failure      400  {object}  OSMInputErrorResponse
+// @Failure      413  {object}  OSMInputErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tours [post]
+func (c *TourController) CreateTour(ctx *gin.Context) {
+	query, err := tourQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var objects []dto.OSMObject
+	if ctx.Request.ContentLength != 0 {
+		var ok bool
+		if objects, ok = bindOSMObjects(ctx); !ok {
+			return
+		}
+	}
+
+	tour, err := c.Service.PlanTour(ctx.Request.Context(), ctx.GetUint("userID"), query, objects)
+	if errors.Is(err, services.ErrInvalidTour) || errors.Is(err, services.ErrInvalidLanguage) || errors.Is(err, services.ErrInvalidNearbyQuery) {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, tour)
+}
+
+// tourQuery читает параметры экскурсии из строки запроса
+func tourQuery(ctx *gin.Context) (dto.TourQuery, error) {
+	language, err := services.RequestLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+	if err != nil {
+		return dto.TourQuery{}, err
+	}
+	query := dto.TourQuery{Language: language}
+
+	if ctx.Query("lat") != "" || ctx.Query("lon") != "" {
+		lat, latErr := strconv.ParseFloat(ctx.Query("lat"), 64)
+		lon, lonErr := strconv.ParseFloat(ctx.Query("lon"), 64)
+		if latErr != nil || lonErr != nil {
+			return query, errors.New("lat and lon must be numbers")
+		}
+		query.Start = &dto.Coordinates{Lat: lat, Lon: lon}
+	}
+
+	numbers := map[string]*float64{
+		"radius":       &query.Radius,
+		"duration":     &query.DurationMinutes,
+		"distance":     &query.DistanceMeters,
+		"stop_minutes": &query.StopMinutes,
+	}
+	for name, target := range numbers {
+		value, err := strconv.ParseFloat(ctx.DefaultQuery(name, "0"), 64)
+		if err != nil {
+			return query, errors.New("invalid " + name)
+		}
+		*target = value
+	}
+
+	maxStops, err := strconv.Atoi(ctx.DefaultQuery("max_stops", "0"))
+	if err != nil {
+		return query, errors.New("invalid max_stops")
+	}
+	query.MaxStops = maxStops
+	return query, nil
+}
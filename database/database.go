@@ -1,9 +1,8 @@
 package database
 
 import (
-	//"fmt"
+	"fmt"
 	"log"
-	"new/models"
 	"os"
 	"time"
 
@@ -15,9 +14,41 @@ import (
 // Глобальная переменная для хранения подключения
 var db *gorm.DB
 
-// InitDB инициализирует подключение к базе данных PostgreSQL
-
+// InitDB инициализирует подключение к базе данных PostgreSQL и проверяет версию схемы.
+// Сервер не запускается, если схема новее кода. Неприменённые миграции накатываются
+// автоматически, если не задано DB_AUTO_MIGRATE=false. Проверка и миграции идут под блокировкой,
+// поэтому одновременно запущенные экземпляры не применяют миграции дважды
 func InitDB() {
+	Connect()
+
+	err := WithMigrationLock(db, func(conn *gorm.DB) error {
+		pending, err := CheckSchema(conn)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки схемы: %v", err)
+		}
+		if pending == 0 {
+			return nil
+		}
+		if os.Getenv("DB_AUTO_MIGRATE") == "false" {
+			return fmt.Errorf("схема базы данных устарела: не применено миграций: %d. Выполните migrate up", pending)
+		}
+
+		applied, err := MigrateUp(conn)
+		for _, m := range applied {
+			log.Printf("Применена миграция %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка миграции: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// Connect открывает подключение к базе данных без проверки схемы
+func Connect() {
 
 	errr := godotenv.Load()
 	if errr != nil {
@@ -40,16 +71,11 @@ func InitDB() {
 
 	// Открытие подключения к базе данных PostgreSQL
 	var err error
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError превращает нарушения уникальных индексов в gorm.ErrDuplicatedKey
+	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Ошибка подключения к базе данных: %v", err)
 	}
-
-	//fmt.Println("Подключение к базе данных успешно установлено!") // Выводим сообщение об успешном подключении
-	err = db.AutoMigrate(&models.User{}, &models.Preference{}, &models.Place{}, &models.ListPreference{})
-	if err != nil {
-		log.Fatalf("Ошибка миграции: %v", err)
-	}
 }
 
 // GetDB возвращает объект подключения к базе данных
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Файлы миграций встраиваются в бинарник: NNNN_name.up.sql и NNNN_name.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migrationLockKey — ключ advisory-блокировки PostgreSQL, под которой проверяется и обновляется схема
+const migrationLockKey int64 = 0x6d6967726174 // "migrat"
+
+// Migration — одна версия схемы базы данных
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState — состояние миграции в базе данных
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration — запись о применённой миграции
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations читает встроенные миграции, отсортированные по версии
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная версия миграции %s: %v", entry.Name(), err)
+		}
+		body, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("у версии %d разные имена миграций: %s и %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("для миграции %d нет файла up", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureMigrationsTable создаёт таблицу schema_migrations при первом запуске
+func ensureMigrationsTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+}
+
+// appliedMigrations возвращает применённые миграции по версиям
+func appliedMigrations(db *gorm.DB) (map[int64]schemaMigration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("ошибка создания schema_migrations: %v", err)
+	}
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// WithMigrationLock выполняет fn под advisory-блокировкой PostgreSQL на одном соединении,
+// чтобы одновременно запущенные экземпляры не применяли одни и те же миграции. Остальные ждут
+// снятия блокировки и видят уже обновленную схему. Для других СУБД fn выполняется без блокировки
+func WithMigrationLock(db *gorm.DB, fn func(db *gorm.DB) error) error {
+	if db.Dialector.Name() != "postgres" {
+		return fn(db)
+	}
+	return db.Connection(func(conn *gorm.DB) (err error) {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("ошибка блокировки миграций: %v", err)
+		}
+		defer func() {
+			if unlockErr := conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error; unlockErr != nil && err == nil {
+				err = fmt.Errorf("ошибка снятия блокировки миграций: %v", unlockErr)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// MigrateUp применяет все неприменённые миграции, каждую в своей транзакции
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("ошибка миграции %04d_%s: %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown откатывает последние steps применённых миграций
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("миграция %04d_%s не поддерживает откат", m.Version, m.Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("ошибка отката %04d_%s: %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrationStatus возвращает состояние всех известных миграций
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			state.Applied = true
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// CheckSchema сверяет версию схемы с миграциями в коде.
+// Возвращает ошибку, если в базе применены миграции, о которых код не знает,
+// и количество ещё не применённых миграций
+func CheckSchema(db *gorm.DB) (pending int, err error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		if _, ok := applied[m.Version]; !ok {
+			pending++
+		}
+	}
+	for version, row := range applied {
+		if !known[version] {
+			return pending, fmt.Errorf("схема базы данных новее кода: применена неизвестная миграция %04d_%s", version, row.Name)
+		}
+	}
+	return pending, nil
+}
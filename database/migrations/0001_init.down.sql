@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS places;
+DROP TABLE IF EXISTS preferences;
+DROP TABLE IF EXISTS list_preferences;
+DROP TABLE IF EXISTS users;
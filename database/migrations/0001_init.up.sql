@@ -0,0 +1,28 @@
+-- Базовая схема. IF NOT EXISTS позволяет принять базы, созданные раньше через AutoMigrate
+CREATE TABLE IF NOT EXISTS users (
+    id       BIGSERIAL PRIMARY KEY,
+    username TEXT UNIQUE,
+    password TEXT,
+    email    TEXT UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS list_preferences (
+    id   BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS preferences (
+    id                 BIGSERIAL PRIMARY KEY,
+    user_id            BIGINT NOT NULL REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    list_preference_id BIGINT NOT NULL REFERENCES list_preferences (id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_preferences_user_id ON preferences (user_id);
+CREATE INDEX IF NOT EXISTS idx_preferences_list_preference_id ON preferences (list_preference_id);
+
+CREATE TABLE IF NOT EXISTS places (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    place_name TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_places_user_id ON places (user_id);
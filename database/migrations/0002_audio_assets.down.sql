@@ -0,0 +1 @@
+DROP TABLE IF EXISTS audio_assets;
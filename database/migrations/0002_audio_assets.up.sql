@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audio_assets (
+    id           BIGSERIAL PRIMARY KEY,
+    hash         VARCHAR(64) NOT NULL,
+    provider     TEXT,
+    voice        TEXT,
+    language     TEXT,
+    format       TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size         BIGINT,
+    storage_key  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audio_assets_hash ON audio_assets (hash);
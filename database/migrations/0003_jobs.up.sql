@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id          BIGSERIAL PRIMARY KEY,
+    provider    TEXT,
+    status      TEXT NOT NULL,
+    total       BIGINT,
+    done        BIGINT,
+    failed      BIGINT,
+    skipped     BIGINT,
+    created_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+
+CREATE TABLE IF NOT EXISTS job_tasks (
+    id         BIGSERIAL PRIMARY KEY,
+    job_id     BIGINT NOT NULL REFERENCES jobs (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    position   BIGINT,
+    osm_type   TEXT,
+    osm_id     BIGINT,
+    input      TEXT NOT NULL,
+    status     TEXT NOT NULL,
+    result     TEXT,
+    error      TEXT,
+    attempts   BIGINT,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_job_tasks_job_id ON job_tasks (job_id);
+CREATE INDEX IF NOT EXISTS idx_job_tasks_status ON job_tasks (status);
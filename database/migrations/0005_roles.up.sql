@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS user_permissions (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_permissions_user_permission ON user_permissions (user_id, permission);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS list_preference_translations;
+ALTER TABLE list_preferences DROP COLUMN IF EXISTS retired_at;
+ALTER TABLE list_preferences DROP COLUMN IF EXISTS position;
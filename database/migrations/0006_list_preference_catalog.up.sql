@@ -0,0 +1,11 @@
+ALTER TABLE list_preferences ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE list_preferences ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
+UPDATE list_preferences SET position = id WHERE position = 0;
+
+CREATE TABLE IF NOT EXISTS list_preference_translations (
+    id                 BIGSERIAL PRIMARY KEY,
+    list_preference_id BIGINT NOT NULL REFERENCES list_preferences (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    language           VARCHAR(16) NOT NULL,
+    name               TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_preference_translations_lang ON list_preference_translations (list_preference_id, language);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_preferences_user_list_preference;
+ALTER TABLE list_preferences DROP COLUMN IF EXISTS osm_tags;
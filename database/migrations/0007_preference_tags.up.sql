@@ -0,0 +1,10 @@
+-- Теги OSM, по которым элемент каталога сопоставляется с объектами (например historic=*,tourism=museum)
+ALTER TABLE list_preferences ADD COLUMN IF NOT EXISTS osm_tags TEXT NOT NULL DEFAULT '';
+
+-- Повторно выбранные предпочтения удаляются, дальше дубли запрещены индексом
+DELETE FROM preferences p
+USING preferences d
+WHERE p.user_id = d.user_id
+  AND p.list_preference_id = d.list_preference_id
+  AND p.id > d.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_preferences_user_list_preference ON preferences (user_id, list_preference_id);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(64) NOT NULL,
+    language   VARCHAR(16) NOT NULL DEFAULT '',
+    audience   VARCHAR(32) NOT NULL DEFAULT '',
+    version    INTEGER NOT NULL,
+    body       TEXT NOT NULL,
+    active     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by BIGINT REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_templates_version ON prompt_templates (name, language, audience, version);
+-- Активной может быть только одна версия варианта
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_templates_active ON prompt_templates (name, language, audience) WHERE active;
+
+INSERT INTO prompt_templates (name, language, audience, version, body, active)
+VALUES ('describe', '', '', 1,
+'Ты — экскурсовод. Кратко и интересно расскажи о месте по его данным из OpenStreetMap. Не выдумывай факты, которых нет в данных, и не используй разметку.
+{{- if .Interests}} Слушателю интересно: {{join .Interests ", "}}. Сделай акцент на том, что связано с этими темами.{{end}}',
+TRUE)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE FROM prompt_templates
+WHERE name = 'describe' AND language = '' AND audience = '' AND version = 2 AND created_by IS NULL;
+UPDATE prompt_templates SET active = TRUE
+WHERE name = 'describe' AND language = '' AND audience = '' AND version = 1
+  AND NOT EXISTS (SELECT 1 FROM prompt_templates WHERE name = 'describe' AND language = '' AND audience = '' AND active);
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS language;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
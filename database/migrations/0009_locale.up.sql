@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS language VARCHAR(16) NOT NULL DEFAULT '';
+
+-- Вторая версия шаблона по умолчанию просит отвечать на языке запроса.
+-- Если администратор уже создал свои версии, шаблоны не меняются
+UPDATE prompt_templates SET active = FALSE
+WHERE name = 'describe' AND language = '' AND audience = '' AND version = 1 AND active
+  AND NOT EXISTS (SELECT 1 FROM prompt_templates WHERE name = 'describe' AND language = '' AND audience = '' AND version > 1);
+
+INSERT INTO prompt_templates (name, language, audience, version, body, active)
+SELECT 'describe', '', '', 2,
+'Ты — экскурсовод. Кратко и интересно расскажи о месте по его данным из OpenStreetMap. Не выдумывай факты, которых нет в данных, и не используй разметку.
+{{- if .Interests}} Слушателю интересно: {{join .Interests ", "}}. Сделай акцент на том, что связано с этими темами.{{end}}
+{{- if .Language}} Отвечай на языке с кодом {{.Language}}.{{end}}',
+NOT EXISTS (SELECT 1 FROM prompt_templates WHERE name = 'describe' AND language = '' AND audience = '' AND active)
+WHERE NOT EXISTS (SELECT 1 FROM prompt_templates WHERE name = 'describe' AND language = '' AND audience = '' AND version > 1);
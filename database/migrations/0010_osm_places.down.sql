@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS osm_place_descriptions;
+DROP TABLE IF EXISTS osm_places;
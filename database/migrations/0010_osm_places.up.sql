@@ -0,0 +1,31 @@
+-- Обработанные места с координатами, общие для всех пользователей (в отличие от истории places)
+CREATE TABLE IF NOT EXISTS osm_places (
+    id         BIGSERIAL PRIMARY KEY,
+    osm_type   VARCHAR(16) NOT NULL,
+    osm_id     BIGINT NOT NULL,
+    name       TEXT NOT NULL,
+    address    TEXT,
+    city       TEXT,
+    lat        DOUBLE PRECISION NOT NULL,
+    lon        DOUBLE PRECISION NOT NULL,
+    geohash    VARCHAR(12) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_osm_places_osm ON osm_places (osm_type, osm_id);
+-- text_pattern_ops позволяет искать по префиксу геохеша (LIKE 'abc%') при любой локали базы
+CREATE INDEX IF NOT EXISTS idx_osm_places_geohash ON osm_places (geohash text_pattern_ops);
+
+-- Описания мест: по одному на язык и LLM-провайдера
+CREATE TABLE IF NOT EXISTS osm_place_descriptions (
+    id             BIGSERIAL PRIMARY KEY,
+    place_id       BIGINT NOT NULL REFERENCES osm_places (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    language       VARCHAR(16) NOT NULL,
+    provider       TEXT NOT NULL,
+    name           TEXT,
+    prompt_version TEXT,
+    text           TEXT NOT NULL,
+    audio_hash     VARCHAR(64),
+    updated_at     TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_osm_place_descriptions_key ON osm_place_descriptions (place_id, language, provider);
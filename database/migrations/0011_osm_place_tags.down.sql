@@ -0,0 +1 @@
+ALTER TABLE osm_places DROP COLUMN IF EXISTS tags;
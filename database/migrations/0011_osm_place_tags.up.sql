@@ -0,0 +1,2 @@
+-- Теги OSM нужны, чтобы отбирать сохраненные места по интересам пользователя
+ALTER TABLE osm_places ADD COLUMN IF NOT EXISTS tags TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+ALTER TABLE job_tasks ALTER COLUMN attempts DROP NOT NULL, ALTER COLUMN attempts DROP DEFAULT;
+ALTER TABLE jobs
+    ALTER COLUMN total DROP NOT NULL, ALTER COLUMN total DROP DEFAULT,
+    ALTER COLUMN done DROP NOT NULL, ALTER COLUMN done DROP DEFAULT,
+    ALTER COLUMN failed DROP NOT NULL, ALTER COLUMN failed DROP DEFAULT,
+    ALTER COLUMN skipped DROP NOT NULL, ALTER COLUMN skipped DROP DEFAULT;
@@ -0,0 +1,11 @@
+-- Счетчики заданий и попыток увеличиваются выражением "x + 1", поэтому не могут быть NULL
+UPDATE jobs SET total = COALESCE(total, 0), done = COALESCE(done, 0), failed = COALESCE(failed, 0), skipped = COALESCE(skipped, 0)
+WHERE total IS NULL OR done IS NULL OR failed IS NULL OR skipped IS NULL;
+ALTER TABLE jobs
+    ALTER COLUMN total SET DEFAULT 0, ALTER COLUMN total SET NOT NULL,
+    ALTER COLUMN done SET DEFAULT 0, ALTER COLUMN done SET NOT NULL,
+    ALTER COLUMN failed SET DEFAULT 0, ALTER COLUMN failed SET NOT NULL,
+    ALTER COLUMN skipped SET DEFAULT 0, ALTER COLUMN skipped SET NOT NULL;
+
+UPDATE job_tasks SET attempts = 0 WHERE attempts IS NULL;
+ALTER TABLE job_tasks ALTER COLUMN attempts SET DEFAULT 0, ALTER COLUMN attempts SET NOT NULL;
@@ -15,194 +15,164 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/ask": {
-            "post": {
-                "description": "Ввод текста, который будет передан ЛЛМ и возвращение ответа",
-                "consumes": [
-                    "application/json"
+        "/admin/cache": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает количество ключей Redis с префиксом и первые limit ключей с TTL. Требует cache:inspect",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "LLM"
+                    "admin"
                 ],
-                "summary": "Задать вопрос ЛЛМ",
+                "summary": "Просмотр кеша",
                 "parameters": [
                     {
-                        "description": "Question data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.InputQuestionDTO"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Вопрос ЛЛМ отправлен",
-                        "schema": {
-                            "$ref": "#/definitions/models.Question"
-                        }
+                        "type": "string",
+                        "description": "Префикс ключей, например llm:desc:",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
                     },
-                    "400": {
-                        "description": "Invalid input\" // Указание структуры ошибки",
-                        "schema": {
-                            "$ref": "#/definitions/controllers.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/audio/generate": {
-            "post": {
-                "description": "Генерирует аудиофайл в формате MP3",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/octet-stream"
-                ],
-                "tags": [
-                    "audio"
-                ],
-                "summary": "Сгенерировать аудио",
-                "parameters": [
                     {
-                        "description": "Текст для генерации аудио",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.AudioDTO"
-                        }
+                        "type": "integer",
+                        "description": "Максимум ключей в ответе (по умолчанию 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Бинарные данные аудиофайла",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dto.CacheInspectDTO"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/cached-response": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает закешированный ответ из Redis для конкретного пользователя и места",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Удаляет ключи Redis с префиксом. Требует cache:purge",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "places"
+                    "admin"
                 ],
-                "summary": "Получить закешированный ответ",
+                "summary": "Очистка кеша",
                 "parameters": [
                     {
-                        "description": "Запрос с названием места",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.AddPlaceDTO"
-                        }
+                        "type": "string",
+                        "description": "Префикс ключей, например llm:user:",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Верный формат",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/dto.PurgeResultDTO"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса",
-                        "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Пользователь не авторизован",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Ответ не найден в кеше",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Login a user by providing email and password, and return a JWT token",
-                "consumes": [
-                    "application/json"
+        "/admin/history": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Удаляет историю мест всех пользователей или одного пользователя, при необходимости только записи старше before. Требует history:purge",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Login user and return JWT token",
+                "summary": "Очистка истории",
                 "parameters": [
                     {
-                        "description": "User login data",
-                        "name": "login",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.LoginDTO"
-                        }
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Удалять записи старше этого момента (RFC 3339)",
+                        "name": "before",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "JWT token",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.TokenResponse"
+                            "$ref": "#/definitions/dto.PurgeResultDTO"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized - invalid credentials",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -210,31 +180,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/preferences": {
+        "/admin/preferences/catalog": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список предпочтений пользователя",
+                "description": "Возвращает все элементы каталога, включая выведенные, с переводами. Требует preferences:manage",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "preferences"
+                    "admin"
                 ],
-                "summary": "Получить предпочтения",
+                "summary": "Каталог интересов (администрирование)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Preference"
+                                "$ref": "#/definitions/models.ListPreference"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -249,7 +225,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Добавляет новое предпочтение для пользователя",
+                "description": "Создает элемент каталога интересов, при необходимости с переводами. Требует preferences:manage",
                 "consumes": [
                     "application/json"
                 ],
@@ -257,17 +233,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "preferences"
+                    "admin"
                 ],
-                "summary": "Добавить предпочтение",
+                "summary": "Добавить элемент каталога",
                 "parameters": [
                     {
-                        "description": "Данные предпочтения",
+                        "description": "Элемент каталога",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreatePreferenceDTO"
+                            "$ref": "#/definitions/dto.CreateListPreferenceDTO"
                         }
                     }
                 ],
@@ -275,7 +251,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Preference"
+                            "$ref": "#/definitions/models.ListPreference"
                         }
                     },
                     "400": {
@@ -284,6 +260,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -293,30 +275,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/preferences/{id}": {
-            "delete": {
+        "/admin/preferences/catalog/order": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет предпочтение пользователя по ID",
+                "description": "Ставит перечисленные элементы в начало в указанном порядке, остальные — следом. Требует preferences:manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "preferences"
+                    "admin"
                 ],
-                "summary": "Удалить предпочтение",
+                "summary": "Изменить порядок каталога",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID предпочтения",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Порядок ID",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReorderListPreferencesDTO"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ListPreference"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -324,6 +320,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -333,64 +335,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/process-json": {
-            "post": {
+        "/admin/preferences/catalog/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обрабатывает JSON-файл с объектами мест и отправляет их на нейросеть",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Скрывает элемент из публичного каталога; уже выбранные пользователями предпочтения сохраняются. Требует preferences:manage",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "places"
+                    "admin"
                 ],
-                "summary": "Обработать JSON-файл с местами",
+                "summary": "Вывести элемент из каталога",
                 "parameters": [
                     {
-                        "description": "JSON-файл с местами",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
-                        }
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/models.ListPreference"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/process-json-mistral": {
-            "post": {
-                "description": "Обрабатывает JSON-файл с объектами мест и отправляет их на Mistral",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет исходное название элемента. Требует preferences:manage",
                 "consumes": [
                     "application/json"
                 ],
@@ -398,17 +394,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "places"
+                    "admin"
                 ],
-                "summary": "Обработать JSON-файл с местами",
+                "summary": "Переименовать элемент каталога",
                 "parameters": [
                     {
-                        "description": "JSON-файл с местами",
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                            "$ref": "#/definitions/dto.RenameListPreferenceDTO"
                         }
                     }
                 ],
@@ -416,31 +419,90 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/models.ListPreference"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
-                    }
-                }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
             }
         },
-        "/process-json-noauth": {
+        "/admin/preferences/catalog/{id}/restore": {
             "post": {
-                "description": "Обрабатывает JSON-файл с объектами мест и отправляет их на заглушку",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отменяет вывод элемента из каталога. Требует preferences:manage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Вернуть элемент в каталог",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListPreference"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/preferences/catalog/{id}/tags": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает условия на теги OSM (например historic=*,tourism=museum|gallery), по которым места ранжируются по интересам пользователя. Требует preferences:manage",
                 "consumes": [
                     "application/json"
                 ],
@@ -448,18 +510,140 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "places"
+                    "admin"
                 ],
-                "summary": "Обработать JSON-файл с местами",
+                "summary": "Теги OSM элемента каталога",
                 "parameters": [
                     {
-                        "description": "JSON-файл с местами",
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Условия на теги",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                            "$ref": "#/definitions/dto.ListPreferenceTagsDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListPreference"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/preferences/catalog/{id}/translations": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет или меняет названия по языкам; пустое название удаляет перевод. Требует preferences:manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Переводы элемента каталога",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Переводы",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ListPreferenceTranslationsDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListPreference"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/prompts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все версии шаблонов промптов, новые версии первыми. Требует prompts:manage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Шаблоны промптов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя шаблона, например describe",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -468,29 +652,88 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object",
-                                "additionalProperties": true
+                                "$ref": "#/definitions/models.PromptTemplate"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сохраняет новую версию варианта шаблона (имя, язык, аудитория). Шаблон проверяется на тестовых данных. Доступны переменные .Name, .Address, .City, .Tags, .Interests, .Language, .Audience и функции join, lower, upper, default, tags. Требует prompts:manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Новая версия шаблона промпта",
+                "parameters": [
+                    {
+                        "description": "Шаблон",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatePromptTemplateDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/register": {
+        "/admin/prompts/preview": {
             "post": {
-                "description": "Register a new user by providing username, password, and email",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Подставляет данные места в сохранённую версию или переданный текст шаблона, не обращаясь к LLM. Требует prompts:manage",
                 "consumes": [
                     "application/json"
                 ],
@@ -498,35 +741,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Register new user",
+                "summary": "Предпросмотр шаблона промпта",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Шаблон и данные",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.RegisterUserDTO"
+                            "$ref": "#/definitions/dto.PreviewPromptDTO"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successfully created user",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/dto.PreviewPromptResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Conflict - user already exists",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -534,203 +783,2286 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/history": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
+        "/admin/prompts/{id}/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Делает версию активной для её варианта; новые описания будут кешироваться под этой версией. Требует prompts:manage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Активировать версию шаблона",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID версии шаблона",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователей с ролями и дополнительными разрешениями. Требует users:manage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Список пользователей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.AdminUserDTO"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/permissions": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет набор разрешений, выданных пользователю сверх роли. Требует users:manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Дополнительные разрешения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Разрешения",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetPermissionsDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetPermissionsDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Назначает пользователю роль user, staff или admin и отзывает его токены. Требует users:manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Назначить роль",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Роль",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetRoleDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ask": {
+            "post": {
+                "description": "Ввод текста, который будет передан ЛЛМ и возвращение ответа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "LLM"
+                ],
+                "summary": "Задать вопрос ЛЛМ",
+                "parameters": [
+                    {
+                        "description": "Question data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.InputQuestionDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Вопрос ЛЛМ отправлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input\" // Указание структуры ошибки",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audio/generate": {
+            "post": {
+                "description": "Генерирует аудиофайл с выбранными голосом, языком, скоростью и форматом (mp3, ogg, wav)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "audio"
+                ],
+                "summary": "Сгенерировать аудио",
+                "parameters": [
+                    {
+                        "description": "Текст для генерации аудио",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AudioDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Бинарные данные аудиофайла, ссылка на сохранённое аудио — в заголовке X-Audio-URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audio/{id}": {
+            "get": {
+                "description": "Отдаёт сохранённое аудио бинарным потоком. Поддерживает Range-запросы (206 Partial Content), ETag и If-None-Match",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "audio"
+                ],
+                "summary": "Получить аудио",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор аудио (хеш содержимого)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Диапазон байт, например bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Бинарные данные аудиофайла",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "206": {
+                        "description": "Часть аудиофайла",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Не изменилось",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Некорректный диапазон",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/cached-response": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает закешированный ответ из Redis: персональный вариант для предпочтений пользователя или общее описание места",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "places"
+                ],
+                "summary": "Получить закешированный ответ",
+                "parameters": [
+                    {
+                        "description": "Запрос с названием места и, при наличии, объектом OSM",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CachedResponseDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Верный формат",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ответ не найден в кеше",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "post": {
+                "description": "Сохраняет задание и ставит каждое место в очередь. Результаты доступны по /jobs/{id}/results по мере готовности.\nПринимает {\"json_data\": [...]}, ответ Overpass API в JSON ({\"elements\": [...]}) или OSM XML (application/xml)",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Создать задание на обработку мест",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя LLM-провайдера (fastapi, mistral, openai)",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык описаний (ru, en, de); без параметра — из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "description": "JSON-файл с местами",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "Возвращает статус задания и количество обработанных, неудачных и пропущенных мест",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Получить статус задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID задания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/results": {
+            "get": {
+                "description": "Возвращает результаты по каждому месту в порядке входного массива, в том числе частичные",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Получить результаты задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID задания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.JobTaskResultDTO"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Login a user by providing email and password, and return a JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Login user and return JWT token",
+                "parameters": [
+                    {
+                        "description": "User login data",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the current access token and its refresh token family",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Logout user",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke",
+                        "name": "logout",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LogoutDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Logged out"
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/places/nearby": {
+            "get": {
+                "description": "Возвращает уже обработанные места в радиусе от точки с сохранёнными описаниями и ссылками на аудио, ближайшие первыми. Язык берётся из lang или Accept-Language",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "places"
+                ],
+                "summary": "Места рядом",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Широта",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Долгота",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Радиус в метрах (по умолчанию 500, не больше 5000)",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык описаний (ru, en, pt-br)",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только описания этого LLM-провайдера",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимум мест (по умолчанию 50, не больше 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.NearbyPlace"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список предпочтений пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Получить предпочтения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Preference"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет новое предпочтение для пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Добавить предпочтение",
+                "parameters": [
+                    {
+                        "description": "Данные предпочтения",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatePreferenceDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preference"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Элемент каталога не найден или выведен",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Предпочтение уже выбрано",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences/catalog": {
+            "get": {
+                "description": "Возвращает действующие элементы каталога в порядке отображения. Язык берется из lang или заголовка Accept-Language; при отсутствии перевода возвращается исходное название",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Каталог интересов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Язык названий, например en",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CatalogItemDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет предпочтение пользователя по ID",
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Удалить предпочтение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID предпочтения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/process-json-mistral": {
+            "post": {
+                "description": "Обрабатывает объекты мест и отправляет их на Mistral.\nПринимает {\"json_data\": [...]}, ответ Overpass API в JSON ({\"elements\": [...]}) или OSM XML (application/xml)",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "places"
+                ],
+                "summary": "Обработать JSON-файл с местами",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Язык описаний (ru, en, de); без параметра — из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "description": "JSON-файл с местами",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PlaceResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/process-json-noauth": {
+            "post": {
+                "description": "Обрабатывает объекты мест и отправляет их LLM-провайдеру из конфигурации.\nПринимает {\"json_data\": [...]}, ответ Overpass API в JSON ({\"elements\": [...]}) или OSM XML (application/xml)",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "places"
+                ],
+                "summary": "Обработать JSON-файл с местами",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя LLM-провайдера (fastapi, mistral, openai)",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Аудитория для варианта шаблона промпта, например kids",
+                        "name": "audience",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык описаний (ru, en, de); без параметра — из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "description": "JSON-файл с местами",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PlaceResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register a new user by providing username, password, and email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterUserDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created user",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - user already exists",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access/refresh pair. Each refresh token can be used once; reusing it revokes the whole token family",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "refresh",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefreshTokenDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tours": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отбирает места по интересам пользователя и упорядочивает их в маршрут в пределах бюджета времени или расстояния (ближайший сосед и 2-opt). Для каждой остановки возвращает описание и аудио, между остановками — текст и аудио перехода. Места берутся из тела запроса (те же форматы, что у /process-json-noauth), а без тела — из уже описанных мест в радиусе от lat/lon. Без бюджета экскурсия рассчитывается на 45 минут",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tours"
+                ],
+                "summary": "Построить пешую экскурсию",
+                "parameters": [
+                    {
+                        "description": "Объекты OSM",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProcessPlacesDTO"
+                        }
+                    },
+                    {
+                        "type": "number",
+                        "description": "Широта начальной точки (обязательна без тела запроса)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Долгота начальной точки (обязательна без тела запроса)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Радиус поиска сохранённых мест, м (по умолчанию 1000)",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Бюджет времени, мин",
+                        "name": "duration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Бюджет длины маршрута, м",
+                        "name": "distance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Время на одной остановке, мин (по умолчанию 5)",
+                        "name": "stop_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимум остановок (по умолчанию 10, не больше 20)",
+                        "name": "max_stops",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык описаний; по умолчанию язык из профиля",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Tour"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OSMInputErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список мест, связанных с пользователем",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "places"
+                ],
+                "summary": "Получить историю запросов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Place"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/locale": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the default language of place descriptions and audio for the current user. An empty locale resets it to the server default",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Set user locale",
+                "parameters": [
+                    {
+                        "description": "Language code, e.g. en or pt-br",
+                        "name": "locale",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LocaleDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid language code",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.OSMInputErrorResponse": {
+            "type": "object",
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/osm.ElementError"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.PlaceErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Время жизни access-токена в секундах",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "description": "Одноразовый refresh-токен",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Access-токен",
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AdminUserDTO": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "permissions": {
+                    "description": "Разрешения, выданные сверх роли",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AudioDTO": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "format": {
+                    "description": "Формат аудио",
+                    "type": "string",
+                    "enum": [
+                        "mp3",
+                        "ogg",
+                        "wav"
+                    ]
+                },
+                "language": {
+                    "description": "Язык озвучки, например ru или en",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "Имя TTS-провайдера (host, http)",
+                    "type": "string"
+                },
+                "speed": {
+                    "description": "Скорость речи, 1.0 — обычная",
+                    "type": "number",
+                    "maximum": 4
+                },
+                "voice": {
+                    "description": "Голос",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AudioRef": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CacheEntryDTO": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "ttl_seconds": {
+                    "description": "-1 — без срока действия",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CacheInspectDTO": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "description": "Не более limit записей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CacheEntryDTO"
+                    }
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CachedResponseDTO": {
+            "type": "object",
+            "required": [
+                "place_name"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "osm_id": {
+                    "type": "integer"
+                },
+                "osm_type": {
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CatalogItemDTO": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Название на запрошенном языке или исходное",
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Coordinates": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.CreateListPreferenceDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "osm_tags": {
+                    "description": "Условия на теги OSM: historic=*,tourism=museum|gallery",
+                    "type": "string"
+                },
+                "position": {
+                    "description": "По умолчанию — в конец списка",
+                    "type": "integer"
+                },
+                "translations": {
+                    "description": "Язык -\u003e название",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CreatePreferenceDTO": {
+            "type": "object",
+            "required": [
+                "list_preference_id"
+            ],
+            "properties": {
+                "list_preference_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreatePromptTemplateDTO": {
+            "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
+            "properties": {
+                "activate": {
+                    "description": "Сразу сделать версию активной",
+                    "type": "boolean"
+                },
+                "audience": {
+                    "description": "Пусто — для аудитории по умолчанию",
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "body": {
+                    "type": "string"
+                },
+                "language": {
+                    "description": "Пусто — для всех языков",
+                    "type": "string",
+                    "maxLength": 16
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "dto.Geometry": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "geometries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Geometry"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Polygon"
+                }
+            }
+        },
+        "dto.InputQuestionDTO": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.JobTaskResultDTO": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "osm_id": {
+                    "type": "integer"
+                },
+                "osm_type": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/dto.PlaceResult"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ListPreferenceTagsDTO": {
+            "type": "object",
+            "properties": {
+                "osm_tags": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ListPreferenceTranslationsDTO": {
+            "type": "object",
+            "required": [
+                "translations"
+            ],
+            "properties": {
+                "translations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.LocaleDTO": {
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LoginDTO": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LogoutDTO": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.NearbyPlace": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "audio": {
+                    "$ref": "#/definitions/dto.AudioRef"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/dto.Coordinates"
+                },
+                "distance": {
+                    "description": "Расстояние от точки поиска в метрах",
+                    "type": "number",
+                    "example": 120.5
+                },
+                "language": {
+                    "type": "string"
+                },
+                "osm_type": {
+                    "type": "string"
+                },
+                "place_id": {
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "response": {
+                    "description": "Сохраненное описание места",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.OSMMember": {
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "description": "Для way-участника (out geom)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Coordinates"
+                    }
+                },
+                "lat": {
+                    "description": "Для node-участника (out geom)",
+                    "type": "number"
+                },
+                "lon": {
+                    "description": "Для node-участника (out geom)",
+                    "type": "number"
+                },
+                "ref": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OSMObject": {
+            "type": "object",
+            "properties": {
+                "center": {
+                    "description": "Центр way или relation (out center)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Coordinates"
+                        }
+                    ]
+                },
+                "geometry": {
+                    "description": "Координаты узлов way (out geom)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Coordinates"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lat": {
+                    "description": "Только для node",
+                    "type": "number"
+                },
+                "lon": {
+                    "description": "Только для node",
+                    "type": "number"
+                },
+                "members": {
+                    "description": "Только для relation",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OSMMember"
+                    }
+                },
+                "nodes": {
+                    "description": "Только для way",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PlaceError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.PlaceStatus"
+                        }
+                    ],
+                    "example": "llm_error"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PlaceResult": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "audio": {
+                    "$ref": "#/definitions/dto.AudioRef"
+                },
+                "bbox": {
+                    "description": "[min_lon, min_lat, max_lon, max_lat]",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "centroid": {
+                    "description": "Центр масс, для невыпуклых объектов может лежать вне их",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Coordinates"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "description": "Точка для отображения на карте, всегда лежит на самом объекте",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Coordinates"
+                        }
+                    ]
+                },
+                "error": {
+                    "$ref": "#/definitions/dto.PlaceError"
+                },
+                "geometry": {
+                    "description": "Для way и relation",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Geometry"
+                        }
+                    ]
+                },
+                "index": {
+                    "description": "Позиция объекта во входном массиве",
+                    "type": "integer"
+                },
+                "interests": {
+                    "description": "Интересы пользователя, под которые подходит место",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "nodes": {
+                    "description": "Только для way",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "osm_type": {
+                    "description": "node, way или relation",
+                    "type": "string"
+                },
+                "place_id": {
+                    "description": "Идентификатор объекта OSM",
+                    "type": "string"
+                },
+                "place_name": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Причина пропуска для статуса skipped",
+                    "type": "string"
+                },
+                "response": {
+                    "description": "Описание места от LLM",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/dto.PlaceStatus"
+                }
+            }
+        },
+        "dto.PlaceStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "success",
+                "llm_error",
+                "tts_error",
+                "cache_error",
+                "failed_to_add",
+                "skipped",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "PlaceStatusCancelled": "Запрос отменен или клиент отключился до готовности результата",
+                "PlaceStatusSkipped": "Объект не обрабатывался, причина — в поле reason"
+            },
+            "x-enum-varnames": [
+                "PlaceStatusPending",
+                "PlaceStatusSuccess",
+                "PlaceStatusLLMError",
+                "PlaceStatusTTSError",
+                "PlaceStatusCacheError",
+                "PlaceStatusFailedToAdd",
+                "PlaceStatusSkipped",
+                "PlaceStatusCancelled"
+            ]
+        },
+        "dto.PreviewPromptDTO": {
+            "type": "object",
+            "properties": {
+                "audience": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "interests": {
+                    "description": "Интересы пользователя",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                ],
-                "description": "Возвращает список мест, связанных с пользователем",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "places"
-                ],
-                "summary": "Получить историю запросов",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Place"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/controllers.PlaceErrorResponse"
-                        }
+                },
+                "language": {
+                    "type": "string"
+                },
+                "place": {
+                    "description": "Теги места; пусто — тестовое место",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
+                },
+                "template_id": {
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.ErrorResponse": {
+        },
+        "dto.PreviewPromptResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "prompt": {
                     "type": "string"
                 }
             }
         },
-        "controllers.PlaceErrorResponse": {
+        "dto.ProcessPlacesDTO": {
             "type": "object",
+            "required": [
+                "json_data"
+            ],
             "properties": {
-                "error": {
-                    "type": "string"
+                "json_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OSMObject"
+                    }
                 }
             }
         },
-        "controllers.TokenResponse": {
+        "dto.PurgeResultDTO": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string"
+                "deleted": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.AddPlaceDTO": {
+        "dto.RefreshTokenDTO": {
             "type": "object",
             "required": [
-                "place_name"
+                "refresh_token"
             ],
             "properties": {
-                "place_name": {
+                "refresh_token": {
                     "type": "string"
                 }
             }
         },
-        "dto.AudioDTO": {
+        "dto.RegisterUserDTO": {
             "type": "object",
             "required": [
-                "message"
+                "email",
+                "password",
+                "username"
             ],
             "properties": {
-                "message": {
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Язык описаний по умолчанию, например ru или en",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "dto.CreatePreferenceDTO": {
+        "dto.RenameListPreferenceDTO": {
             "type": "object",
             "required": [
-                "list_preference_id"
+                "name"
             ],
             "properties": {
-                "list_preference_id": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "dto.InputQuestionDTO": {
+        "dto.ReorderListPreferencesDTO": {
             "type": "object",
             "required": [
-                "message"
+                "ids"
             ],
             "properties": {
-                "message": {
-                    "type": "string"
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "dto.LoginDTO": {
+        "dto.SetPermissionsDTO": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.SetRoleDTO": {
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "role"
             ],
             "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "staff",
+                        "admin"
+                    ]
                 }
             }
         },
-        "dto.OSMObject": {
+        "dto.Tour": {
             "type": "object",
             "properties": {
-                "id": {
+                "candidates": {
+                    "description": "Сколько мест рассматривалось после отбора по интересам",
                     "type": "integer"
                 },
-                "lat": {
-                    "description": "Только для node",
-                    "type": "number"
+                "distance": {
+                    "description": "Суммарная длина переходов, м",
+                    "type": "number",
+                    "example": 1850
                 },
-                "lon": {
-                    "description": "Только для node",
-                    "type": "number"
+                "duration_minutes": {
+                    "description": "Переходы и время на остановках",
+                    "type": "number",
+                    "example": 43
                 },
-                "members": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "properties": {
-                            "ref": {
-                                "type": "integer"
-                            },
-                            "role": {
-                                "type": "string"
-                            },
-                            "type": {
-                                "type": "string"
-                            }
-                        }
-                    }
+                "language": {
+                    "type": "string"
                 },
-                "nodes": {
-                    "description": "Только для way",
+                "start": {
+                    "$ref": "#/definitions/dto.Coordinates"
+                },
+                "stops": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/dto.TourStop"
                     }
+                }
+            }
+        },
+        "dto.TourStop": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "description": "Номер остановки, с 1",
+                    "type": "integer"
                 },
-                "tags": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
+                "place": {
+                    "$ref": "#/definitions/dto.PlaceResult"
                 },
-                "type": {
-                    "type": "string"
+                "transition": {
+                    "description": "Переход от предыдущей остановки или от начальной точки",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.TourTransition"
+                        }
+                    ]
                 }
             }
         },
-        "dto.ProcessPlacesDTO": {
+        "dto.TourTransition": {
             "type": "object",
-            "required": [
-                "json_data"
-            ],
             "properties": {
-                "json_data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.OSMObject"
-                    }
+                "audio": {
+                    "$ref": "#/definitions/dto.AudioRef"
+                },
+                "direction": {
+                    "description": "Направление по компасу: N, NE, E, SE, S, SW, W, NW",
+                    "type": "string",
+                    "example": "NE"
+                },
+                "distance": {
+                    "description": "Метры",
+                    "type": "number",
+                    "example": 240
+                },
+                "text": {
+                    "type": "string"
+                },
+                "walk_minutes": {
+                    "type": "number",
+                    "example": 3
                 }
             }
         },
-        "dto.RegisterUserDTO": {
+        "models.Job": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
+                "created_at": {
                     "type": "string"
                 },
-                "password": {
+                "done": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
                     "type": "string"
                 },
-                "username": {
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Язык описаний, пустой — язык по умолчанию",
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "LLM-провайдер, пустой — провайдер по умолчанию",
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
@@ -741,6 +3073,35 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "name": {
+                    "type": "string"
+                },
+                "osm_tags": {
+                    "description": "Условия на теги OSM, например historic=*,tourism=museum",
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Порядок в списке выбора",
+                    "type": "integer"
+                },
+                "retired_at": {
+                    "description": "Выведен из каталога; уже выбранные предпочтения сохраняются",
+                    "type": "string"
+                },
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ListPreferenceTranslation"
+                    }
+                }
+            }
+        },
+        "models.ListPreferenceTranslation": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 }
@@ -784,6 +3145,38 @@ const docTemplate = `{
                 }
             }
         },
+        "models.PromptTemplate": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "audience": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Question": {
             "type": "object",
             "properties": {
@@ -801,13 +3194,44 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "locale": {
+                    "description": "Язык описаний по умолчанию, например ru или en",
+                    "type": "string"
+                },
                 "password": {
                     "type": "string"
                 },
+                "role": {
+                    "description": "Роль пользователя: user, staff или admin",
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
             }
+        },
+        "osm.ElementError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Идентификатор элемента, если удалось прочитать",
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "Позиция элемента во входном массиве",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Тип элемента, если удалось прочитать",
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
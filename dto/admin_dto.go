@@ -0,0 +1,38 @@
+package dto
+
+// AdminUserDTO — пользователь в административном списке
+type AdminUserDTO struct {
+	ID          uint     `json:"id"`
+	Username    string   `json:"username"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"` // Разрешения, выданные сверх роли
+}
+
+// SetRoleDTO — назначение роли пользователю
+type SetRoleDTO struct {
+	Role string `json:"role" binding:"required,oneof=user staff admin"`
+}
+
+// SetPermissionsDTO — замена набора дополнительных разрешений пользователя
+type SetPermissionsDTO struct {
+	Permissions []string `json:"permissions"`
+}
+
+// CacheEntryDTO — ключ кеша и оставшееся время жизни
+type CacheEntryDTO struct {
+	Key        string `json:"key"`
+	TTLSeconds int64  `json:"ttl_seconds"` // -1 — без срока действия
+}
+
+// CacheInspectDTO — результат просмотра кеша по префиксу
+type CacheInspectDTO struct {
+	Prefix  string          `json:"prefix"`
+	Count   int             `json:"count"`
+	Entries []CacheEntryDTO `json:"entries"` // Не более limit записей
+}
+
+// PurgeResultDTO — количество удалённых записей
+type PurgeResultDTO struct {
+	Deleted int64 `json:"deleted"`
+}
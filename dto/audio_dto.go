@@ -1,5 +1,11 @@
 package dto
 
+// AudioDTO — текст для озвучивания и параметры синтеза речи
 type AudioDTO struct {
-	Message    string `json:"message" binding:"required"`
-}
\ No newline at end of file
+	Message  string  `json:"message" binding:"required"`
+	Provider string  `json:"provider,omitempty"`                                     // Имя TTS-провайдера (host, http)
+	Voice    string  `json:"voice,omitempty"`                                        // Голос
+	Language string  `json:"language,omitempty"`                                     // Язык озвучки, например ru или en
+	Speed    float64 `json:"speed,omitempty" binding:"omitempty,gt=0,lte=4"`         // Скорость речи, 1.0 — обычная
+	Format   string  `json:"format,omitempty" binding:"omitempty,oneof=mp3 ogg wav"` // Формат аудио
+}
@@ -4,21 +4,41 @@ package dto
 type AddPlaceDTO struct {
 	PlaceName string `json:"place_name" binding:"required"`
 }
+
+// OSMObject — объект OSM во внутреннем формате. Поля совпадают с элементом Overpass JSON
 type OSMObject struct {
-	ID      int64
-	Type    string
-	Tags    map[string]string
-	Lat     float64    // Только для node
-	Lon     float64    // Только для node
-	Nodes   []int64    // Только для way
-	Members []struct { // Только для relation
-		Type string
-		Ref  int64
-		Role string
-	} `json:"members,omitempty"`
+	ID       int64
+	Type     string
+	Tags     map[string]string
+	Lat      float64       // Только для node
+	Lon      float64       // Только для node
+	Nodes    []int64       // Только для way
+	Members  []OSMMember   `json:"members,omitempty"`  // Только для relation
+	Center   *Coordinates  `json:"center,omitempty"`   // Центр way или relation (out center)
+	Geometry []Coordinates `json:"geometry,omitempty"` // Координаты узлов way (out geom)
+}
+
+// OSMMember — участник relation
+type OSMMember struct {
+	Type     string
+	Ref      int64
+	Role     string
+	Lat      float64       `json:"lat,omitempty"`      // Для node-участника (out geom)
+	Lon      float64       `json:"lon,omitempty"`      // Для node-участника (out geom)
+	Geometry []Coordinates `json:"geometry,omitempty"` // Для way-участника (out geom)
 }
 
 // ProcessPlacesDTO представляет массив мест для обработки
 type ProcessPlacesDTO struct {
 	JSONData []OSMObject `json:"json_data" binding:"required"`
 }
+
+// CachedResponseDTO ищет закешированное описание места.
+// Если переданы osm_type и osm_id, поиск идет по объекту OSM, иначе по названию
+type CachedResponseDTO struct {
+	PlaceName string `json:"place_name" binding:"required"`
+	OSMType   string `json:"osm_type,omitempty"`
+	OSMID     int64  `json:"osm_id,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+}
@@ -1,6 +1,5 @@
 package dto
 
-
 type InputQuestionDTO struct {
-	Message    string `json:"message" binding:"required"`
-}
\ No newline at end of file
+	Message string `json:"message" binding:"required"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+// JobTaskResultDTO — результат обработки одного места в задании
+type JobTaskResultDTO struct {
+	Position int          `json:"position"`
+	OSMType  string       `json:"osm_type"`
+	OSMID    int64        `json:"osm_id"`
+	Status   string       `json:"status"`
+	Result   *PlaceResult `json:"result,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
@@ -1,5 +1,38 @@
 package dto
 
+// CreateListPreferenceDTO — новый элемент каталога интересов
 type CreateListPreferenceDTO struct {
-	Place string `json:"place" binding:"required,"`
+	Name         string            `json:"name" binding:"required"`
+	Position     *int              `json:"position"`     // По умолчанию — в конец списка
+	OSMTags      string            `json:"osm_tags"`     // Условия на теги OSM: historic=*,tourism=museum|gallery
+	Translations map[string]string `json:"translations"` // Язык -> название
+}
+
+// RenameListPreferenceDTO — переименование элемента каталога
+type RenameListPreferenceDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ListPreferenceTagsDTO — условия на теги OSM, по которым элемент каталога сопоставляется с объектами.
+// Пустая строка отключает сопоставление
+type ListPreferenceTagsDTO struct {
+	OSMTags string `json:"osm_tags"`
+}
+
+// ReorderListPreferencesDTO — новый порядок каталога; не перечисленные элементы идут следом
+type ReorderListPreferencesDTO struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// ListPreferenceTranslationsDTO — названия элемента каталога по языкам.
+// Пустое название удаляет перевод
+type ListPreferenceTranslationsDTO struct {
+	Translations map[string]string `json:"translations" binding:"required"`
+}
+
+// CatalogItemDTO — элемент каталога для экрана выбора интересов
+type CatalogItemDTO struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"` // Название на запрошенном языке или исходное
+	Position int    `json:"position"`
 }
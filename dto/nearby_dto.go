@@ -0,0 +1,27 @@
+package dto
+
+// NearbyQuery — параметры поиска описанных мест рядом с точкой
+type NearbyQuery struct {
+	Lat      float64
+	Lon      float64
+	Radius   float64 // Радиус в метрах, 0 — радиус по умолчанию
+	Language string  // Язык описаний, пусто — язык по умолчанию
+	Provider string  // LLM-провайдер описаний, пусто — любой
+	Limit    int     // Максимум мест в ответе, 0 — значение по умолчанию
+}
+
+// NearbyPlace — уже описанное место рядом с точкой поиска
+type NearbyPlace struct {
+	PlaceID     string            `json:"place_id"`
+	OSMType     string            `json:"osm_type"`
+	PlaceName   string            `json:"place_name"`
+	Address     string            `json:"address,omitempty"`
+	City        string            `json:"city,omitempty"`
+	Coordinates Coordinates       `json:"coordinates"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Distance    float64           `json:"distance" example:"120.5"` // Расстояние от точки поиска в метрах
+	Language    string            `json:"language"`
+	Provider    string            `json:"provider"`
+	Response    string            `json:"response"` // Сохраненное описание места
+	Audio       *AudioRef         `json:"audio,omitempty"`
+}
@@ -0,0 +1,70 @@
+package dto
+
+// PlaceStatus — итог обработки места
+type PlaceStatus string
+
+// Возможные статусы обработки места
+const (
+	PlaceStatusPending     PlaceStatus = "pending"
+	PlaceStatusSuccess     PlaceStatus = "success"
+	PlaceStatusLLMError    PlaceStatus = "llm_error"
+	PlaceStatusTTSError    PlaceStatus = "tts_error"
+	PlaceStatusCacheError  PlaceStatus = "cache_error"
+	PlaceStatusFailedToAdd PlaceStatus = "failed_to_add"
+	PlaceStatusSkipped     PlaceStatus = "skipped"   // Объект не обрабатывался, причина — в поле reason
+	PlaceStatusCancelled   PlaceStatus = "cancelled" // Запрос отменен или клиент отключился до готовности результата
+)
+
+// PlaceError — описание ошибки обработки места. Code совпадает со статусом результата
+type PlaceError struct {
+	Code    PlaceStatus `json:"code" example:"llm_error"`
+	Message string      `json:"message"`
+}
+
+// Coordinates — координаты места
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Geometry — геометрия места в формате GeoJSON, координаты в порядке [lon, lat].
+// Для GeometryCollection вместо Coordinates заполняется Geometries
+type Geometry struct {
+	Type        string      `json:"type" example:"Polygon"`
+	Coordinates interface{} `json:"coordinates,omitempty" swaggertype:"array,number"`
+	Geometries  []Geometry  `json:"geometries,omitempty"`
+}
+
+// AudioRef — ссылка на сохранённое аудио, сами данные отдаются по URL
+type AudioRef struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// PlaceResult — результат обработки одного места, общий для REST, WebSocket и заданий
+type PlaceResult struct {
+	Index       int          `json:"index"`              // Позиция объекта во входном массиве
+	PlaceID     string       `json:"place_id,omitempty"` // Идентификатор объекта OSM
+	OSMType     string       `json:"osm_type,omitempty"` // node, way или relation
+	PlaceName   string       `json:"place_name"`
+	Status      PlaceStatus  `json:"status"`
+	Response    string       `json:"response,omitempty"` // Описание места от LLM
+	Error       *PlaceError  `json:"error,omitempty"`
+	Reason      string       `json:"reason,omitempty"` // Причина пропуска для статуса skipped
+	Address     string       `json:"address,omitempty"`
+	City        string       `json:"city,omitempty"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"` // Точка для отображения на карте, всегда лежит на самом объекте
+	Centroid    *Coordinates `json:"centroid,omitempty"`    // Центр масс, для невыпуклых объектов может лежать вне их
+	BBox        []float64    `json:"bbox,omitempty"`        // [min_lon, min_lat, max_lon, max_lat]
+	Geometry    *Geometry    `json:"geometry,omitempty"`    // Для way и relation
+	Nodes       []int64      `json:"nodes,omitempty"`       // Только для way
+	Interests   []string     `json:"interests,omitempty"`   // Интересы пользователя, под которые подходит место
+	Audio       *AudioRef    `json:"audio,omitempty"`
+}
+
+// Fail переводит результат в статус ошибки с сообщением
+func (r *PlaceResult) Fail(status PlaceStatus, message string) {
+	r.Status = status
+	r.Error = &PlaceError{Code: status, Message: message}
+}
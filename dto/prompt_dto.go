@@ -0,0 +1,26 @@
+package dto
+
+// CreatePromptTemplateDTO — новая версия шаблона промпта (Go text/template)
+type CreatePromptTemplateDTO struct {
+	Name     string `json:"name" binding:"required,max=64"`
+	Language string `json:"language" binding:"max=16"` // Пусто — для всех языков
+	Audience string `json:"audience" binding:"max=32"` // Пусто — для аудитории по умолчанию
+	Body     string `json:"body" binding:"required"`
+	Activate bool   `json:"activate"` // Сразу сделать версию активной
+}
+
+// PreviewPromptDTO — предпросмотр шаблона на данных места.
+// Задается либо template_id сохранённой версии, либо текст body
+type PreviewPromptDTO struct {
+	TemplateID uint              `json:"template_id"`
+	Body       string            `json:"body"`
+	Place      map[string]string `json:"place"`     // Теги места; пусто — тестовое место
+	Interests  []string          `json:"interests"` // Интересы пользователя
+	Language   string            `json:"language"`
+	Audience   string            `json:"audience"`
+}
+
+// PreviewPromptResponse — текст промпта после подстановки
+type PreviewPromptResponse struct {
+	Prompt string `json:"prompt"`
+}
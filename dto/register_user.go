@@ -5,4 +5,10 @@ type RegisterUserDTO struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
+	Locale   string `json:"locale,omitempty"` // Язык описаний по умолчанию, например ru или en
+}
+
+// LocaleDTO — язык описаний по умолчанию; пустая строка сбрасывает его
+type LocaleDTO struct {
+	Locale string `json:"locale"`
 }
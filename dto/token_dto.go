@@ -0,0 +1,11 @@
+package dto
+
+// RefreshTokenDTO — запрос на обмен refresh-токена
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutDTO — запрос на выход; refresh-токен необязателен
+type LogoutDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
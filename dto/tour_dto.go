@@ -0,0 +1,39 @@
+package dto
+
+// TourQuery — параметры пешей экскурсии. Если объекты OSM не переданы,
+// остановки выбираются из уже описанных мест в радиусе Radius от Start
+type TourQuery struct {
+	Start           *Coordinates // Начальная точка; без нее экскурсия начинается с самого интересного места
+	Radius          float64      // Радиус поиска сохраненных мест вокруг Start, м
+	DurationMinutes float64      // Бюджет времени с учетом остановок; 0 — без ограничения
+	DistanceMeters  float64      // Бюджет длины маршрута; 0 — без ограничения
+	StopMinutes     float64      // Время на одной остановке; 0 — значение по умолчанию
+	MaxStops        int          // 0 — значение по умолчанию
+	Language        string       // Пусто — язык из профиля пользователя
+}
+
+// Tour — маршрут экскурсии с описаниями остановок и переходами между ними
+type Tour struct {
+	Language        string       `json:"language"`
+	Start           *Coordinates `json:"start,omitempty"`
+	Distance        float64      `json:"distance" example:"1850"`       // Суммарная длина переходов, м
+	DurationMinutes float64      `json:"duration_minutes" example:"43"` // Переходы и время на остановках
+	Candidates      int          `json:"candidates"`                    // Сколько мест рассматривалось после отбора по интересам
+	Stops           []TourStop   `json:"stops"`
+}
+
+// TourStop — остановка экскурсии
+type TourStop struct {
+	Order      int             `json:"order"`                // Номер остановки, с 1
+	Transition *TourTransition `json:"transition,omitempty"` // Переход от предыдущей остановки или от начальной точки
+	Place      PlaceResult     `json:"place"`
+}
+
+// TourTransition — переход к остановке с текстом для озвучивания
+type TourTransition struct {
+	Distance    float64   `json:"distance" example:"240"` // Метры
+	WalkMinutes float64   `json:"walk_minutes" example:"3"`
+	Direction   string    `json:"direction" example:"NE"` // Направление по компасу: N, NE, E, SE, S, SW, W, NW
+	Text        string    `json:"text"`
+	Audio       *AudioRef `json:"audio,omitempty"`
+}
@@ -0,0 +1,88 @@
+package dto
+
+import "encoding/json"
+
+// WSProtocolVersion — текущая версия протокола WebSocket
+const WSProtocolVersion = 1
+
+// Типы сообщений клиента
+const (
+	WSMessageProcess  = "process"  // Описать объекты OSM из payload
+	WSMessageCancel   = "cancel"   // Отменить запрос process с тем же request_id
+	WSMessagePing     = "ping"     // Проверка соединения, ответ — pong
+	WSMessageLocation = "location" // Текущее положение пользователя
+	WSMessagePlaces   = "places"   // Места для рассказа при приближении, без немедленного описания
+)
+
+// Типы сообщений сервера
+const (
+	WSMessagePong       = "pong"
+	WSMessageProgress   = "progress"   // Ход обработки запроса process
+	WSMessageResult     = "result"     // Описание одного места
+	WSMessageError      = "error"      // Ошибка сообщения или соединения
+	WSMessageDone       = "done"       // Запрос process завершен или отменен
+	WSMessageNarration  = "narration"  // Рассказ о месте, в геозону которого вошел пользователь
+	WSMessageRegistered = "registered" // Места из сообщения places добавлены в сессию
+)
+
+// Коды ошибок WebSocket
+const (
+	WSErrorInvalidMessage     = "invalid_message"     // Сообщение не удалось разобрать
+	WSErrorUnsupportedVersion = "unsupported_version" // Версия протокола не поддерживается
+	WSErrorUnknownType        = "unknown_type"        // Неизвестный тип сообщения
+	WSErrorInvalidPayload     = "invalid_payload"     // Payload не соответствует типу сообщения
+	WSErrorDuplicateRequest   = "duplicate_request"   // Запрос с таким request_id уже выполняется
+	WSErrorNotFound           = "not_found"           // Нет выполняющегося запроса с таким request_id
+	WSErrorUnauthorized       = "unauthorized"        // Токен отсутствует, недействителен или отозван
+)
+
+// WSEnvelope — конверт любого сообщения WebSocket в обе стороны. Схема протокола — docs/websocket.md
+type WSEnvelope struct {
+	Version   int             `json:"v" example:"1"`                          // Версия протокола; 0 у клиента — текущая
+	Type      string          `json:"type" example:"process"`                 // Тип сообщения
+	RequestID string          `json:"request_id,omitempty" example:"a1"`      // Ответы несут request_id сообщения клиента
+	Payload   json.RawMessage `json:"payload,omitempty" swaggertype:"object"` // Содержимое, зависит от типа
+}
+
+// LocationUpdate — payload сообщения location
+type LocationUpdate struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Accuracy float64 `json:"accuracy,omitempty"` // Точность определения положения, м
+}
+
+// WSProgress — payload сообщения progress
+type WSProgress struct {
+	Completed int `json:"completed"` // Мест с готовым результатом
+	Total     int `json:"total"`     // Всего объектов в запросе
+}
+
+// WSDone — payload сообщения done
+type WSDone struct {
+	Completed int  `json:"completed"`
+	Total     int  `json:"total"`
+	Failed    int  `json:"failed"`  // Результаты с ошибкой
+	Skipped   int  `json:"skipped"` // Объекты без названия и адреса
+	Cancelled bool `json:"cancelled"`
+}
+
+// WSError — payload сообщения error
+type WSError struct {
+	Code     string      `json:"code" example:"invalid_payload"`
+	Message  string      `json:"message"`
+	Elements interface{} `json:"elements,omitempty" swaggertype:"array,object"` // Ошибки отдельных объектов OSM
+}
+
+// GeofenceEvent — payload сообщения narration
+type GeofenceEvent struct {
+	Event    string      `json:"event" example:"enter"`
+	Distance float64     `json:"distance"` // Расстояние до места в момент входа, м; 0 — внутри контура
+	Place    PlaceResult `json:"place"`
+}
+
+// PlacesRegistered — payload сообщения registered
+type PlacesRegistered struct {
+	Registered int `json:"registered"` // Места с координатами, о которых будет рассказано при приближении
+	Skipped    int `json:"skipped"`    // Места без названия, адреса или координат
+	Total      int `json:"total"`      // Всего мест в сессии
+}
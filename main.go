@@ -1,14 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	backgroundprocesses "new/background_processes"
 	"new/controllers"
 	"new/database"
 	docs "new/docs"
 	middleware "new/middleware"
+	"new/models"
 	"new/services"
+	"new/services/blobstore"
+	"new/services/llm"
+	"new/services/tts"
+	"new/utils"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -20,33 +33,110 @@ import (
 // @name Authorization
 
 func main() {
+	// Подкоманда управления миграциями: migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// Инициализация подключения к базе данных
 	database.InitDB()
 	database.InitRedis()
 
+	// Ключи подписи JWT
+	keyring, err := utils.LoadKeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка загрузки ключей JWT: %v", err)
+	}
+	utils.SetKeyring(keyring)
+
 	// Инициализация сервисов
 	registService := &services.RegistService{
 		DB: database.GetDB(),
 	}
+	tokenService := &services.TokenService{
+		DB: database.GetDB(),
+	}
+	if err := tokenService.CheckDenyList(); err != nil {
+		log.Fatalf("Ошибка проверки deny-list токенов: %v", err)
+	}
 	authService := &services.AuthService{
+		DB:     database.GetDB(),
+		Tokens: tokenService,
+	}
+	accessService := &services.AccessService{
+		DB:     database.GetDB(),
+		Tokens: tokenService,
+	}
+	if err := accessService.EnsureAdmins(os.Getenv("ADMIN_EMAILS")); err != nil {
+		log.Printf("Не удалось назначить администраторов из ADMIN_EMAILS: %v", err)
+	}
+	listPreferenceService := &services.ListPreferenceService{
+		DB: database.GetDB(),
+	}
+	adminService := &services.AdminService{
 		DB: database.GetDB(),
 	}
 	preferenceService := &services.PreferenceService{
 		DB: database.GetDB(),
 	}
-	placeService := &services.PlaceService{
+	llmRegistry, err := llm.NewRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка настройки LLM-провайдеров: %v", err)
+	}
+	ttsRegistry, err := tts.NewRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка настройки TTS-провайдеров: %v", err)
+	}
+	blobStore, err := blobstore.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Ошибка настройки хранилища аудио: %v", err)
+	}
+	promptService := &services.PromptTemplateService{
 		DB: database.GetDB(),
 	}
+	audioService := services.NewAudioCacheService(database.GetDB(), blobStore, ttsRegistry)
+	osmPlaceService := &services.OSMPlaceService{
+		DB:    database.GetDB(),
+		Audio: audioService,
+	}
+	placeService := &services.PlaceService{
+		DB:        database.GetDB(),
+		LLM:       llmRegistry,
+		TTS:       ttsRegistry,
+		Audio:     audioService,
+		Prompts:   promptService,
+		OSMPlaces: osmPlaceService,
+	}
 	delethistory := &backgroundprocesses.Deletehistory{
 		DB: database.GetDB(),
 	}
 	go delethistory.CleanupOldPlaces()
-	askLLMService := &services.AskLLMService{}
+	jobService := &services.JobService{
+		DB:     database.GetDB(),
+		Places: placeService,
+	}
+	jobWorker := &backgroundprocesses.JobWorker{
+		Jobs:        jobService,
+		Concurrency: envInt("JOB_WORKERS", 4),
+	}
+	// Обработчик заданий останавливается по сигналу завершения и дожидается начатых задач
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		jobWorker.Run(shutdownCtx)
+	}()
+	askLLMService := &services.AskLLMService{
+		LLM:      llmRegistry,
+		Provider: os.Getenv("ASK_LLM_PROVIDER"),
+	}
 
 	// Инициализация контроллеров
 	regisController := &controllers.RegistController{
 		Service_regist: registService,
 		Service_auth:   authService,
+		Service_tokens: tokenService,
 	}
 	askLLMController := &controllers.AskLLMController{
 		Service: askLLMService,
@@ -57,9 +147,32 @@ func main() {
 	placeController := &controllers.PlaceController{
 		Service: placeService,
 	}
+	jobController := &controllers.JobController{
+		Service: jobService,
+	}
+	audioController := &controllers.AudioController{
+		Service: placeService.Audio,
+	}
+	nearbyController := &controllers.NearbyController{
+		Service: osmPlaceService,
+	}
+	tourController := &controllers.TourController{
+		Service: placeService,
+	}
+	jwksController := &controllers.JWKSController{}
+	listPreferenceController := &controllers.ListPreferenceController{
+		Service: listPreferenceService,
+	}
+	promptController := &controllers.PromptController{
+		Service: promptService,
+	}
+	adminController := &controllers.AdminController{
+		Access: accessService,
+		Admin:  adminService,
+	}
 
 	// Создаём WebSocket-обработчик
-	wsHandler := services.NewWebSocketHandler(placeService)
+	wsHandler := services.NewWebSocketHandler(placeService, tokenService)
 
 	// Настройка маршрутов и Swagger документации
 	r := gin.Default()
@@ -70,22 +183,69 @@ func main() {
 	{
 		v1.POST("/register", regisController.RegisterUser)
 		v1.POST("/login", regisController.LoginUser)
+		v1.POST("/token/refresh", regisController.RefreshToken)
 		v1.POST("/ask", askLLMController.AskLLMQuestion)                     //Эта часть остается в открытом доступе для тестирования
 		v1.POST("/audio/generate", placeController.GenerateAudioFromText)    //Генерация аудио из текста
+		v1.GET("/audio/:id", audioController.GetAudio)                       //Выдача сохранённого аудио с поддержкой Range
 		v1.POST("/process-json-noauth", placeController.ProcessJSONNoAuth)   //Обработка массива данных без необходимости регистрироваться
 		v1.POST("/process-json-mistral", placeController.ProcessJSONMistral) //Реальная ЛЛМ Mistral
+		v1.POST("/jobs", jobController.CreateJob)                            //Асинхронная обработка массива мест
+		v1.GET("/jobs/:id", jobController.GetJob)
+		v1.GET("/jobs/:id/results", jobController.GetJobResults)
+		v1.GET("/preferences/catalog", listPreferenceController.GetCatalog) //Каталог интересов для экрана выбора
+		v1.GET("/places/nearby", nearbyController.GetNearbyPlaces)          //Уже описанные места рядом с точкой
 	}
 
 	// Защищённые маршруты
 	protected := v1.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(tokenService))
 	{
+		protected.POST("/logout", regisController.Logout)
 		protected.POST("/preferences", preferenceController.CreatePreference)
 		protected.GET("/preferences", preferenceController.GetPreferences)
 		protected.DELETE("/preferences/:id", preferenceController.DeletePreference)
 		protected.GET("/users/history", placeController.GetUserHistory)
+		protected.PUT("/users/locale", regisController.SetLocale)
 		// protected.POST("/process-json", placeController.ProcessJSON)
 		protected.POST("/cached-response", placeController.GetCachedResponse)
+		protected.POST("/tours", tourController.CreateTour)
+	}
+
+	// Открытые ключи для проверки токенов другими сервисами
+	r.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+
+	// Административные маршруты: каждая операция проверяет разрешение — от роли или выданное лично,
+	// поэтому пользователь с ролью user и личным разрешением тоже получает доступ к этой операции
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(tokenService))
+	{
+		admin.GET("/users", middleware.RequirePermission(accessService, models.PermManageUsers), adminController.ListUsers)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(accessService, models.PermManageUsers), adminController.SetUserRole)
+		admin.PUT("/users/:id/permissions", middleware.RequirePermission(accessService, models.PermManageUsers), adminController.SetUserPermissions)
+		admin.GET("/cache", middleware.RequirePermission(accessService, models.PermInspectCache), adminController.InspectCache)
+		admin.DELETE("/cache", middleware.RequirePermission(accessService, models.PermPurgeCache), adminController.PurgeCache)
+		admin.DELETE("/history", middleware.RequirePermission(accessService, models.PermPurgeHistory), adminController.PurgeHistory)
+	}
+	catalog := admin.Group("/preferences/catalog")
+	catalog.Use(middleware.RequirePermission(accessService, models.PermManagePreferences))
+	{
+		catalog.GET("", listPreferenceController.ListCatalog)
+		catalog.POST("", listPreferenceController.CreateCatalogEntry)
+		catalog.PUT("/order", listPreferenceController.ReorderCatalog)
+		catalog.PATCH("/:id", listPreferenceController.RenameCatalogEntry)
+		catalog.PUT("/:id/translations", listPreferenceController.SetCatalogTranslations)
+		catalog.PUT("/:id/tags", listPreferenceController.SetCatalogTags)
+		catalog.DELETE("/:id", listPreferenceController.RetireCatalogEntry)
+		catalog.POST("/:id/restore", listPreferenceController.RestoreCatalogEntry)
+	}
+
+	prompts := admin.Group("/prompts")
+	prompts.Use(middleware.RequirePermission(accessService, models.PermManagePrompts))
+	{
+		prompts.GET("", promptController.ListPrompts)
+		prompts.POST("", promptController.CreatePrompt)
+		prompts.POST("/preview", promptController.PreviewPrompt)
+		prompts.POST("/:id/activate", promptController.ActivatePrompt)
 	}
 
 	// Маршрут для Swagger документации
@@ -97,8 +257,29 @@ func main() {
 	})
 
 	// Запуск сервера
-	log.Println("Сервер запущен на :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal(err)
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Println("Сервер запущен на :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-shutdownCtx.Done()
+	log.Println("Остановка сервера")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Ошибка при остановке сервера: %v", err)
+	}
+	<-workerDone
+}
+
+// envInt читает целое число из переменной окружения, возвращая def при отсутствии или ошибке
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
 	}
+	return value
 }
@@ -2,15 +2,15 @@ package middleware
 
 import (
 	"net/http"
-	"new/utils"
+	"new/services"
 
 	//"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware — middleware для проверки JWT токена
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware — middleware для проверки JWT токена и его отсутствия в deny-list
+func AuthMiddleware(tokens *services.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Получаем токен из заголовков
 		authHeader := c.GetHeader("Authorization")
@@ -28,23 +28,53 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 	return
 		// }
 
-		// Проверяем токен с помощью утилиты
-		_, err := utils.ValidateToken(authHeader)
+		// Проверяем подпись, срок действия и отзыв токена
+		userID, claims, err := tokens.Authenticate(authHeader)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		userID, err := utils.ExtractUserIDFromToken(authHeader)
+		// Если токен валиден, продолжаем выполнение запроса
+		c.Set("userID", userID)
+		c.Set("userRole", claims.Role)
+		c.Set("tokenClaims", claims)
+		c.Next()
+	}
+}
+
+// RequireRole — пропускает запрос, только если роль из токена входит в roles.
+// Используется после AuthMiddleware
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission — пропускает запрос, если разрешение дает роль пользователя
+// или оно выдано ему лично. Используется после AuthMiddleware
+func RequirePermission(access *services.AccessService, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := access.HasPermission(c.GetUint("userID"), c.GetString("userRole"), permission)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: " + permission})
 			c.Abort()
 			return
 		}
-
-		// Если токен валиден, продолжаем выполнение запроса
-		c.Set("userID", userID)
 		c.Next()
 	}
 }
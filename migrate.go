@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"new/database"
+
+	"gorm.io/gorm"
+)
+
+// runMigrate выполняет подкоманду migrate и возвращает код завершения
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "использование: migrate up | down [N] | status")
+		return 2
+	}
+
+	database.Connect()
+	db := database.GetDB()
+
+	switch args[0] {
+	case "up":
+		var applied []database.Migration
+		err := database.WithMigrationLock(db, func(conn *gorm.DB) (err error) {
+			applied, err = database.MigrateUp(conn)
+			return err
+		})
+		for _, m := range applied {
+			fmt.Printf("применена %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("схема актуальна")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, "количество шагов должно быть положительным числом")
+				return 2
+			}
+			steps = n
+		}
+		var reverted []database.Migration
+		err := database.WithMigrationLock(db, func(conn *gorm.DB) (err error) {
+			reverted, err = database.MigrateDown(conn, steps)
+			return err
+		})
+		for _, m := range reverted {
+			fmt.Printf("откачена %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, state := range states {
+			applied := "не применена"
+			if state.Applied {
+				applied = "применена " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", state.Version, state.Name, applied)
+		}
+		if _, err := database.CheckSchema(db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "неизвестная команда migrate %s\n", args[0])
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AudioAsset — метаданные сгенерированного аудио, сами данные лежат в хранилище blobstore
+type AudioAsset struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Hash        string    `json:"hash" gorm:"size:64;uniqueIndex;not null"` // sha256 от текста и параметров синтеза
+	Provider    string    `json:"provider"`                                 // TTS-провайдер, сгенерировавший аудио
+	Voice       string    `json:"voice"`
+	Language    string    `json:"language"`
+	Format      string    `json:"format" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-" gorm:"not null"` // Ключ объекта в хранилище
+	CreatedAt   time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Статусы задания
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+)
+
+// Статусы отдельных мест в задании
+const (
+	TaskStatusPending = "pending"
+	TaskStatusRunning = "running"
+	TaskStatusDone    = "done"
+	TaskStatusFailed  = "failed"
+	TaskStatusSkipped = "skipped"
+)
+
+// Job — асинхронное задание на обработку массива OSM-объектов
+type Job struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Provider   string     `json:"provider"` // LLM-провайдер, пустой — провайдер по умолчанию
+	Language   string     `json:"language"` // Язык описаний, пустой — язык по умолчанию
+	Status     string     `json:"status" gorm:"not null;index"`
+	Total      int        `json:"total" gorm:"not null;default:0"`
+	Done       int        `json:"done" gorm:"not null;default:0"`
+	Failed     int        `json:"failed" gorm:"not null;default:0"`
+	Skipped    int        `json:"skipped" gorm:"not null;default:0"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// JobTask — обработка одного OSM-объекта в рамках задания
+type JobTask struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     uint      `json:"job_id" gorm:"not null;index"`
+	Position  int       `json:"position"` // Индекс объекта во входном массиве
+	OSMType   string    `json:"osm_type"`
+	OSMID     int64     `json:"osm_id"`
+	Input     string    `json:"-" gorm:"type:text;not null"` // Исходный OSM-объект в JSON
+	Status    string    `json:"status" gorm:"not null;index"`
+	Result    string    `json:"-" gorm:"type:text"` // Результат обработки в JSON
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Job       Job       `json:"-" gorm:"foreignKey:JobID;constraint:onUpdate:CASCADE,onDelete:CASCADE"`
+}
@@ -1,6 +1,21 @@
 package models
 
+import "time"
+
+// ListPreference — элемент каталога интересов, из которого пользователь выбирает предпочтения
 type ListPreference struct {
-	ID   uint   `gorm:"primaryKey" json:"id"`
-	Name string `gorm:"unique;not null" json:"name"`
+	ID           uint                        `gorm:"primaryKey" json:"id"`
+	Name         string                      `gorm:"unique;not null" json:"name"`
+	Position     int                         `gorm:"not null;default:0" json:"position"`  // Порядок в списке выбора
+	OSMTags      string                      `gorm:"not null;default:''" json:"osm_tags"` // Условия на теги OSM, например historic=*,tourism=museum
+	RetiredAt    *time.Time                  `json:"retired_at,omitempty"`                // Выведен из каталога; уже выбранные предпочтения сохраняются
+	Translations []ListPreferenceTranslation `gorm:"foreignKey:ListPreferenceID" json:"translations,omitempty"`
+}
+
+// ListPreferenceTranslation — название элемента каталога на другом языке
+type ListPreferenceTranslation struct {
+	ID               uint   `gorm:"primaryKey" json:"-"`
+	ListPreferenceID uint   `gorm:"not null;uniqueIndex:idx_list_preference_translations_lang" json:"-"`
+	Language         string `gorm:"size:16;not null;uniqueIndex:idx_list_preference_translations_lang" json:"language"`
+	Name             string `gorm:"not null" json:"name"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// OSMPlace — обработанный объект OSM с точкой на карте. В отличие от Place не привязан к пользователю
+type OSMPlace struct {
+	ID           uint                  `json:"id" gorm:"primaryKey"`
+	OSMType      string                `json:"osm_type" gorm:"size:16;not null;uniqueIndex:idx_osm_places_osm"`
+	OSMID        int64                 `json:"osm_id" gorm:"not null;uniqueIndex:idx_osm_places_osm"`
+	Name         string                `json:"name" gorm:"not null"`
+	Address      string                `json:"address"`
+	City         string                `json:"city"`
+	Lat          float64               `json:"lat" gorm:"not null"`
+	Lon          float64               `json:"lon" gorm:"not null"`
+	Geohash      string                `json:"geohash" gorm:"size:12;not null;index"`       // Геохеш точки для поиска по соседству
+	Tags         string                `json:"tags" gorm:"type:text;not null;default:'{}'"` // Теги OSM в JSON
+	CreatedAt    time.Time             `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	Descriptions []OSMPlaceDescription `json:"descriptions,omitempty" gorm:"foreignKey:PlaceID"`
+}
+
+// OSMPlaceDescription — описание места на одном языке от одного LLM-провайдера
+type OSMPlaceDescription struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	PlaceID       uint      `json:"place_id" gorm:"not null;uniqueIndex:idx_osm_place_descriptions_key"`
+	Language      string    `json:"language" gorm:"size:16;not null;uniqueIndex:idx_osm_place_descriptions_key"`
+	Provider      string    `json:"provider" gorm:"not null;uniqueIndex:idx_osm_place_descriptions_key"`
+	Name          string    `json:"name"` // Название места на языке описания
+	PromptVersion string    `json:"prompt_version"`
+	Text          string    `json:"text" gorm:"type:text;not null"`
+	AudioHash     string    `json:"audio_hash" gorm:"size:64"` // Хеш аудио в audio_assets
+	UpdatedAt     time.Time `json:"updated_at"`
+}
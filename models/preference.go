@@ -2,8 +2,8 @@ package models
 
 type Preference struct {
 	ID               uint           `json:"id" gorm:"primaryKey"`
-	UserID           uint           `json:"user_id" gorm:"not null;index"` // Внешний ключ для связи с User
-	ListPreferenceID uint           `json:"list_preference_id" gorm:"not null;index"`
+	UserID           uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_preferences_user_list_preference"` // Внешний ключ для связи с User
+	ListPreferenceID uint           `json:"list_preference_id" gorm:"not null;uniqueIndex:idx_preferences_user_list_preference"`
 	ListPreference   ListPreference `json:"list_preference" gorm:"foreignKey:ListPreferenceID;constraint:onUpdate:CASCADE,onDelete:RESTRICT"`
 	User             User           `json:"-" gorm:"foreignKey:UserID;constraint:onUpdate:CASCADE,onDelete:CASCADE"`
 }
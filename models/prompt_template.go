@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// PromptTemplate — версия шаблона промпта (text/template) для варианта name/language/audience.
+// Пустые Language и Audience означают вариант по умолчанию; активна одна версия варианта
+type PromptTemplate struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:64;not null;uniqueIndex:idx_prompt_templates_version"`
+	Language  string    `json:"language" gorm:"size:16;not null;default:'';uniqueIndex:idx_prompt_templates_version"`
+	Audience  string    `json:"audience" gorm:"size:32;not null;default:'';uniqueIndex:idx_prompt_templates_version"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_prompt_templates_version"`
+	Body      string    `json:"body" gorm:"type:text;not null"`
+	Active    bool      `json:"active" gorm:"not null;default:false"`
+	CreatedBy *uint     `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Tag — метка версии шаблона, которая попадает в ключ кеша описаний
+func (t *PromptTemplate) Tag() string {
+	return fmt.Sprintf("tpl%dv%d", t.ID, t.Version)
+}
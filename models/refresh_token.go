@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RefreshToken — refresh-токен пользователя; хранится только sha256-хеш.
+// Токены одной цепочки ротации объединены в семейство (FamilyID)
+type RefreshToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index;not null"`
+	FamilyID   string     `json:"family_id" gorm:"size:32;index;not null"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *uint      `json:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	User       User       `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
+
+// Разрешения для административных операций
+const (
+	PermManagePreferences = "preferences:manage" // Управление каталогом ListPreference
+	PermInspectCache      = "cache:inspect"      // Просмотр кешей
+	PermPurgeCache        = "cache:purge"        // Очистка кешей
+	PermPurgeHistory      = "history:purge"      // Очистка истории пользователей
+	PermManageUsers       = "users:manage"       // Назначение ролей и разрешений
+	PermManagePrompts     = "prompts:manage"     // Редактирование шаблонов промптов
+)
+
+// RolePermissions — разрешения, которые дает роль
+var RolePermissions = map[string][]string{
+	RoleUser:  {},
+	RoleStaff: {PermManagePreferences, PermInspectCache, PermManagePrompts},
+	RoleAdmin: {PermManagePreferences, PermInspectCache, PermPurgeCache, PermPurgeHistory, PermManageUsers, PermManagePrompts},
+}
+
+// Permissions — все известные разрешения
+var Permissions = RolePermissions[RoleAdmin]
+
+// IsValidRole проверяет, что роль известна
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// IsValidPermission проверяет, что разрешение известно
+func IsValidPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UserPermission — разрешение, выданное пользователю сверх его роли
+type UserPermission struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserID     uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_user_permissions_user_permission"`
+	Permission string `json:"permission" gorm:"size:64;not null;uniqueIndex:idx_user_permissions_user_permission"`
+	User       User   `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -6,4 +6,6 @@ type User struct {
 	Username string `json:"username" gorm:"unique"`
 	Password string `json:"password"`
 	Email    string `json:"email" gorm:"unique"`
+	Role     string `json:"role" gorm:"size:32;not null;default:user"` // Роль пользователя: user, staff или admin
+	Locale   string `json:"locale" gorm:"size:16;not null;default:''"` // Язык описаний по умолчанию, например ru или en
 }
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"new/dto"
+	"new/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownRole — роль не существует
+var ErrUnknownRole = errors.New("неизвестная роль")
+
+// ErrUnknownPermission — разрешение не существует
+var ErrUnknownPermission = errors.New("неизвестное разрешение")
+
+// AccessService хранит роли и дополнительные разрешения пользователей
+type AccessService struct {
+	DB     *gorm.DB
+	Tokens *TokenService
+}
+
+// HasPermission проверяет разрешение по роли и по разрешениям, выданным пользователю лично
+func (s *AccessService) HasPermission(userID uint, role, permission string) (bool, error) {
+	for _, p := range models.RolePermissions[role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+
+	var count int64
+	err := s.DB.Model(&models.UserPermission{}).
+		Where("user_id = ? AND permission = ?", userID, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки разрешения: %v", err)
+	}
+	return count > 0, nil
+}
+
+// ListUsers возвращает пользователей с ролями и дополнительными разрешениями
+func (s *AccessService) ListUsers() ([]dto.AdminUserDTO, error) {
+	var users []models.User
+	if err := s.DB.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	var grants []models.UserPermission
+	if err := s.DB.Order("permission").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	byUser := make(map[uint][]string)
+	for _, g := range grants {
+		byUser[g.UserID] = append(byUser[g.UserID], g.Permission)
+	}
+
+	result := make([]dto.AdminUserDTO, 0, len(users))
+	for _, u := range users {
+		permissions := byUser[u.ID]
+		if permissions == nil {
+			permissions = []string{}
+		}
+		result = append(result, dto.AdminUserDTO{
+			ID:          u.ID,
+			Username:    u.Username,
+			Email:       u.Email,
+			Role:        u.Role,
+			Permissions: permissions,
+		})
+	}
+	return result, nil
+}
+
+// SetRole назначает роль и отзывает токены пользователя, чтобы новая роль вступила в силу сразу
+func (s *AccessService) SetRole(userID uint, role string) error {
+	if !models.IsValidRole(role) {
+		return ErrUnknownRole
+	}
+	res := s.DB.Model(&models.User{}).Where("id = ?", userID).Update("role", role)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if s.Tokens == nil {
+		return nil
+	}
+	return s.Tokens.RevokeUser(userID)
+}
+
+// SetPermissions заменяет набор дополнительных разрешений пользователя
+func (s *AccessService) SetPermissions(userID uint, permissions []string) ([]string, error) {
+	unique := make(map[string]struct{}, len(permissions))
+	for _, p := range permissions {
+		if !models.IsValidPermission(p) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, p)
+		}
+		unique[p] = struct{}{}
+	}
+	result := make([]string, 0, len(unique))
+	for p := range unique {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserPermission{}).Error; err != nil {
+			return err
+		}
+		for _, p := range result {
+			if err := tx.Create(&models.UserPermission{UserID: userID, Permission: p}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EnsureAdmins назначает роль admin пользователям из списка email (ADMIN_EMAILS)
+func (s *AccessService) EnsureAdmins(emails string) error {
+	var list []string
+	for _, email := range strings.Split(emails, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			list = append(list, email)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return s.DB.Model(&models.User{}).
+		Where("email IN ? AND role <> ?", list, models.RoleAdmin).
+		Update("role", models.RoleAdmin).Error
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"new/database"
+	"new/dto"
+	"new/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrCachePrefix — префикс не относится к управляемым кешам
+var ErrCachePrefix = errors.New("префикс кеша должен начинаться с одного из: " + strings.Join(cacheNamespaces, ", "))
+
+// cacheNamespaces — пространства ключей Redis, которые можно просматривать и очищать
+var cacheNamespaces = []string{"llm:desc:", "llm:user:", "lock:"}
+
+// AdminService — служебные операции над кешами и историей
+type AdminService struct {
+	DB *gorm.DB
+}
+
+// checkCachePrefix проверяет, что префикс лежит внутри одного из управляемых пространств
+func checkCachePrefix(prefix string) error {
+	for _, ns := range cacheNamespaces {
+		if strings.HasPrefix(prefix, ns) {
+			return nil
+		}
+	}
+	return ErrCachePrefix
+}
+
+// scanCache обходит ключи Redis с префиксом prefix
+func scanCache(prefix string, fn func(keys []string) error) error {
+	if database.RedisClient == nil {
+		return errCacheUnavailable
+	}
+	ctx := context.Background()
+	var cursor uint64
+	for {
+		keys, next, err := database.RedisClient.Scan(ctx, cursor, prefix+"*", 500).Result()
+		if err != nil {
+			return fmt.Errorf("ошибка обхода кеша: %v", err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// InspectCache возвращает число ключей с префиксом и первые limit из них со временем жизни
+func (s *AdminService) InspectCache(prefix string, limit int) (*dto.CacheInspectDTO, error) {
+	if err := checkCachePrefix(prefix); err != nil {
+		return nil, err
+	}
+	result := &dto.CacheInspectDTO{Prefix: prefix, Entries: []dto.CacheEntryDTO{}}
+	ctx := context.Background()
+
+	err := scanCache(prefix, func(keys []string) error {
+		result.Count += len(keys)
+		for _, key := range keys {
+			if len(result.Entries) >= limit {
+				break
+			}
+			ttl, err := database.RedisClient.TTL(ctx, key).Result()
+			if err != nil {
+				return fmt.Errorf("ошибка чтения TTL: %v", err)
+			}
+			seconds := int64(-1)
+			if ttl > 0 {
+				seconds = int64(ttl / time.Second)
+			}
+			result.Entries = append(result.Entries, dto.CacheEntryDTO{Key: key, TTLSeconds: seconds})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PurgeCache удаляет ключи с префиксом и возвращает их количество
+func (s *AdminService) PurgeCache(prefix string) (int64, error) {
+	if err := checkCachePrefix(prefix); err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err := scanCache(prefix, func(keys []string) error {
+		n, err := database.RedisClient.Del(context.Background(), keys...).Result()
+		if err != nil {
+			return fmt.Errorf("ошибка очистки кеша: %v", err)
+		}
+		deleted += n
+		return nil
+	})
+	return deleted, err
+}
+
+// PurgeHistory удаляет историю мест: всю, одного пользователя (userID > 0)
+// и/или записи старше before (если задано)
+func (s *AdminService) PurgeHistory(userID uint, before time.Time) (int64, error) {
+	query := s.DB.Where("1 = 1")
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if !before.IsZero() {
+		query = query.Where("created_at < ?", before)
+	}
+	res := query.Delete(&models.Place{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("ошибка очистки истории: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
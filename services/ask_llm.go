@@ -1,38 +1,28 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
+	"context"
+	"fmt"
 	"new/models"
-	"os"
+	"new/services/llm"
 )
 
+// AskLLMService задаёт вопросы LLM-провайдеру, выбранному в конфигурации
+type AskLLMService struct {
+	LLM      *llm.Registry
+	Provider string // Имя провайдера (ASK_LLM_PROVIDER), пустое значение — провайдер по умолчанию
+}
 
-type AskLLMService struct{}
+func (s *AskLLMService) AskLLMQuestion(ctx context.Context, question models.Question) (string, error) {
+	if s.LLM == nil {
+		return "", fmt.Errorf("LLM-провайдеры не настроены")
+	}
 
-func (s *AskLLMService) AskLLMQuestion(question models.Question) (string, error) {
-	// Конвертируем вопрос в json
-    jsonData, err := json.Marshal(question)
-    if err != nil {
-        return "", err
-    }
+	provider, err := s.LLM.Get(s.Provider)
+	if err != nil {
+		return "", err
+	}
 
-    // Отправка POST запроса на FastAPI сервер открытый по порту 8000
-    resp, err := http.Post(os.Getenv("HOST_LLM"), "application/json", bytes.NewBuffer(jsonData))
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
-
-    // Проверка, что есть ответ
-    if resp.StatusCode != http.StatusOK {
-        return "", err
-    }
-
-    var result map[string]interface{}
-    json.NewDecoder(resp.Body).Decode(&result)
-    
-    // Возвращает ответ от FastAPI сервера
-    return result["message"].(string), nil
-}
\ No newline at end of file
+	// Возвращает ответ от выбранного провайдера
+	return provider.Ask(ctx, question.Message)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"new/models"
+	"new/services/blobstore"
+	"new/services/tts"
+
+	"gorm.io/gorm"
+)
+
+// AudioCacheService хранит сгенерированное аудио по хешу содержимого,
+// чтобы повторные запросы не обращались к TTS-сервису
+type AudioCacheService struct {
+	DB      *gorm.DB
+	Store   blobstore.Store
+	TTS     *tts.Registry
+	BaseURL string // Префикс ссылки на аудио, например /api/audio/
+
+	flight Coalescer // Объединяет одновременные генерации одного аудио
+}
+
+// NewAudioCacheService создает кеш аудио. Префикс ссылок берется из AUDIO_BASE_URL
+func NewAudioCacheService(db *gorm.DB, store blobstore.Store, ttsRegistry *tts.Registry) *AudioCacheService {
+	baseURL := os.Getenv("AUDIO_BASE_URL")
+	if baseURL == "" {
+		baseURL = "/api/audio/"
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &AudioCacheService{DB: db, Store: store, TTS: ttsRegistry, BaseURL: baseURL}
+}
+
+// AudioHash вычисляет ключ кеша аудио по тексту, голосу, формату, скорости речи и языку.
+// Язык добавляется только если задан, поэтому ключи аудио без языка не меняются
+func AudioHash(text string, opts tts.Options) string {
+	h := sha256.New()
+	parts := []string{text, opts.Voice, opts.Format, strconv.FormatFloat(opts.Speed, 'f', -1, 64)}
+	if opts.Language != "" {
+		parts = append(parts, opts.Language)
+	}
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// URL возвращает стабильную ссылку на аудио
+func (s *AudioCacheService) URL(asset *models.AudioAsset) string {
+	return s.BaseURL + asset.Hash
+}
+
+// GetOrCreate возвращает аудио из кеша или генерирует его и сохраняет в хранилище.
+// Одновременные запросы одного и того же аудио ждут одну генерацию
+func (s *AudioCacheService) GetOrCreate(ctx context.Context, providerName, text string, opts tts.Options) (*models.AudioAsset, error) {
+	opts = s.TTS.Resolve(opts)
+	hash := AudioHash(text, opts)
+
+	lookup := func(ctx context.Context) (string, bool, error) {
+		asset, err := s.FindByHash(ctx, hash)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		exists, err := s.Store.Exists(asset.StorageKey)
+		if err != nil {
+			return "", false, fmt.Errorf("ошибка проверки хранилища: %v", err)
+		}
+		// Метаданные без файла считаются промахом — аудио будет сгенерировано заново
+		return hash, exists, nil
+	}
+
+	generate := func(ctx context.Context) (string, error) {
+		return hash, s.create(ctx, providerName, text, hash, opts)
+	}
+
+	if _, _, err := s.flight.Do(ctx, "audio:"+hash, lookup, generate); err != nil {
+		return nil, err
+	}
+	return s.FindByHash(ctx, hash)
+}
+
+// create генерирует аудио, сохраняет его в хранилище и записывает метаданные
+func (s *AudioCacheService) create(ctx context.Context, providerName, text, hash string, opts tts.Options) error {
+	audio, err := s.TTS.Synthesize(ctx, providerName, text, opts)
+	if err != nil {
+		return err
+	}
+
+	storageKey := fmt.Sprintf("audio/%s/%s.%s", hash[:2], hash, opts.Format)
+	if err := s.Store.Put(storageKey, audio.Data); err != nil {
+		return fmt.Errorf("ошибка сохранения аудио: %v", err)
+	}
+
+	if providerName == "" {
+		if provider, err := s.TTS.Get(""); err == nil {
+			providerName = provider.Name()
+		}
+	}
+	asset := models.AudioAsset{
+		Hash:        hash,
+		Provider:    providerName,
+		Voice:       opts.Voice,
+		Language:    opts.Language,
+		Format:      opts.Format,
+		ContentType: audio.ContentType,
+		Size:        int64(len(audio.Data)),
+		StorageKey:  storageKey,
+	}
+
+	// Если запись уже есть (файл был потерян), обновляем её
+	if err := s.DB.WithContext(ctx).Where(models.AudioAsset{Hash: hash}).Assign(asset).FirstOrCreate(&asset).Error; err != nil {
+		return fmt.Errorf("ошибка сохранения метаданных аудио: %v", err)
+	}
+	return nil
+}
+
+// FindByHash возвращает метаданные аудио по хешу
+func (s *AudioCacheService) FindByHash(ctx context.Context, hash string) (*models.AudioAsset, error) {
+	var asset models.AudioAsset
+	if err := s.DB.WithContext(ctx).Where("hash = ?", hash).First(&asset).Error; err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Load читает данные аудио из хранилища
+func (s *AudioCacheService) Load(asset *models.AudioAsset) ([]byte, error) {
+	obj, err := s.Store.Open(asset.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
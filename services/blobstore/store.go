@@ -0,0 +1,153 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound возвращается, если объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("объект не найден в хранилище")
+
+// Object — открытый на чтение объект хранилища
+type Object interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// Store описывает хранилище бинарных объектов (аудио и т.п.)
+type Store interface {
+	// Put сохраняет данные под ключом, перезаписывая существующий объект
+	Put(key string, data []byte) error
+	// Open открывает объект на чтение
+	Open(key string) (Object, error)
+	// Exists проверяет наличие объекта
+	Exists(key string) (bool, error)
+	// Delete удаляет объект, отсутствие объекта ошибкой не считается
+	Delete(key string) error
+}
+
+// NewFromEnv создает хранилище по переменным окружения:
+// BLOB_STORE — тип хранилища (пока только local), BLOB_STORE_DIR — каталог для local
+func NewFromEnv() (Store, error) {
+	kind := os.Getenv("BLOB_STORE")
+	switch kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_STORE_DIR")
+		if dir == "" {
+			dir = "data/blobs"
+		}
+		return NewLocalStore(dir)
+	default:
+		return nil, fmt.Errorf("неизвестный тип хранилища: %s", kind)
+	}
+}
+
+// LocalStore хранит объекты в файлах на локальном диске
+type LocalStore struct {
+	Root string
+}
+
+// NewLocalStore создает хранилище в каталоге root, создавая его при необходимости
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога хранилища: %v", err)
+	}
+	return &LocalStore{Root: root}, nil
+}
+
+// path преобразует ключ в путь к файлу, не позволяя выйти за пределы корня
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("некорректный ключ объекта: %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(clean)), nil
+}
+
+// Put атомарно записывает объект через временный файл
+func (s *LocalStore) Put(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ошибка создания каталога: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи файла: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи файла: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сохранения файла: %v", err)
+	}
+	return nil
+}
+
+type localObject struct {
+	*os.File
+	size int64
+}
+
+func (o *localObject) Size() int64 {
+	return o.size
+}
+
+// Open открывает файл объекта
+func (s *LocalStore) Open(key string) (Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &localObject{File: file, size: info.Size()}, nil
+}
+
+// Exists проверяет наличие файла объекта
+func (s *LocalStore) Exists(key string) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete удаляет файл объекта
+func (s *LocalStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
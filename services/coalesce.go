@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"new/database"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+)
+
+// Параметры распределённой блокировки по умолчанию
+const (
+	defaultLockTTL       = 2 * time.Minute        // Время жизни блокировки, продлевается пока идет генерация
+	defaultLockPollDelay = 500 * time.Millisecond // Интервал проверки результата чужой генерации
+	lockWaitTimeout      = 10 * time.Minute       // Сколько ждать результат чужой генерации
+)
+
+// releaseLockScript снимает блокировку, только если она всё ещё принадлежит нам
+var releaseLockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// extendLockScript продлевает блокировку, только если она всё ещё принадлежит нам
+var extendLockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// Coalescer объединяет одновременные генерации одного и того же ключа:
+// внутри процесса через singleflight, между экземплярами сервера через блокировку в Redis.
+// Общая генерация отменяется, только когда ее перестают ждать все вызывающие.
+// Нулевое значение готово к использованию
+type Coalescer struct {
+	LockTTL   time.Duration // Время жизни блокировки в Redis, по умолчанию 2 минуты
+	PollDelay time.Duration // Интервал проверки чужой генерации, по умолчанию 500 мс
+
+	group   singleflight.Group
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight — контекст общей генерации ключа и число ее ожидающих
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// Do возвращает значение для key. lookup ищет готовое значение (например, в кеше),
+// generate создаёт и сохраняет его. Пока одна генерация выполняется, остальные запросы
+// с тем же ключом ждут её результат. shared сообщает, что значение получено не этим вызовом generate.
+// При отмене ctx вызов сразу возвращает ctx.Err()
+func (c *Coalescer) Do(ctx context.Context, key string, lookup func(context.Context) (string, bool, error), generate func(context.Context) (string, error)) (value string, shared bool, err error) {
+	f := c.join(key)
+	defer c.leave(key, f)
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		return c.doDistributed(f.ctx, key, lookup, generate)
+	})
+	select {
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Shared, res.Err
+		}
+		result := res.Val.(coalesceResult)
+		return result.value, res.Shared || result.fromLookup, nil
+	}
+}
+
+// join регистрирует ожидающего генерации key
+func (c *Coalescer) join(key string) *flight {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flights == nil {
+		c.flights = make(map[string]*flight)
+	}
+	f, ok := c.flights[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		f = &flight{ctx: ctx, cancel: cancel}
+		c.flights[key] = f
+	}
+	f.waiters++
+	return f
+}
+
+// leave снимает ожидающего. Когда ожидающих не остается, генерация отменяется,
+// а следующий вызов с тем же ключом начинает новую
+func (c *Coalescer) leave(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f.waiters--; f.waiters > 0 {
+		return
+	}
+	f.cancel()
+	delete(c.flights, key)
+	c.group.Forget(key)
+}
+
+type coalesceResult struct {
+	value      string
+	fromLookup bool
+}
+
+// doDistributed выполняет генерацию под блокировкой Redis или дожидается чужой генерации
+func (c *Coalescer) doDistributed(ctx context.Context, key string, lookup func(context.Context) (string, bool, error), generate func(context.Context) (string, error)) (coalesceResult, error) {
+	if value, ok, err := lookup(ctx); err != nil {
+		return coalesceResult{}, err
+	} else if ok {
+		return coalesceResult{value: value, fromLookup: true}, nil
+	}
+
+	// Без Redis остаётся только объединение внутри процесса
+	if database.RedisClient == nil {
+		value, err := generate(ctx)
+		return coalesceResult{value: value}, err
+	}
+
+	lockKey := "lock:" + key
+	token, err := newLockToken()
+	if err != nil {
+		return coalesceResult{}, err
+	}
+
+	deadline := time.Now().Add(lockWaitTimeout)
+	for {
+		acquired, err := database.RedisClient.SetNX(ctx, lockKey, token, c.lockTTL()).Result()
+		if err != nil && ctx.Err() != nil {
+			return coalesceResult{}, ctx.Err()
+		} else if err != nil {
+			// Redis недоступен — генерируем сами, чтобы не блокировать пользователя
+			fmt.Printf("Ошибка при захвате блокировки %s: %v\n", lockKey, err)
+			value, err := generate(ctx)
+			return coalesceResult{value: value}, err
+		}
+
+		if acquired {
+			value, err := c.generateLocked(ctx, lockKey, token, generate)
+			return coalesceResult{value: value}, err
+		}
+
+		// Блокировку держит другой экземпляр — ждём, пока результат появится или блокировка освободится
+		for {
+			if time.Now().After(deadline) {
+				return coalesceResult{}, fmt.Errorf("превышено время ожидания генерации для %s", key)
+			}
+			select {
+			case <-ctx.Done():
+				return coalesceResult{}, ctx.Err()
+			case <-time.After(c.pollDelay()):
+			}
+
+			if value, ok, err := lookup(ctx); err != nil {
+				return coalesceResult{}, err
+			} else if ok {
+				return coalesceResult{value: value, fromLookup: true}, nil
+			}
+
+			exists, err := database.RedisClient.Exists(ctx, lockKey).Result()
+			if err != nil || exists == 0 {
+				// Владелец блокировки завершился без результата — пробуем сами
+				break
+			}
+		}
+	}
+}
+
+// generateLocked выполняет генерацию, продлевая блокировку, и снимает её по завершении
+func (c *Coalescer) generateLocked(ctx context.Context, lockKey, token string, generate func(context.Context) (string, error)) (string, error) {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		// Блокировка снимается и после отмены генерации, иначе другие экземпляры ждали бы ее истечения
+		if err := releaseLockScript.Run(context.WithoutCancel(ctx), database.RedisClient, []string{lockKey}, token).Err(); err != nil && err != redis.Nil {
+			fmt.Printf("Ошибка при снятии блокировки %s: %v\n", lockKey, err)
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(c.lockTTL() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				extendLockScript.Run(ctx, database.RedisClient, []string{lockKey}, token, c.lockTTL().Milliseconds())
+			}
+		}
+	}()
+
+	return generate(ctx)
+}
+
+func (c *Coalescer) lockTTL() time.Duration {
+	if c.LockTTL > 0 {
+		return c.LockTTL
+	}
+	return defaultLockTTL
+}
+
+func (c *Coalescer) pollDelay() time.Duration {
+	if c.PollDelay > 0 {
+		return c.PollDelay
+	}
+	return defaultLockPollDelay
+}
+
+// newLockToken создаёт случайный идентификатор владельца блокировки
+func newLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации токена блокировки: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
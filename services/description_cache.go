@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"new/services/llm"
+)
+
+// Версия промпта (когда шаблоны в базе не настроены) и язык по умолчанию входят в ключ общего кеша описаний
+const (
+	PromptVersion   = "v1"
+	DefaultLanguage = "ru"
+)
+
+// Время жизни описаний в кеше
+const (
+	sharedDescriptionTTL   = 7 * 24 * time.Hour
+	personalDescriptionTTL = 24 * time.Hour
+)
+
+// DescriptionKey идентифицирует каноническое описание места, общее для всех пользователей
+type DescriptionKey struct {
+	Place         string // Идентификатор объекта OSM, например node/123
+	Language      string
+	PromptVersion string
+	Provider      string
+	Tags          string // Отпечаток данных места, по которым строится промпт
+}
+
+// String возвращает ключ Redis для общего описания
+func (k DescriptionKey) String() string {
+	return fmt.Sprintf("llm:desc:%s:%s:%s:%s:%s", k.Place, k.Language, k.PromptVersion, k.Provider, k.Tags)
+}
+
+// UserKey возвращает ключ Redis для персонального варианта описания,
+// зависящего от набора предпочтений пользователя
+func (k DescriptionKey) UserKey(userID uint, preferencesHash string) string {
+	return fmt.Sprintf("llm:user:%d:prefs:%s:%s", userID, preferencesHash, k.String())
+}
+
+// placeIdentity возвращает идентификатор объекта OSM, а для мест без него — название
+func placeIdentity(place map[string]string) string {
+	if place["osm_id"] != "" && place["type"] != "" {
+		return place["type"] + "/" + place["osm_id"]
+	}
+	return "name/" + place["place_name"]
+}
+
+// placeFingerprint возвращает отпечаток данных места, которые попадают в промпт. Теги приходят от клиента,
+// поэтому описание по другим тегам того же объекта хранится под другим ключом и не подменяет общее.
+// Интересы и готовый промпт не учитываются: их покрывают отпечаток предпочтений и версия шаблона
+func placeFingerprint(place map[string]string) string {
+	keys := make([]string, 0, len(place))
+	for k := range place {
+		if k != llm.InterestsField && k != llm.PromptField && k != promptVersionKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(place[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// descriptionKey формирует ключ общего кеша описаний для места
+func (s *PlaceService) descriptionKey(providerName string, place map[string]string) (DescriptionKey, error) {
+	if s.LLM == nil {
+		return DescriptionKey{}, fmt.Errorf("LLM-провайдеры не настроены")
+	}
+	provider, err := s.LLM.Get(providerName)
+	if err != nil {
+		return DescriptionKey{}, err
+	}
+
+	language := place["language"]
+	if language == "" {
+		language = DefaultLanguage
+	}
+
+	// Версия шаблона промпта входит в ключ: новая версия шаблона дает новые описания
+	version := place[promptVersionKey]
+	if version == "" {
+		tpl, err := s.placeTemplate(place)
+		if err != nil {
+			return DescriptionKey{}, err
+		}
+		version = PromptVersion
+		if tpl != nil {
+			version = tpl.Tag()
+		}
+	}
+
+	return DescriptionKey{
+		Place:         placeIdentity(place),
+		Language:      language,
+		PromptVersion: version,
+		Provider:      provider.Name(),
+		Tags:          placeFingerprint(place),
+	}, nil
+}
+
+// descriptionCacheKey возвращает ключ кеша описания места и время жизни записи:
+// общий ключ, если предпочтений нет (preferencesHash пустой), иначе персональный ключ пользователя
+func (s *PlaceService) descriptionCacheKey(userID uint, preferencesHash, providerName string, place map[string]string) (string, time.Duration, error) {
+	key, err := s.descriptionKey(providerName, place)
+	if err != nil {
+		return "", 0, err
+	}
+	if preferencesHash == "" {
+		return key.String(), sharedDescriptionTTL, nil
+	}
+	return key.UserKey(userID, preferencesHash), personalDescriptionTTL, nil
+}
@@ -0,0 +1,79 @@
+package geo
+
+import "math"
+
+// earthRadius — средний радиус Земли в метрах
+const earthRadius = 6371000.0
+
+// Distance возвращает расстояние между точками по большому кругу в метрах
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing возвращает начальный азимут от a к b в градусах (0 — север, 90 — восток)
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Compass возвращает одно из восьми направлений (N, NE, E, SE, S, SW, W, NW) для азимута
+func Compass(bearing float64) string {
+	directions := [...]string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return directions[int(math.Mod(bearing+22.5, 360)/45)%8]
+}
+
+// DistanceTo возвращает расстояние от точки до фигуры в метрах: 0 внутри полигона,
+// иначе до ближайшей точки, линии или границы полигона
+func (s *Shape) DistanceTo(p Point) float64 {
+	best := math.Inf(1)
+	for _, polygon := range s.Polygons {
+		if containsPoint(polygon, p) {
+			return 0
+		}
+		for _, ring := range polygon {
+			best = math.Min(best, lineDistance(p, ring))
+		}
+	}
+	for _, line := range s.Lines {
+		best = math.Min(best, lineDistance(p, line))
+	}
+	for _, q := range s.Points {
+		best = math.Min(best, Distance(p, q))
+	}
+	return best
+}
+
+// lineDistance возвращает расстояние от точки до ломаной в метрах
+func lineDistance(p Point, line []Point) float64 {
+	if len(line) == 1 {
+		return Distance(p, line[0])
+	}
+	best := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		best = math.Min(best, segmentDistance(p, line[i-1], line[i]))
+	}
+	return best
+}
+
+// segmentDistance возвращает расстояние от точки до отрезка в метрах.
+// Считается в локальной проекции вокруг p, что точно на расстояниях до нескольких километров
+func segmentDistance(p, a, b Point) float64 {
+	kx := metersPerDegree * math.Cos(p.Lat*math.Pi/180)
+	ax, ay := (a.Lon-p.Lon)*kx, (a.Lat-p.Lat)*metersPerDegree
+	bx, by := (b.Lon-p.Lon)*kx, (b.Lat-p.Lat)*metersPerDegree
+	dx, dy := bx-ax, by-ay
+
+	// Проекция начала координат (точки p) на отрезок
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// GeohashPrecision — длина геохеша, с которой места сохраняются в базе (ячейка около 5×5 м)
+const GeohashPrecision = 9
+
+// metersPerDegree — длина градуса меридиана в метрах
+const metersPerDegree = 111320.0
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash кодирует точку в геохеш заданной длины
+func Geohash(p Point, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	var hash strings.Builder
+	bits, ch, even := 0, 0, true
+	for hash.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if p.Lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if p.Lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// cellSize возвращает размер ячейки геохеша заданной длины в градусах
+func cellSize(precision int) (latDegrees, lonDegrees float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+// CoverPrefixes возвращает префиксы геохешей, ячейки которых покрывают круг радиуса radius метров:
+// ячейку центра и соседние с ней. Длина префикса выбирается так, чтобы ячейка была не меньше радиуса
+func CoverPrefixes(center Point, radius float64) []string {
+	precision := 1
+	for p := GeohashPrecision; p > 1; p-- {
+		latDegrees, lonDegrees := cellSize(p)
+		height := latDegrees * metersPerDegree
+		width := lonDegrees * metersPerDegree * math.Cos(center.Lat*math.Pi/180)
+		if math.Min(height, width) >= radius {
+			precision = p
+			break
+		}
+	}
+
+	latDegrees, lonDegrees := cellSize(precision)
+	seen := make(map[string]bool, 9)
+	var prefixes []string
+	for _, dLat := range []float64{0, -1, 1} {
+		for _, dLon := range []float64{0, -1, 1} {
+			lat := center.Lat + dLat*latDegrees
+			if lat < -90 || lat > 90 {
+				continue
+			}
+			// Соседи за антимеридианом
+			lon := math.Mod(center.Lon+dLon*lonDegrees+540, 360) - 180
+			prefix := Geohash(Point{Lat: lat, Lon: lon}, precision)
+			if !seen[prefix] {
+				seen[prefix] = true
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,302 @@
+package geo
+
+import "new/dto"
+
+// maxRelationDepth ограничивает вложенность relation, чтобы не уйти в бесконечную рекурсию
+const maxRelationDepth = 8
+
+// Index — объекты одного входного массива, проиндексированные по типу и ID.
+// Позволяет разрешить ссылки way на узлы и участников relation
+type Index struct {
+	nodes     map[int64]Point
+	ways      map[int64]*dto.OSMObject
+	relations map[int64]*dto.OSMObject
+}
+
+// NewIndex индексирует объекты входного массива
+func NewIndex(objects []dto.OSMObject) *Index {
+	index := &Index{
+		nodes:     make(map[int64]Point),
+		ways:      make(map[int64]*dto.OSMObject),
+		relations: make(map[int64]*dto.OSMObject),
+	}
+	for i := range objects {
+		obj := &objects[i]
+		switch obj.Type {
+		case "node":
+			index.nodes[obj.ID] = Point{Lat: obj.Lat, Lon: obj.Lon}
+		case "way":
+			index.ways[obj.ID] = obj
+		case "relation":
+			index.relations[obj.ID] = obj
+		}
+	}
+	return index
+}
+
+// Resolve строит геометрию объекта. Возвращает nil, если координаты найти не удалось
+func (idx *Index) Resolve(obj dto.OSMObject) *Shape {
+	var shape *Shape
+	switch obj.Type {
+	case "node":
+		if obj.Lat != 0 || obj.Lon != 0 {
+			shape = &Shape{Points: []Point{{Lat: obj.Lat, Lon: obj.Lon}}}
+		}
+	case "way":
+		shape = shapeFromLine(idx.wayPoints(&obj))
+	case "relation":
+		shape = idx.relationShape(&obj, map[int64]bool{}, 0)
+	}
+
+	// Для объектов без геометрии остается центр из out center
+	if shape.Empty() && obj.Center != nil {
+		shape = &Shape{Points: []Point{{Lat: obj.Center.Lat, Lon: obj.Center.Lon}}}
+	}
+	if shape.Empty() {
+		return nil
+	}
+	return shape
+}
+
+// wayPoints возвращает координаты узлов way: по ссылкам на узлы из того же массива,
+// а если хотя бы одного узла нет — из поля geometry
+func (idx *Index) wayPoints(way *dto.OSMObject) []Point {
+	if len(way.Nodes) > 0 {
+		points := make([]Point, 0, len(way.Nodes))
+		for _, ref := range way.Nodes {
+			p, ok := idx.nodes[ref]
+			if !ok {
+				points = nil
+				break
+			}
+			points = append(points, p)
+		}
+		if points != nil {
+			return points
+		}
+	}
+	return coordinatesToPoints(way.Geometry)
+}
+
+// memberWayPoints возвращает координаты way-участника relation
+func (idx *Index) memberWayPoints(member dto.OSMMember) []Point {
+	if way, ok := idx.ways[member.Ref]; ok {
+		if points := idx.wayPoints(way); len(points) > 0 {
+			return points
+		}
+	}
+	return coordinatesToPoints(member.Geometry)
+}
+
+// memberNode возвращает координаты node-участника relation
+func (idx *Index) memberNode(member dto.OSMMember) (Point, bool) {
+	if p, ok := idx.nodes[member.Ref]; ok {
+		return p, true
+	}
+	if member.Lat != 0 || member.Lon != 0 {
+		return Point{Lat: member.Lat, Lon: member.Lon}, true
+	}
+	return Point{}, false
+}
+
+// relationShape собирает геометрию relation. Для multipolygon и boundary участники outer и inner
+// склеиваются в кольца, для остальных relation геометрия — набор линий и точек участников
+func (idx *Index) relationShape(relation *dto.OSMObject, visited map[int64]bool, depth int) *Shape {
+	if visited[relation.ID] || depth > maxRelationDepth {
+		return nil
+	}
+	visited[relation.ID] = true
+
+	kind := relation.Tags["type"]
+	areal := kind == "multipolygon" || kind == "boundary"
+
+	shape := &Shape{}
+	var outer, inner [][]Point
+	for _, member := range relation.Members {
+		switch member.Type {
+		case "node":
+			if p, ok := idx.memberNode(member); ok {
+				shape.Points = append(shape.Points, p)
+			}
+		case "way":
+			points := idx.memberWayPoints(member)
+			if len(points) == 0 {
+				continue
+			}
+			switch {
+			case areal && member.Role == "inner":
+				inner = append(inner, points)
+			case areal && (member.Role == "outer" || member.Role == ""):
+				outer = append(outer, points)
+			default:
+				shape.Lines = append(shape.Lines, points)
+			}
+		case "relation":
+			nested, ok := idx.relations[member.Ref]
+			if !ok {
+				continue
+			}
+			if part := idx.relationShape(nested, visited, depth+1); part != nil {
+				shape.Points = append(shape.Points, part.Points...)
+				shape.Lines = append(shape.Lines, part.Lines...)
+				shape.Polygons = append(shape.Polygons, part.Polygons...)
+			}
+		}
+	}
+
+	if areal {
+		outerRings, openOuter := assembleRings(outer)
+		innerRings, openInner := assembleRings(inner)
+		shape.Polygons = append(shape.Polygons, buildPolygons(outerRings, innerRings)...)
+		// Незамкнутые участки (обрезанный запрос) остаются линиями, чтобы не терять координаты
+		shape.Lines = append(shape.Lines, openOuter...)
+		shape.Lines = append(shape.Lines, openInner...)
+	}
+	return shape
+}
+
+// shapeFromLine возвращает полигон для замкнутой линии и линию для незамкнутой
+func shapeFromLine(points []Point) *Shape {
+	switch {
+	case len(points) == 0:
+		return nil
+	case len(points) == 1:
+		return &Shape{Points: points}
+	case closed(points):
+		return &Shape{Polygons: []Polygon{{points}}}
+	default:
+		return &Shape{Lines: [][]Point{points}}
+	}
+}
+
+// closed проверяет, что линия замкнута и может быть кольцом
+func closed(points []Point) bool {
+	return len(points) >= 4 && points[0] == points[len(points)-1]
+}
+
+// assembleRings склеивает линии с общими концами в замкнутые кольца.
+// Возвращает кольца и линии, которые замкнуть не удалось
+func assembleRings(lines [][]Point) (rings, open [][]Point) {
+	var pending [][]Point
+	for _, line := range lines {
+		if closed(line) {
+			rings = append(rings, line)
+		} else if len(line) > 1 {
+			pending = append(pending, line)
+		}
+	}
+
+	for len(pending) > 0 {
+		current := append([]Point(nil), pending[0]...)
+		pending = pending[1:]
+		for !closed(current) {
+			joined := false
+			for i, line := range pending {
+				first, last := current[0], current[len(current)-1]
+				switch {
+				case line[0] == last:
+					current = append(current, line[1:]...)
+				case line[len(line)-1] == last:
+					current = append(current, reversed(line)[1:]...)
+				case line[len(line)-1] == first:
+					current = append(append([]Point(nil), line...), current[1:]...)
+				case line[0] == first:
+					current = append(reversed(line), current[1:]...)
+				default:
+					continue
+				}
+				pending = append(pending[:i], pending[i+1:]...)
+				joined = true
+				break
+			}
+			if !joined {
+				break
+			}
+		}
+		if closed(current) {
+			rings = append(rings, current)
+		} else {
+			open = append(open, current)
+		}
+	}
+	return rings, open
+}
+
+// buildPolygons распределяет внутренние кольца по внешним, в которые они попадают
+func buildPolygons(outer, inner [][]Point) []Polygon {
+	polygons := make([]Polygon, len(outer))
+	for i, ring := range outer {
+		polygons[i] = Polygon{ring}
+	}
+	for _, hole := range inner {
+		for i := range polygons {
+			if containsPoint(Polygon{polygons[i][0]}, hole[0]) {
+				polygons[i] = append(polygons[i], hole)
+				break
+			}
+		}
+	}
+	return polygons
+}
+
+func reversed(points []Point) []Point {
+	result := make([]Point, len(points))
+	for i, p := range points {
+		result[len(points)-1-i] = p
+	}
+	return result
+}
+
+func coordinatesToPoints(coordinates []dto.Coordinates) []Point {
+	if len(coordinates) == 0 {
+		return nil
+	}
+	points := make([]Point, len(coordinates))
+	for i, c := range coordinates {
+		points[i] = Point{Lat: c.Lat, Lon: c.Lon}
+	}
+	return points
+}
+
+func pointsToCoordinates(points []Point) []dto.Coordinates {
+	coordinates := make([]dto.Coordinates, len(points))
+	for i, p := range points {
+		coordinates[i] = dto.Coordinates{Lat: p.Lat, Lon: p.Lon}
+	}
+	return coordinates
+}
+
+// Embed возвращает копию объекта, в которую перенесены координаты узлов way и участников relation
+// из остального массива. Такой объект можно обработать отдельно, например в фоновом задании
+func (idx *Index) Embed(obj dto.OSMObject) dto.OSMObject {
+	switch obj.Type {
+	case "way":
+		if points := idx.wayPoints(&obj); len(points) > 0 {
+			obj.Geometry = pointsToCoordinates(points)
+		}
+	case "relation":
+		members := make([]dto.OSMMember, len(obj.Members))
+		copy(members, obj.Members)
+		for i, member := range members {
+			switch member.Type {
+			case "node":
+				if p, ok := idx.memberNode(member); ok {
+					members[i].Lat, members[i].Lon = p.Lat, p.Lon
+				}
+			case "way":
+				if points := idx.memberWayPoints(member); len(points) > 0 {
+					members[i].Geometry = pointsToCoordinates(points)
+				}
+			}
+		}
+		obj.Members = members
+		// Вложенные relation в задание не переносятся: если других участников нет, останется центр
+		if obj.Center == nil {
+			if shape := idx.Resolve(obj); shape != nil {
+				p := shape.RepresentativePoint()
+				obj.Center = &dto.Coordinates{Lat: p.Lat, Lon: p.Lon}
+			}
+		}
+	}
+	return obj
+}
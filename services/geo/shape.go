@@ -0,0 +1,388 @@
+// Package geo строит геометрию объектов OSM (точки, линии, полигоны) и вычисляет по ней
+// ограничивающий прямоугольник, центроид и точку для отображения места на карте.
+// Вычисления ведутся на плоскости в градусах — для отдельных зданий и кварталов этого достаточно
+package geo
+
+import (
+	"math"
+
+	"new/dto"
+)
+
+// Point — точка в координатах WGS 84
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// BBox — ограничивающий прямоугольник
+type BBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Polygon — полигон: первое кольцо внешнее, остальные — дыры. Кольца замкнуты (первая точка равна последней)
+type Polygon [][]Point
+
+// Shape — геометрия объекта: отдельные точки, линии и полигоны
+type Shape struct {
+	Points   []Point
+	Lines    [][]Point
+	Polygons []Polygon
+}
+
+// Типы геометрии GeoJSON
+const (
+	TypePoint              = "Point"
+	TypeMultiPoint         = "MultiPoint"
+	TypeLineString         = "LineString"
+	TypeMultiLineString    = "MultiLineString"
+	TypePolygon            = "Polygon"
+	TypeMultiPolygon       = "MultiPolygon"
+	TypeGeometryCollection = "GeometryCollection"
+)
+
+// Empty сообщает, что у фигуры нет ни одной точки
+func (s *Shape) Empty() bool {
+	return s == nil || len(s.Points) == 0 && len(s.Lines) == 0 && len(s.Polygons) == 0
+}
+
+// Type возвращает тип геометрии GeoJSON
+func (s *Shape) Type() string {
+	switch {
+	case len(s.Lines) == 0 && len(s.Polygons) == 0:
+		if len(s.Points) == 1 {
+			return TypePoint
+		}
+		return TypeMultiPoint
+	case len(s.Points) == 0 && len(s.Polygons) == 0:
+		if len(s.Lines) == 1 {
+			return TypeLineString
+		}
+		return TypeMultiLineString
+	case len(s.Points) == 0 && len(s.Lines) == 0:
+		if len(s.Polygons) == 1 {
+			return TypePolygon
+		}
+		return TypeMultiPolygon
+	default:
+		return TypeGeometryCollection
+	}
+}
+
+// GeoJSON возвращает геометрию в формате GeoJSON (координаты в порядке [lon, lat])
+func (s *Shape) GeoJSON() dto.Geometry {
+	kind := s.Type()
+	switch kind {
+	case TypePoint:
+		return dto.Geometry{Type: kind, Coordinates: position(s.Points[0])}
+	case TypeMultiPoint:
+		return dto.Geometry{Type: kind, Coordinates: positions(s.Points)}
+	case TypeLineString:
+		return dto.Geometry{Type: kind, Coordinates: positions(s.Lines[0])}
+	case TypeMultiLineString:
+		lines := make([][][2]float64, len(s.Lines))
+		for i, line := range s.Lines {
+			lines[i] = positions(line)
+		}
+		return dto.Geometry{Type: kind, Coordinates: lines}
+	case TypePolygon:
+		return dto.Geometry{Type: kind, Coordinates: polygonPositions(s.Polygons[0])}
+	case TypeMultiPolygon:
+		polygons := make([][][][2]float64, len(s.Polygons))
+		for i, polygon := range s.Polygons {
+			polygons[i] = polygonPositions(polygon)
+		}
+		return dto.Geometry{Type: kind, Coordinates: polygons}
+	}
+
+	collection := dto.Geometry{Type: kind}
+	for _, part := range []*Shape{{Points: s.Points}, {Lines: s.Lines}, {Polygons: s.Polygons}} {
+		if !part.Empty() {
+			collection.Geometries = append(collection.Geometries, part.GeoJSON())
+		}
+	}
+	return collection
+}
+
+func position(p Point) [2]float64 {
+	return [2]float64{p.Lon, p.Lat}
+}
+
+func positions(points []Point) [][2]float64 {
+	result := make([][2]float64, len(points))
+	for i, p := range points {
+		result[i] = position(p)
+	}
+	return result
+}
+
+func polygonPositions(polygon Polygon) [][][2]float64 {
+	rings := make([][][2]float64, len(polygon))
+	for i, ring := range polygon {
+		rings[i] = positions(ring)
+	}
+	return rings
+}
+
+// BBox возвращает ограничивающий прямоугольник всех точек фигуры
+func (s *Shape) BBox() BBox {
+	box := BBox{MinLat: math.Inf(1), MinLon: math.Inf(1), MaxLat: math.Inf(-1), MaxLon: math.Inf(-1)}
+	extend := func(points []Point) {
+		for _, p := range points {
+			box.MinLat = math.Min(box.MinLat, p.Lat)
+			box.MinLon = math.Min(box.MinLon, p.Lon)
+			box.MaxLat = math.Max(box.MaxLat, p.Lat)
+			box.MaxLon = math.Max(box.MaxLon, p.Lon)
+		}
+	}
+	extend(s.Points)
+	for _, line := range s.Lines {
+		extend(line)
+	}
+	for _, polygon := range s.Polygons {
+		if len(polygon) > 0 {
+			extend(polygon[0])
+		}
+	}
+	return box
+}
+
+// Centroid возвращает центр масс: для полигонов — по площади с учетом дыр,
+// для линий — по длине, для точек — среднее. Центроид может лежать вне фигуры
+func (s *Shape) Centroid() Point {
+	var sumLat, sumLon, total float64
+	for _, polygon := range s.Polygons {
+		c, area := polygonCentroid(polygon)
+		sumLat += c.Lat * area
+		sumLon += c.Lon * area
+		total += area
+	}
+	if total > 0 {
+		return Point{Lat: sumLat / total, Lon: sumLon / total}
+	}
+
+	// Вырожденные полигоны учитываются как линии
+	lines := s.Lines
+	for _, polygon := range s.Polygons {
+		lines = append(lines, polygon[0])
+	}
+	for _, line := range lines {
+		for i := 1; i < len(line); i++ {
+			length := distance(line[i-1], line[i])
+			sumLat += (line[i-1].Lat + line[i].Lat) / 2 * length
+			sumLon += (line[i-1].Lon + line[i].Lon) / 2 * length
+			total += length
+		}
+	}
+	if total > 0 {
+		return Point{Lat: sumLat / total, Lon: sumLon / total}
+	}
+
+	// Точки и линии нулевой длины
+	var count float64
+	add := func(points []Point) {
+		for _, p := range points {
+			sumLat += p.Lat
+			sumLon += p.Lon
+			count++
+		}
+	}
+	add(s.Points)
+	for _, line := range lines {
+		add(line)
+	}
+	if count == 0 {
+		return Point{}
+	}
+	return Point{Lat: sumLat / count, Lon: sumLon / count}
+}
+
+// RepresentativePoint возвращает точку, которая гарантированно лежит на фигуре:
+// внутри самого большого полигона, посередине самой длинной линии или ближайшую к центроиду точку
+func (s *Shape) RepresentativePoint() Point {
+	var largest Polygon
+	largestArea := 0.0
+	for _, polygon := range s.Polygons {
+		if _, area := polygonCentroid(polygon); area > largestArea {
+			largest, largestArea = polygon, area
+		}
+	}
+	if largest != nil {
+		return polygonInteriorPoint(largest)
+	}
+
+	var longest []Point
+	longestLength := -1.0
+	lines := s.Lines
+	for _, polygon := range s.Polygons {
+		lines = append(lines, polygon[0])
+	}
+	for _, line := range lines {
+		if length := lineLength(line); length > longestLength {
+			longest, longestLength = line, length
+		}
+	}
+	if longestLength > 0 {
+		return pointAlong(longest, longestLength/2)
+	}
+
+	centroid := s.Centroid()
+	candidates := s.Points
+	for _, line := range lines {
+		candidates = append(candidates, line...)
+	}
+	if len(candidates) == 0 {
+		return centroid
+	}
+	best := candidates[0]
+	for _, p := range candidates[1:] {
+		if distance(p, centroid) < distance(best, centroid) {
+			best = p
+		}
+	}
+	return best
+}
+
+// distance — расстояние на плоскости в градусах
+func distance(a, b Point) float64 {
+	return math.Hypot(a.Lat-b.Lat, a.Lon-b.Lon)
+}
+
+func lineLength(line []Point) float64 {
+	length := 0.0
+	for i := 1; i < len(line); i++ {
+		length += distance(line[i-1], line[i])
+	}
+	return length
+}
+
+// pointAlong возвращает точку на линии на расстоянии offset от начала
+func pointAlong(line []Point, offset float64) Point {
+	for i := 1; i < len(line); i++ {
+		segment := distance(line[i-1], line[i])
+		if segment > 0 && offset <= segment {
+			t := offset / segment
+			return Point{
+				Lat: line[i-1].Lat + (line[i].Lat-line[i-1].Lat)*t,
+				Lon: line[i-1].Lon + (line[i].Lon-line[i-1].Lon)*t,
+			}
+		}
+		offset -= segment
+	}
+	return line[len(line)-1]
+}
+
+// ringCentroid возвращает центроид кольца и его площадь со знаком (формула площади Гаусса)
+func ringCentroid(ring []Point) (Point, float64) {
+	var area, lat, lon float64
+	for i := 0; i+1 < len(ring); i++ {
+		a, b := ring[i], ring[i+1]
+		cross := a.Lon*b.Lat - b.Lon*a.Lat
+		area += cross
+		lon += (a.Lon + b.Lon) * cross
+		lat += (a.Lat + b.Lat) * cross
+	}
+	area /= 2
+	if area == 0 {
+		return Point{}, 0
+	}
+	return Point{Lat: lat / (6 * area), Lon: lon / (6 * area)}, area
+}
+
+// polygonCentroid возвращает центроид полигона с учетом дыр и его площадь
+func polygonCentroid(polygon Polygon) (Point, float64) {
+	var sumLat, sumLon, total float64
+	for i, ring := range polygon {
+		c, area := ringCentroid(ring)
+		area = math.Abs(area)
+		if i > 0 {
+			area = -area // Дыры вычитаются
+		}
+		sumLat += c.Lat * area
+		sumLon += c.Lon * area
+		total += area
+	}
+	if total <= 0 {
+		return Point{}, 0
+	}
+	return Point{Lat: sumLat / total, Lon: sumLon / total}, total
+}
+
+// containsPoint проверяет, что точка лежит внутри полигона и не попадает в дыры (метод трассировки луча)
+func containsPoint(polygon Polygon, p Point) bool {
+	inside := false
+	for _, ring := range polygon {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+				p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// polygonInteriorPoint возвращает центроид, если он внутри полигона, иначе середину
+// самого широкого отрезка горизонтали через полигон
+func polygonInteriorPoint(polygon Polygon) Point {
+	centroid, _ := polygonCentroid(polygon)
+	if containsPoint(polygon, centroid) {
+		return centroid
+	}
+
+	shape := Shape{Polygons: []Polygon{polygon}}
+	box := shape.BBox()
+	// Пробуем горизонтали через центроид и середину прямоугольника, затем сдвинутые от нее
+	for _, lat := range []float64{
+		centroid.Lat,
+		(box.MinLat + box.MaxLat) / 2,
+		box.MinLat + (box.MaxLat-box.MinLat)*0.25,
+		box.MinLat + (box.MaxLat-box.MinLat)*0.75,
+	} {
+		if p, ok := scanline(polygon, lat); ok {
+			return p
+		}
+	}
+	return polygon[0][0]
+}
+
+// scanline пересекает полигон горизонталью lat и возвращает середину самого широкого отрезка внутри
+func scanline(polygon Polygon, lat float64) (Point, bool) {
+	var xs []float64
+	for _, ring := range polygon {
+		for i := 0; i+1 < len(ring); i++ {
+			a, b := ring[i], ring[i+1]
+			if (a.Lat > lat) != (b.Lat > lat) {
+				xs = append(xs, a.Lon+(lat-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat))
+			}
+		}
+	}
+	if len(xs) < 2 {
+		return Point{}, false
+	}
+	sortFloats(xs)
+
+	best, width := 0.0, 0.0
+	for i := 0; i+1 < len(xs); i += 2 {
+		if w := xs[i+1] - xs[i]; w > width {
+			best, width = (xs[i]+xs[i+1])/2, w
+		}
+	}
+	if width == 0 {
+		return Point{}, false
+	}
+	return Point{Lat: lat, Lon: best}, true
+}
+
+// sortFloats сортирует небольшой срез вставками
+func sortFloats(xs []float64) {
+	for i := 1; i < len(xs); i++ {
+		for j := i; j > 0 && xs[j] < xs[j-1]; j-- {
+			xs[j], xs[j-1] = xs[j-1], xs[j]
+		}
+	}
+}
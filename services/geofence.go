@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"new/dto"
+	"new/services/geo"
+)
+
+// Параметры геозон WebSocket-сессии
+const (
+	DefaultGeofenceRadius = 30.0  // Расстояние до контура места, на котором начинается рассказ, м
+	MaxGeofenceRadius     = 500.0 // Метры
+	MaxLocationAccuracy   = 100.0 // Положения с худшей точностью не учитываются, м
+)
+
+// Повторы неудачного рассказа: пауза удваивается после каждой попытки, после последней место больше не рассказывается
+const (
+	DefaultNarrationRetryDelay = 30 * time.Second
+	MaxNarrationAttempts       = 3
+)
+
+// narrationFailure — неудачные попытки рассказать место
+type narrationFailure struct {
+	attempts int
+	retryAt  time.Time
+}
+
+// fencedPlace — место сессии с геометрией для проверки геозоны
+type fencedPlace struct {
+	Key   string // Идентификатор объекта OSM, например way/123
+	Input placeInput
+}
+
+// Geofence хранит места, известные WebSocket-сессии, и отмечает рассказанные,
+// чтобы каждое место озвучивалось не больше одного раза за сессию. Места, описание которых еще готовится,
+// считаются занятыми и тоже не выбираются, а после неудачного рассказа место выбирается снова только после паузы.
+// Безопасен для параллельных запросов сессии
+type Geofence struct {
+	Radius     float64
+	RetryDelay time.Duration // Пауза перед повтором неудачного рассказа, по умолчанию 30 секунд
+	mu         sync.Mutex
+	places     map[string]fencedPlace
+	narrated   map[string]bool
+	busy       map[string]int // Число незавершенных описаний места
+	failures   map[string]narrationFailure
+}
+
+// NewGeofence создает геозоны с радиусом radius метров; 0 — радиус по умолчанию
+func NewGeofence(radius float64) *Geofence {
+	if radius <= 0 {
+		radius = DefaultGeofenceRadius
+	}
+	return &Geofence{
+		Radius:   math.Min(radius, MaxGeofenceRadius),
+		places:   make(map[string]fencedPlace),
+		narrated: make(map[string]bool),
+		busy:     make(map[string]int),
+		failures: make(map[string]narrationFailure),
+	}
+}
+
+// Add добавляет в сессию объекты с координатами, названия — на языке language.
+// Повторно переданный объект заменяет прежний, но не рассказывается заново
+func (g *Geofence) Add(osmObjects []dto.OSMObject, language string) dto.PlacesRegistered {
+	registered, _ := g.add(osmObjects, language, false)
+	return registered
+}
+
+// AddInProgress добавляет объекты как Add и отмечает еще не рассказанные места занятыми, пока их описывает
+// запрос process. Возвращает ключи занятых мест; каждое нужно освободить через Finish
+func (g *Geofence) AddInProgress(osmObjects []dto.OSMObject, language string) (dto.PlacesRegistered, []string) {
+	return g.add(osmObjects, language, true)
+}
+
+// add регистрирует места и при claim занимает еще не рассказанные
+func (g *Geofence) add(osmObjects []dto.OSMObject, language string, claim bool) (dto.PlacesRegistered, []string) {
+	inputs, skipped := preparePlaces(osmObjects, language)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	registered := dto.PlacesRegistered{Skipped: len(skipped)}
+	var claimed []string
+	seen := make(map[string]bool)
+	for _, in := range inputs {
+		if in.Shape == nil {
+			registered.Skipped++
+			continue
+		}
+		key := placeIdentity(in.Data)
+		g.places[key] = fencedPlace{Key: key, Input: in}
+		registered.Registered++
+		if claim && !g.narrated[key] && !seen[key] {
+			seen[key] = true
+			g.busy[key]++
+			claimed = append(claimed, key)
+		}
+	}
+	registered.Total = len(g.places)
+	return registered, claimed
+}
+
+// Finish освобождает место, занятое AddInProgress или выбором для рассказа. При narrated место
+// отмечается рассказанным, иначе снова может быть рассказано при приближении
+func (g *Geofence) Finish(key string, narrated bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if narrated {
+		g.narrated[key] = true
+	}
+	if g.busy[key] > 1 {
+		g.busy[key]--
+	} else {
+		delete(g.busy, key)
+	}
+}
+
+// MarkNarrated отмечает место как уже рассказанное
+func (g *Geofence) MarkNarrated(osmType, osmID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.narrated[osmType+"/"+osmID] = true
+}
+
+// Narrated сообщает, рассказано ли место в этой сессии
+func (g *Geofence) Narrated(osmType, osmID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.narrated[osmType+"/"+osmID]
+}
+
+// fail освобождает место после неудачного рассказа и откладывает следующую попытку.
+// После MaxNarrationAttempts попыток место в этой сессии больше не рассказывается
+func (g *Geofence) fail(key string) {
+	delay := g.RetryDelay
+	if delay <= 0 {
+		delay = DefaultNarrationRetryDelay
+	}
+	g.mu.Lock()
+	failure := g.failures[key]
+	failure.attempts++
+	failure.retryAt = time.Now().Add(delay << (failure.attempts - 1))
+	g.failures[key] = failure
+	g.mu.Unlock()
+	g.Finish(key, false)
+}
+
+// Next возвращает ближайшее нерассказанное и не занятое место, в геозону которого попадает точка,
+// и расстояние до него. Место сразу отмечается рассказанным
+func (g *Geofence) Next(position geo.Point) (key string, distance float64, ok bool) {
+	_, key, distance, ok = g.next(position)
+	if ok {
+		g.Finish(key, true)
+	}
+	return key, distance, ok
+}
+
+// next выбирает место как Next и занимает его до вызова Finish
+func (g *Geofence) next(position geo.Point) (in placeInput, key string, distance float64, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	distance = math.Inf(1)
+	for k, place := range g.places {
+		if g.narrated[k] || g.busy[k] > 0 {
+			continue
+		}
+		if failure, failed := g.failures[k]; failed && (failure.attempts >= MaxNarrationAttempts || now.Before(failure.retryAt)) {
+			continue
+		}
+		d := place.Input.Shape.DistanceTo(position)
+		// При равном расстоянии порядок не зависит от обхода map
+		if d <= g.Radius && (d < distance || d == distance && k < key) {
+			key, distance, ok = k, d, true
+		}
+	}
+	if ok {
+		g.busy[key]++
+		in = g.places[key].Input
+	}
+	return in, key, distance, ok
+}
+
+// NarrateNearby проверяет положение пользователя и, если он вошел в геозону еще не рассказанного места,
+// возвращает описание и аудио этого места. Место считается рассказанным только после успешного описания;
+// неудачный рассказ клиенту не отправляется, а место повторяется после паузы. Положения с плохой точностью пропускаются
+func (s *PlaceService) NarrateNearby(ctx context.Context, userID uint, opts DescribeOptions, fence *Geofence, update dto.LocationUpdate) (*dto.GeofenceEvent, bool) {
+	if update.Accuracy > MaxLocationAccuracy {
+		return nil, false
+	}
+	in, key, distance, ok := fence.next(geo.Point{Lat: update.Lat, Lon: update.Lon})
+	if !ok {
+		return nil, false
+	}
+
+	opts.apply([]placeInput{in})
+	interests, err := s.loadInterests(ctx, userID)
+	if err != nil {
+		fmt.Printf("Ошибка при загрузке предпочтений: %v\n", err)
+		interests = &userInterests{}
+	}
+	place := s.processPlace(ctx, userID, interests, in)
+	switch place.Status {
+	case dto.PlaceStatusSuccess:
+		fence.Finish(key, true)
+	case dto.PlaceStatusCancelled:
+		fence.Finish(key, false)
+		return nil, false
+	default:
+		message := string(place.Status)
+		if place.Error != nil {
+			message = place.Error.Message
+		}
+		fmt.Printf("Не удалось рассказать место %s: %s\n", key, message)
+		fence.fail(key)
+		return nil, false
+	}
+	return &dto.GeofenceEvent{
+		Event:    "enter",
+		Distance: math.Round(distance),
+		Place:    place,
+	}, true
+}
@@ -1,13 +1,11 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +13,10 @@ import (
 	"new/database"
 	"new/dto"
 	"new/models"
+	"new/services/blobstore"
+	"new/services/geo"
+	"new/services/llm"
+	"new/services/tts"
 
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
@@ -22,22 +24,48 @@ import (
 
 // PlaceService представляет сервис для работы с местами
 type PlaceService struct {
-	DB *gorm.DB
+	DB    *gorm.DB
+	LLM   *llm.Registry      // Реестр LLM-провайдеров
+	TTS   *tts.Registry      // Реестр TTS-провайдеров
+	Audio *AudioCacheService // Кеш сгенерированного аудио, без него ссылки на аудио не формируются
+	// Шаблоны промптов; без них провайдеры используют встроенные инструкции
+	Prompts *PromptTemplateService
+	// Обработанные места с координатами для поиска по соседству; без него места не сохраняются
+	OSMPlaces *OSMPlaceService
+
+	flight Coalescer // Объединяет одновременные генерации одного описания
 }
 
-// NewPlaceService создает новый экземпляр PlaceService
+// NewPlaceService создает новый экземпляр PlaceService с провайдерами из переменных окружения
 func NewPlaceService(db *gorm.DB) *PlaceService {
-	return &PlaceService{DB: db}
+	llmRegistry, err := llm.NewRegistryFromEnv()
+	if err != nil {
+		log.Printf("Ошибка настройки LLM-провайдеров: %v", err)
+	}
+	ttsRegistry, err := tts.NewRegistryFromEnv()
+	if err != nil {
+		log.Printf("Ошибка настройки TTS-провайдеров: %v", err)
+	}
+	service := &PlaceService{DB: db, LLM: llmRegistry, TTS: ttsRegistry}
+	if db != nil {
+		service.Prompts = &PromptTemplateService{DB: db}
+	}
+	if store, err := blobstore.NewFromEnv(); err != nil {
+		log.Printf("Ошибка настройки хранилища аудио: %v", err)
+	} else if ttsRegistry != nil {
+		service.Audio = NewAudioCacheService(db, store, ttsRegistry)
+	}
+	return service
 }
 
 // AddPlace добавляет новое место в историю пользователя
-func (s *PlaceService) AddPlace(userID uint, input dto.AddPlaceDTO) (*models.Place, error) {
+func (s *PlaceService) AddPlace(ctx context.Context, userID uint, input dto.AddPlaceDTO) (*models.Place, error) {
 	place := &models.Place{
 		UserID:    userID,
 		PlaceName: input.PlaceName,
 	}
 
-	if err := s.DB.Create(place).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Create(place).Error; err != nil {
 		return nil, err
 	}
 
@@ -45,354 +73,337 @@ func (s *PlaceService) AddPlace(userID uint, input dto.AddPlaceDTO) (*models.Pla
 }
 
 // GetUserHistory возвращает историю запросов пользователя из PostgreSQL
-func (s *PlaceService) GetUserHistory(userID uint) ([]models.Place, error) {
+func (s *PlaceService) GetUserHistory(ctx context.Context, userID uint) ([]models.Place, error) {
 	var places []models.Place
-	if err := s.DB.Where("user_id = ?", userID).Find(&places).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&places).Error; err != nil {
 		return nil, err
 	}
 	return places, nil
 }
 
-// GetCachedResponse возвращает закешированный ответ из Redis
-func (s *PlaceService) GetCachedResponse(userID uint, placeName string) (string, error) {
-	ctx := context.Background()
-
-	cacheKey := fmt.Sprintf("llm:user:%d:place:%s", userID, placeName)
-	cachedResponse, err := database.RedisClient.Get(ctx, cacheKey).Result()
-	if err == redis.Nil {
-		fmt.Printf("Ответ не найден в кеше для пользователя %d и места: %s\n", userID, placeName)
-		return "", fmt.Errorf("ответ не найден в кеше для места: %s", placeName)
-	} else if err != nil {
-		fmt.Printf("Ошибка при получении данных из Redis: %v\n", err)
-		return "", fmt.Errorf("ошибка при обращении к Redis: %v", err)
+// GetCachedResponse возвращает закешированный ответ из Redis: сначала персональный вариант
+// для предпочтений пользователя, затем общее описание места
+func (s *PlaceService) GetCachedResponse(ctx context.Context, userID uint, input dto.CachedResponseDTO) (string, error) {
+	place := map[string]string{
+		"place_name": input.PlaceName,
+		"language":   s.requestLanguage(ctx, userID, input.Language),
 	}
-
-	fmt.Printf("Ответ найден в кеше для пользователя %d и места: %s\n", userID, placeName)
-	return cachedResponse, nil
-}
-
-// SendBatchToLLM отправляет данные одного места в LLM в формате одиночного объекта JSON
-func (s *PlaceService) SendBatchToLLM(places []map[string]string) (string, error) {
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 9999*time.Second)
-	defer cancel()
-
-	if len(places) == 0 {
-		return "", fmt.Errorf("массив мест пуст")
-	}
-
-	// Берем только первое место, так как ожидается один объект
-	place := places[0]
-
-	// Формируем JSON вручную с помощью strings.Builder
-	var jsonBuilder strings.Builder
-	jsonBuilder.WriteString("{") // Начинаем с объекта
-
-	// Поля для места
-	fields := []string{
-		"addr:city",
-		"addr:street",
-		"addr:housenumber",
-		"name",
-		"amenity",
-		"tourism",
-		"highway",
-		"leisure",
-		"building",
-		"inscription",
-		"description",
-	}
-
-	firstField := true // Флаг для отслеживания первого добавленного поля
-	for _, field := range fields {
-		if value, exists := place[field]; exists && value != "" {
-			if !firstField {
-				jsonBuilder.WriteString(",")
-			}
-			escapedValue := strings.ReplaceAll(value, `"`, `\"`)
-			jsonBuilder.WriteString(fmt.Sprintf(`"%s":"%s"`, field, escapedValue))
-			firstField = false // После добавления первого поля сбрасываем флаг
-		}
+	if input.OSMID != 0 && input.OSMType != "" {
+		place["type"] = input.OSMType
+		place["osm_id"] = fmt.Sprintf("%d", input.OSMID)
 	}
 
-	jsonBuilder.WriteString("}") // Закрываем объект
-	jsonBody := jsonBuilder.String()
-
-	req, err := http.NewRequestWithContext(ctxWithTimeout, "POST", os.Getenv("HOST_LLM"), bytes.NewBufferString(jsonBody))
+	key, err := s.descriptionKey(input.Provider, place)
 	if err != nil {
-		return "", fmt.Errorf("ошибка при создании запроса: %v", err)
+		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	cacheKeys := []string{key.String()}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	interests, err := s.loadInterests(ctx, userID)
 	if err != nil {
-		return "", fmt.Errorf("ошибка при отправке запроса: %v", err)
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
+	if interests.Hash != "" {
+		cacheKeys = append([]string{key.UserKey(userID, interests.Hash)}, cacheKeys...)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при чтении тела ответа: %v", err)
+	for _, cacheKey := range cacheKeys {
+		cachedResponse, err := database.RedisClient.Get(ctx, cacheKey).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			fmt.Printf("Ошибка при получении данных из Redis: %v\n", err)
+			return "", fmt.Errorf("ошибка при обращении к Redis: %v", err)
+		}
+
+		fmt.Printf("Ответ найден в кеше для пользователя %d и места: %s\n", userID, input.PlaceName)
+		return cachedResponse, nil
 	}
 
-	var response struct {
-		Text string `json:"message"`
+	fmt.Printf("Ответ не найден в кеше для пользователя %d и места: %s\n", userID, input.PlaceName)
+	return "", fmt.Errorf("ответ не найден в кеше для места: %s", input.PlaceName)
+}
+
+// describePlace запрашивает описание места у LLM-провайдера с указанным именем.
+// Промпт строится по активному шаблону, если он ещё не подставлен в данные места
+func (s *PlaceService) describePlace(ctx context.Context, providerName string, place map[string]string) (string, error) {
+	if s.LLM == nil {
+		return "", fmt.Errorf("LLM-провайдеры не настроены")
 	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("ошибка парсинга JSON: %v", err)
+	provider, err := s.LLM.Get(providerName)
+	if err != nil {
+		return "", err
 	}
-
-	if err := checkResponseError(response.Text, "LLM"); err != nil {
+	place, err = s.preparePrompt(place)
+	if err != nil {
 		return "", err
 	}
-
-	return response.Text, nil
+	return provider.Describe(ctx, place)
 }
 
-// AudioGenerate отправляет текст в формате JSON и получает аудио в кодировке UTF-8
-func (s *PlaceService) AudioGenerate(text string) ([]byte, error) {
-	client := &http.Client{}
-	reqBody := map[string]string{"message": text}
-	jsonBody, err := json.Marshal(reqBody)
+// AudioGenerate озвучивает текст TTS-провайдером по умолчанию с параметрами по умолчанию
+func (s *PlaceService) AudioGenerate(ctx context.Context, text string) ([]byte, error) {
+	audio, err := s.SynthesizeAudio(ctx, "", text, tts.Options{})
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+		return nil, err
 	}
+	return audio.Data, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 9999*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "POST", os.Getenv("HOST_TTS"), bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при создании запроса: %v", err)
+// SynthesizeAudio озвучивает текст выбранным TTS-провайдером с указанными голосом, языком, скоростью и форматом
+func (s *PlaceService) SynthesizeAudio(ctx context.Context, providerName, text string, opts tts.Options) (*tts.Audio, error) {
+	if s.TTS == nil {
+		return nil, fmt.Errorf("TTS-провайдеры не настроены")
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return s.TTS.Synthesize(ctx, providerName, text, opts)
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при отправке запроса: %v", err)
+// CachedAudio озвучивает текст с указанными параметрами через кеш аудио и возвращает его метаданные.
+// Если кеш не настроен, текст всё равно озвучивается, но ссылки на аудио нет и возвращается nil
+func (s *PlaceService) CachedAudio(ctx context.Context, providerName, text string, opts tts.Options) (*models.AudioAsset, error) {
+	if s.Audio == nil {
+		_, err := s.SynthesizeAudio(ctx, providerName, text, opts)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
+	if err := opts.Validate(); err != nil {
+		return nil, err
 	}
+	return s.Audio.GetOrCreate(ctx, providerName, text, opts)
+}
 
-	audioData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при чтении тела ответа: %v", err)
-	}
+// generateAudio озвучивает текст через кеш аудио на языке language; голос подбирается по языку
+func (s *PlaceService) generateAudio(ctx context.Context, text, language string) (*models.AudioAsset, error) {
+	return s.CachedAudio(ctx, "", text, tts.Options{Language: language})
+}
 
-	var response struct {
-		Text string `json:"message"`
+// audioRef формирует ссылку на аудио для результата
+func (s *PlaceService) audioRef(asset *models.AudioAsset) *dto.AudioRef {
+	if asset == nil || s.Audio == nil {
+		return nil
 	}
-	if err := json.Unmarshal(audioData, &response); err == nil {
-		if err := checkResponseError(response.Text, "TTS"); err != nil {
-			return nil, err
+	return &dto.AudioRef{
+		ID:          asset.Hash,
+		URL:         s.Audio.URL(asset),
+		ContentType: asset.ContentType,
+	}
+}
+
+// newPlaceResult создает результат обработки места по данным из buildPlaceData
+func newPlaceResult(place map[string]string) dto.PlaceResult {
+	result := dto.PlaceResult{
+		PlaceID:   place["osm_id"],
+		OSMType:   place["type"],
+		PlaceName: place["place_name"],
+		Status:    dto.PlaceStatusPending,
+		Address:   buildAddress(place),
+		City:      place["addr:city"],
+	}
+	lat, latErr := strconv.ParseFloat(place["lat"], 64)
+	lon, lonErr := strconv.ParseFloat(place["lon"], 64)
+	if latErr == nil && lonErr == nil {
+		result.Coordinates = &dto.Coordinates{Lat: lat, Lon: lon}
+	}
+	return result
+}
+
+// errCacheUnavailable означает, что кеш описаний в Redis недоступен
+var errCacheUnavailable = errors.New("кеш недоступен")
+
+// describeCached возвращает описание места из кеша или генерирует и кеширует его.
+// Одновременные запросы одного и того же ключа ждут одну генерацию.
+// cached сообщает, что описание не было сгенерировано этим вызовом. Генерация, которую перестали ждать, отменяется.
+// Без Redis описания не кешируются
+func (s *PlaceService) describeCached(ctx context.Context, cacheKey string, expiration time.Duration, providerName string, place map[string]string) (text string, cached bool, err error) {
+	lookup := func(ctx context.Context) (string, bool, error) {
+		if database.RedisClient == nil {
+			return "", false, nil
 		}
+		cachedResponse, err := database.RedisClient.Get(ctx, cacheKey).Result()
+		if err == redis.Nil {
+			return "", false, nil
+		} else if err != nil {
+			fmt.Printf("Ошибка при получении из Redis: %v\n", err)
+			return "", false, fmt.Errorf("%w: %v", errCacheUnavailable, err)
+		}
+		return cachedResponse, true, nil
 	}
 
-	if len(audioData) == 0 {
-		return nil, fmt.Errorf("пустое тело ответа")
+	generate := func(ctx context.Context) (string, error) {
+		text, err := s.describePlace(ctx, providerName, place)
+		if err != nil {
+			return "", err
+		}
+		if database.RedisClient == nil {
+			return text, nil
+		}
+		if err := database.RedisClient.Set(ctx, cacheKey, text, expiration).Err(); err != nil {
+			fmt.Printf("Ошибка при сохранении в Redis: %v\n", err)
+		}
+		return text, nil
 	}
 
-	return audioData, nil
+	return s.flight.Do(ctx, cacheKey, lookup, generate)
 }
 
-// checkResponseError проверяет текст ответа на наличие ошибок
-func checkResponseError(responseText string, source string) error {
-	if strings.Contains(responseText, "ОШИБКА") {
-		return fmt.Errorf("ошибка от %s: %s", source, responseText)
+// processPlace описывает и озвучивает одно место для пользователя, используя кеш.
+// Интересы пользователя передаются в промпт, совпавшие с местом — в результат
+func (s *PlaceService) processPlace(ctx context.Context, userID uint, interests *userInterests, in placeInput) dto.PlaceResult {
+	placeResult := newInputResult(in)
+	placeResult.Interests = interests.match(inputTags(in))
+
+	// Промпт строится один раз: его версия попадает в ключ кеша
+	place, err := s.preparePrompt(interests.withInterests(in.Data))
+	if err != nil {
+		placeResult.Fail(dto.PlaceStatusLLMError, err.Error())
+		return placeResult
 	}
-	return nil
+
+	cacheKey, expiration, err := s.descriptionCacheKey(userID, interests.Hash, "", place)
+	if err != nil {
+		placeResult.Fail(dto.PlaceStatusLLMError, err.Error())
+		return placeResult
+	}
+
+	// Берём описание из кеша или отправляем место в LLM
+	text, cached, err := s.describeCached(ctx, cacheKey, expiration, "", place)
+	if ctx.Err() != nil {
+		placeResult.Fail(dto.PlaceStatusCancelled, ctx.Err().Error())
+		return placeResult
+	} else if errors.Is(err, errCacheUnavailable) {
+		placeResult.Fail(dto.PlaceStatusCacheError, err.Error())
+		return placeResult
+	} else if err != nil {
+		placeResult.Fail(dto.PlaceStatusLLMError, err.Error())
+		return placeResult
+	}
+	placeResult.Response = text
+
+	// Аудио берётся из кеша аудио или генерируется для этого конкретного ответа голосом языка описания
+	asset, ttsErr := s.generateAudio(ctx, text, place["language"])
+	if ctx.Err() != nil {
+		placeResult.Fail(dto.PlaceStatusCancelled, ctx.Err().Error())
+		return placeResult
+	} else if ttsErr != nil {
+		placeResult.Fail(dto.PlaceStatusTTSError, ttsErr.Error())
+		return placeResult
+	}
+	placeResult.Audio = s.audioRef(asset)
+
+	// Добавляем в историю только новые описания; без базы история не ведется
+	if !cached && s.DB != nil {
+		if _, err := s.AddPlace(ctx, userID, dto.AddPlaceDTO{PlaceName: placeResult.PlaceName}); err != nil {
+			fmt.Printf("Ошибка при добавлении в историю: %v\n", err)
+			placeResult.Fail(dto.PlaceStatusFailedToAdd, err.Error())
+			return placeResult
+		}
+	}
+
+	// Успешный результат
+	placeResult.Status = dto.PlaceStatusSuccess
+	s.rememberPlace(ctx, "", in, place, placeResult, interests.Hash == "")
+	return placeResult
 }
 
-// ProcessPlaces обрабатывает массив мест последовательно, возвращая массив с полной информацией о каждом месте
-func (s *PlaceService) ProcessPlaces(userID uint, places []map[string]string) ([]map[string]interface{}, error) {
-	var result []map[string]interface{}
-	ctx := context.Background()
+// ProcessPlaces обрабатывает массив мест последовательно, возвращая массив с полной информацией о каждом месте.
+// Места, подходящие под интересы пользователя, обрабатываются и возвращаются первыми
+func (s *PlaceService) ProcessPlaces(ctx context.Context, userID uint, places []map[string]string) ([]dto.PlaceResult, error) {
+	var result []dto.PlaceResult
 
 	if len(places) == 0 {
 		return result, nil
 	}
 
-	// Обрабатываем каждое место по очереди
-	for _, place := range places {
-		placeName := place["place_name"]
-		cacheKey := fmt.Sprintf("llm:user:%d:place:%s", userID, placeName)
-
-		// Инициализируем объект для текущего места
-		placeResult := map[string]interface{}{
-			"place_name": placeName,
-			"details":    place, // Сохраняем исходные данные места
-			"status":     "pending",
-		}
+	// Интересы пользователя влияют на промпт, порядок мест и ключ кеша
+	interests, err := s.loadInterests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	inputs := placeInputsFromData(places)
+	DescribeOptions{Language: s.userLanguage(ctx, userID)}.apply(inputs)
+	inputs, skipped := interests.rankInputs(inputs)
 
-		// Проверяем кеш
-		cachedResponse, err := database.RedisClient.Get(ctx, cacheKey).Result()
-		if err == redis.Nil {
-			// Если нет в кеше, отправляем в LLM индивидуально
-			placeToProcess := []map[string]string{place} // Массив из одного объекта
-			text, llmErr := s.SendBatchToLLM(placeToProcess)
-			if llmErr != nil {
-				placeResult["response"] = fmt.Sprintf("Ошибка LLM: %v", llmErr)
-				placeResult["audio"] = nil
-				placeResult["status"] = "llm_error"
-				result = append(result, placeResult)
-				continue
-			}
-
-			// Генерируем аудио для этого конкретного ответа
-			audioData, ttsErr := s.AudioGenerate(text)
-			if ttsErr != nil {
-				placeResult["response"] = text
-				placeResult["audio"] = fmt.Sprintf("Ошибка TTS: %v", ttsErr)
-				placeResult["status"] = "tts_error"
-				result = append(result, placeResult)
-				continue
-			}
-
-			// Сохраняем в кеш
-			expiration := 24 * time.Hour
-			if err := database.RedisClient.Set(ctx, cacheKey, text, expiration).Err(); err != nil {
-				fmt.Printf("Ошибка при сохранении в Redis: %v\n", err)
-			}
-
-			// Добавляем в историю
-			_, err = s.AddPlace(userID, dto.AddPlaceDTO{PlaceName: placeName})
-			if err != nil {
-				fmt.Printf("Ошибка при добавлении в историю: %v\n", err)
-				placeResult["response"] = text
-				placeResult["audio"] = audioData
-				placeResult["status"] = "failed_to_add"
-				result = append(result, placeResult)
-				continue
-			}
-
-			// Успешный результат
-			placeResult["response"] = text
-			placeResult["audio"] = audioData
-			placeResult["status"] = "success"
-		} else if err != nil {
-			fmt.Printf("Ошибка при получении из Redis: %v\n", err)
-			placeResult["response"] = fmt.Sprintf("Ошибка Redis: %v", err)
-			placeResult["audio"] = nil
-			placeResult["status"] = "cache_error"
-		} else {
-			// Если найдено в кеше
-			placeResult["response"] = cachedResponse
-			placeResult["audio"] = nil // Аудио не кэшируется
-			placeResult["status"] = "success"
+	// Обрабатываем каждое место по очереди
+	for _, in := range inputs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-
-		result = append(result, placeResult)
+		result = append(result, s.processPlace(ctx, userID, interests, in))
 	}
 
-	return result, nil
+	return append(result, skipped...), nil
+}
+
+// ProcessPlacesGoroutines обрабатывает массив мест параллельно с оптимизацией.
+// Одинаковые места из параллельных запросов генерируются один раз.
+// Места без языка описываются на языке из профиля пользователя. После отмены ctx новые места не обрабатываются
+func (s *PlaceService) ProcessPlacesGoroutines(ctx context.Context, userID uint, places []map[string]string, resultChan chan<- dto.PlaceResult) {
+	inputs := placeInputsFromData(places)
+	DescribeOptions{Language: s.userLanguage(ctx, userID)}.apply(inputs)
+	s.processInputsGoroutines(ctx, userID, inputs, resultChan)
 }
 
-// ProcessPlacesGoroutines обрабатывает массив мест параллельно с оптимизацией
-func (s *PlaceService) ProcessPlacesGoroutines(userID uint, places []map[string]string, resultChan chan<- map[string]interface{}) {
-	ctx := context.Background()
+// processInputsGoroutines обрабатывает места параллельно и отправляет результаты в канал по мере готовности.
+// Места ранжируются по интересам пользователя; отброшенные фильтром отправляются со статусом skipped.
+// После отмены ctx новые места не запускаются, а результаты больше не отправляются: метод возвращается,
+// даже если канал никто не читает
+func (s *PlaceService) processInputsGoroutines(ctx context.Context, userID uint, inputs []placeInput, resultChan chan<- dto.PlaceResult) {
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 1) // Ограничиваем до 5 параллельных запросов
 
-	if len(places) == 0 {
+	if len(inputs) == 0 {
 		return
 	}
 
-	// Обрабатываем каждое место
-	for _, place := range places {
-		placeName := place["place_name"]
-		cacheKey := fmt.Sprintf("llm:user:%d:place:%s", userID, placeName)
-
-		// Инициализируем объект результата
-		placeResult := map[string]interface{}{
-			"place_name": placeName,
-			"details":    place,
-			"status":     "pending",
+	// Интересы пользователя влияют на промпт, порядок мест и ключ кеша
+	interests, err := s.loadInterests(ctx, userID)
+	if err != nil {
+		fmt.Printf("Ошибка при загрузке предпочтений: %v\n", err)
+		interests = &userInterests{}
+	}
+	inputs, skipped := interests.rankInputs(inputs)
+	for _, result := range skipped {
+		if !sendResult(ctx, resultChan, result) {
+			return
 		}
+	}
 
-		// Проверяем кеш заранее
-		if cachedResponse, err := database.RedisClient.Get(ctx, cacheKey).Result(); err == nil {
-			placeResult["response"] = cachedResponse
-			audioData, _ := s.AudioGenerate(cachedResponse)
-			placeResult["audio"] = audioData
-			placeResult["status"] = "success"
-			resultChan <- placeResult
-			continue
+	// Обрабатываем каждое место в горутине
+	defer wg.Wait()
+	for _, in := range inputs {
+		select {
+		case semaphore <- struct{}{}: // Захватываем слот в семафоре
+		case <-ctx.Done():
+			return
 		}
-
-		// Если нет в кеше, обрабатываем в горутине
 		wg.Add(1)
-		semaphore <- struct{}{} // Захватываем слот в семафоре
-		go func(place map[string]string) {
+		go func(in placeInput) {
 			defer wg.Done()
 			defer func() { <-semaphore }() // Освобождаем слот
 
-			placeName := place["place_name"]
-			cacheKey := fmt.Sprintf("llm:user:%d:place:%s", userID, placeName)
-			placeResult := map[string]interface{}{
-				"place_name": placeName,
-				"details":    place,
-				"status":     "pending",
-			}
-
-			placeToProcess := []map[string]string{place}
-			text, llmErr := s.SendBatchToLLM(placeToProcess)
-			if llmErr != nil {
-				placeResult["response"] = fmt.Sprintf("Ошибка LLM: %v", llmErr)
-				placeResult["audio"] = nil
-				placeResult["status"] = "llm_error"
-				resultChan <- placeResult
-				return
-			}
-
-			audioData, ttsErr := s.AudioGenerate(text)
-			if ttsErr != nil {
-				placeResult["response"] = text
-				placeResult["audio"] = fmt.Sprintf("Ошибка TTS: %v", ttsErr)
-				placeResult["status"] = "tts_error"
-				resultChan <- placeResult
-				return
-			}
-
-			expiration := 24 * time.Hour
-			if err := database.RedisClient.Set(ctx, cacheKey, text, expiration).Err(); err != nil {
-				fmt.Printf("Ошибка при сохранении в Redis: %v\n", err)
-			}
-
-			_, err := s.AddPlace(userID, dto.AddPlaceDTO{PlaceName: placeName})
-			if err != nil {
-				fmt.Printf("Ошибка при добавлении в историю: %v\n", err)
-				placeResult["response"] = text
-				placeResult["audio"] = audioData
-				placeResult["status"] = "failed_to_add"
-				resultChan <- placeResult
-				return
-			}
-
-			placeResult["response"] = text
-			placeResult["audio"] = audioData
-			placeResult["status"] = "success"
-			resultChan <- placeResult
-		}(place)
-	}
-
-	wg.Wait()
+			sendResult(ctx, resultChan, s.processPlace(ctx, userID, interests, in))
+		}(in)
+	}
+}
+
+// sendResult отправляет результат в канал. Возвращает false, если ctx отменен раньше, чем результат прочитали
+func sendResult(ctx context.Context, resultChan chan<- dto.PlaceResult, result dto.PlaceResult) bool {
+	select {
+	case resultChan <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // findResultByPlaceName проверяет, есть ли результат для placeName
-func findResultByPlaceName(results []map[string]interface{}, placeName string) (map[string]interface{}, bool) {
+func findResultByPlaceName(results []dto.PlaceResult, placeName string) (dto.PlaceResult, bool) {
 	for _, result := range results {
-		if result["place_name"] == placeName {
+		if result.PlaceName == placeName {
 			return result, true
 		}
 	}
-	return nil, false
+	return dto.PlaceResult{}, false
 }
 
 // ProcessJSON обрабатывает JSON-файл и отправляет места на обработку
@@ -432,9 +443,9 @@ func findResultByPlaceName(results []map[string]interface{}, placeName string) (
 // 	return results, nil
 // }
 
-// buildPlaceName формирует название места
-func buildPlaceName(tags map[string]string, objType string, objID int64) string {
-	if name, exists := tags["name"]; exists && name != "" {
+// buildPlaceName формирует название места на языке language (name:en, name:de), иначе берёт name
+func buildPlaceName(tags map[string]string, language, objType string, objID int64) string {
+	if name := localizedTag(tags, "name", language); name != "" {
 		return name
 	}
 	return fmt.Sprintf("%s %d", objType, objID)
@@ -448,16 +459,25 @@ func isPlaceEmpty(tags map[string]string) bool {
 		tags["name"] == ""
 }
 
-// buildPlaceData формирует данные для ProcessPlaces
-func buildPlaceData(obj dto.OSMObject) map[string]string {
+// buildPlaceData формирует данные для ProcessPlaces: теги объекта и служебные поля.
+// Теги, совпадающие со служебными полями, не копируются; какие из тегов уйдут в LLM, решает белый список провайдера.
+// Название (name) заменяется переводом на язык language, если он есть в тегах name:xx
+func buildPlaceData(obj dto.OSMObject, language string) map[string]string {
 	tags := obj.Tags
-	placeData := map[string]string{
-		"type":             obj.Type,
-		"place_name":       buildPlaceName(tags, obj.Type, obj.ID),
-		"addr:city":        tags["addr:city"],
-		"addr:street":      tags["addr:street"],
-		"addr:housenumber": tags["addr:housenumber"],
-		"name":             tags["name"],
+	placeData := make(map[string]string, len(tags)+5)
+	for k, v := range tags {
+		if !promptServiceKeys[k] && k != "lat" && k != "lon" {
+			placeData[k] = v
+		}
+	}
+	if name := localizedTag(tags, "name", language); name != "" {
+		placeData["name"] = name
+	}
+	placeData["type"] = obj.Type
+	placeData["osm_id"] = fmt.Sprintf("%d", obj.ID)
+	placeData["place_name"] = buildPlaceName(tags, language, obj.Type, obj.ID)
+	if language != "" {
+		placeData["language"] = language
 	}
 	if obj.Lat != 0 && obj.Lon != 0 {
 		placeData["lat"] = fmt.Sprintf("%f", obj.Lat)
@@ -466,17 +486,8 @@ func buildPlaceData(obj dto.OSMObject) map[string]string {
 	return placeData
 }
 
-// buildPlaceOutput формирует выходное представление места
-func buildPlaceOutput(obj dto.OSMObject) map[string]interface{} {
-	tags := obj.Tags
-	placeData := map[string]interface{}{
-		"place_name": buildPlaceName(tags, obj.Type, obj.ID),
-		"address":    "",
-		"city":       tags["addr:city"],
-		"place_id":   fmt.Sprintf("%d", obj.ID),
-	}
-
-	// Формируем address
+// buildAddress формирует адрес из улицы и номера дома
+func buildAddress(tags map[string]string) string {
 	var addressParts []string
 	if street := tags["addr:street"]; street != "" {
 		addressParts = append(addressParts, street)
@@ -484,40 +495,57 @@ func buildPlaceOutput(obj dto.OSMObject) map[string]interface{} {
 	if housenumber := tags["addr:housenumber"]; housenumber != "" {
 		addressParts = append(addressParts, housenumber)
 	}
-	placeData["address"] = strings.Join(addressParts, ", ")
+	return strings.Join(addressParts, ", ")
+}
+
+// applyPlaceOutput дополняет результат полной информацией об объекте OSM, название — на языке language.
+// Координаты берутся из геометрии shape, для way и relation добавляются центроид, рамка и сама геометрия
+func applyPlaceOutput(result *dto.PlaceResult, obj dto.OSMObject, shape *geo.Shape, language string) {
+	tags := obj.Tags
+	result.PlaceName = buildPlaceName(tags, language, obj.Type, obj.ID)
+	result.PlaceID = fmt.Sprintf("%d", obj.ID)
+	result.OSMType = obj.Type
+	result.Address = buildAddress(tags)
+	result.City = tags["addr:city"]
 
 	// Добавляем nodes
-	if obj.Nodes != nil && len(obj.Nodes) > 0 {
-		placeData["nodes"] = obj.Nodes
+	if len(obj.Nodes) > 0 {
+		result.Nodes = obj.Nodes
 	}
 
-	// Добавляем координаты
-	if obj.Lat != 0 && obj.Lon != 0 {
-		placeData["lat"] = obj.Lat
-		placeData["lon"] = obj.Lon
+	if shape == nil {
+		return
 	}
-
-	return placeData
+	point := shape.RepresentativePoint()
+	result.Coordinates = &dto.Coordinates{Lat: point.Lat, Lon: point.Lon}
+	if obj.Type == "node" {
+		return
+	}
+	centroid := shape.Centroid()
+	result.Centroid = &dto.Coordinates{Lat: centroid.Lat, Lon: centroid.Lon}
+	box := shape.BBox()
+	result.BBox = []float64{box.MinLon, box.MinLat, box.MaxLon, box.MaxLat}
+	geometry := shape.GeoJSON()
+	result.Geometry = &geometry
 }
 
-// StreamProcessJSON обрабатывает JSON-файл и отправляет результаты в канал по мере готовности
-func (s *PlaceService) StreamProcessJSON(userID uint, osmObjects []dto.OSMObject, resultChan chan<- map[string]interface{}) {
-	places := make([]map[string]string, 0)
-
-	// Формируем список мест
-	for _, obj := range osmObjects {
-		// Пропускаем пустые места
-		if isPlaceEmpty(obj.Tags) {
-			continue
+// StreamProcessJSON обрабатывает JSON-файл и отправляет результаты в канал по мере готовности.
+// Пропущенные объекты отправляются сразу со статусом skipped.
+// Если язык в opts не задан, описания строятся на языке из профиля пользователя.
+// При отмене ctx обработка прерывается, и метод возвращается, не дожидаясь чтения канала
+func (s *PlaceService) StreamProcessJSON(ctx context.Context, userID uint, opts DescribeOptions, osmObjects []dto.OSMObject, resultChan chan<- dto.PlaceResult) {
+	opts.Language = s.requestLanguage(ctx, userID, opts.Language)
+	inputs, skipped := preparePlaces(osmObjects, opts.Language)
+	opts.apply(inputs)
+
+	for _, result := range skipped {
+		if !sendResult(ctx, resultChan, result) {
+			return
 		}
-
-		// Формируем данные для ProcessPlaces
-		placeData := buildPlaceData(obj)
-		places = append(places, placeData)
 	}
 
 	// Обрабатываем места и отправляем результаты в канал
-	s.ProcessPlacesGoroutines(userID, places, resultChan)
+	s.processInputsGoroutines(ctx, userID, inputs, resultChan)
 }
 
 //
@@ -526,358 +554,79 @@ func (s *PlaceService) StreamProcessJSON(userID uint, osmObjects []dto.OSMObject
 //
 //
 
-// ProcessJSONNoAuth обрабатывает JSON-файл и отправляет места на обработку без аутентификации
-func (s *PlaceService) ProcessJSONNoAuth(osmObjects []dto.OSMObject) ([]map[string]interface{}, error) {
-	places := make([]map[string]string, 0)
-
-	// Формируем список мест
-	for _, obj := range osmObjects {
-		// Пропускаем пустые места
-		if isPlaceEmpty(obj.Tags) {
-			continue
-		}
-
-		// Формируем данные для ProcessPlaces
-		placeData := buildPlaceData(obj)
-		places = append(places, placeData)
-	}
-
-	// Обрабатываем места
-	results, err := s.ProcessPlacesNoAuth(places)
-	if err != nil {
-		return results, err
-	}
-
-	// Обновляем результаты, добавляя полную информацию о местах
-	for i, result := range results {
-		place := osmObjects[i]
-		placeOutput := buildPlaceOutput(place)
-		// Копируем все поля из placeOutput в result
-		for k, v := range placeOutput {
-			result[k] = v
-		}
-		// Удаляем временное поле details, если оно больше не нужно
-		delete(result, "details")
-		// Добавляем place_id в успешные результаты
-		if status, ok := result["status"].(string); ok && status == "success" {
-			result["place_id"] = fmt.Sprintf("%d", osmObjects[i].ID)
-		}
-	}
-
-	return results, nil
-}
-
-func (s *PlaceService) ProcessPlacesNoAuth(places []map[string]string) ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
-	//ctx := context.Background()
-
-	// Заглушка: если массив пустой, возвращаем пустой результат
-	if len(places) == 0 {
-		return results, nil
-	}
-
-	for _, place := range places {
-
-		placeName := place["place_name"]
-
-		// Формируем массив с одним элементом для отправки в LLM
-		placesToProcess := []map[string]string{place}
-
-		// Отправляем запрос в LLM
-		text, err := s.SendBatchToLLM(placesToProcess)
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"place_name": placeName,
-				"status":     "llm_error",
-				"response":   err.Error(),
-				"audio":      nil,
-			})
-			continue
-			//return results, nil //err
-		}
-
-		// Генерируем аудио
-		audioData, err := s.AudioGenerate(text)
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"place_name": placeName,
-				"status":     "tts_error",
-				"response":   text,
-				"audio":      nil,
-			})
-			continue
-			//return results, nil //err
-		}
-
-		// Успешный результат
-		results = append(results, map[string]interface{}{
-			"place_name": placeName,
-			"status":     "success",
-			"response":   text,
-			"audio":      audioData,
-		})
-	}
-
-	return results, nil
-}
-
-//  MISTRAL ниже
-
-// ProcessJSONMistral обрабатывает JSON-файл и отправляет места на обработку для Mistral
-func (s *PlaceService) ProcessJSONMistral(osmObjects []dto.OSMObject) ([]map[string]interface{}, error) {
-	places := make([]map[string]string, 0)
-
-	// Формируем список мест
-	for _, obj := range osmObjects {
-		// Пропускаем пустые места
-		if isPlaceEmpty(obj.Tags) {
-			continue
-		}
-
-		// Формируем данные для ProcessPlaces
-		placeData := buildPlaceData(obj)
-		places = append(places, placeData)
+// ProcessJSONNoAuth обрабатывает JSON-файл и отправляет места на обработку без аутентификации.
+// opts выбирает LLM-провайдер, язык и аудиторию шаблона промпта, пустые значения означают значения по умолчанию.
+// Результат содержит по одному элементу на каждый входной объект в том же порядке.
+// После отмены ctx оставшиеся объекты не обрабатываются и возвращается ctx.Err()
+func (s *PlaceService) ProcessJSONNoAuth(ctx context.Context, opts DescribeOptions, osmObjects []dto.OSMObject) ([]dto.PlaceResult, error) {
+	results := make([]dto.PlaceResult, len(osmObjects))
+	if opts.Language == "" {
+		opts.Language = DefaultLanguage
 	}
 
-	// Обрабатываем места
-	results, err := s.ProcessPlacesMistral(places)
-	if err != nil {
-		return results, err
+	inputs, skipped := preparePlaces(osmObjects, opts.Language)
+	for _, result := range skipped {
+		results[result.Index] = result
 	}
+	opts.apply(inputs)
 
-	// Обновляем результаты, добавляя полную информацию о местах
-	for i, result := range results {
-		place := osmObjects[i]
-		placeOutput := buildPlaceOutput(place)
-		// Копируем все поля из placeOutput в result
-		for k, v := range placeOutput {
-			result[k] = v
-		}
-		// Удаляем временное поле details, если оно больше не нужно
-		delete(result, "details")
-		// Добавляем place_id в успешные результаты
-		if status, ok := result["status"].(string); ok && status == "success" {
-			result["place_id"] = fmt.Sprintf("%d", osmObjects[i].ID)
+	for _, in := range inputs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		results[in.Position] = s.processPlaceNoAuth(ctx, opts.Provider, in)
 	}
 
 	return results, nil
 }
 
-func (s *PlaceService) ProcessPlacesMistral(places []map[string]string) ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
-	//ctx := context.Background()
+// ProcessPlacesNoAuth последовательно описывает места выбранным LLM-провайдером и озвучивает ответы
+func (s *PlaceService) ProcessPlacesNoAuth(ctx context.Context, opts DescribeOptions, places []map[string]string) ([]dto.PlaceResult, error) {
+	var results []dto.PlaceResult
 
-	// Заглушка: если массив пустой, возвращаем пустой результат
-	if len(places) == 0 {
-		return results, nil
+	if opts.Language == "" {
+		opts.Language = DefaultLanguage
 	}
-
-	for _, place := range places {
-
-		placeName := place["place_name"]
-
-		// Формируем массив с одним элементом для отправки в LLM
-		placesToProcess := []map[string]string{place}
-
-		// Отправляем запрос в LLM
-		text, err := s.SendBatchToMistral(placesToProcess)
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"place_name": placeName,
-				"status":     "llm_error",
-				"response":   err.Error(),
-				"audio":      nil,
-			})
-			continue
-			//return results, nil //err
+	inputs := placeInputsFromData(places)
+	opts.apply(inputs)
+	for _, in := range inputs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-
-		// Генерируем аудио
-		audioData, err := s.AudioGenerate(text)
-		if err != nil {
-			results = append(results, map[string]interface{}{
-				"place_name": placeName,
-				"status":     "tts_error",
-				"response":   text,
-				"audio":      nil,
-			})
-			continue
-			//return results, nil //err
-		}
-
-		// Успешный результат
-		results = append(results, map[string]interface{}{
-			"place_name": placeName,
-			"status":     "success",
-			"response":   text,
-			"audio":      audioData,
-		})
+		results = append(results, s.processPlaceNoAuth(ctx, opts.Provider, in))
 	}
 
 	return results, nil
 }
 
-func (s *PlaceService) SendBatchToMistral(places []map[string]string) (string, error) {
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 300*time.Second)
-	defer cancel()
-
-	if len(places) == 0 {
-		return "", fmt.Errorf("массив мест пуст")
-	}
-
-	// Берем только первое место, так как ожидается один объект
-	place := places[0]
-
-	// Формируем JSON вручную с помощью strings.Builder
-	var jsonBuilder strings.Builder
-	jsonBuilder.WriteString("{") // Начинаем с объекта
-
-	// Поля для места
-	fields := []string{
-		"addr:city",
-		"addr:street",
-		"addr:housenumber",
-		"name",
-		"amenity",
-		"tourism",
-		"highway",
-		"leisure",
-		"building",
-		"inscription",
-		"description",
-		"lat",
-		"lon",
-	}
-
-	firstField := true // Флаг для отслеживания первого добавленного поля
-	for _, field := range fields {
-		if value, exists := place[field]; exists && value != "" {
-			if !firstField {
-				jsonBuilder.WriteString(",")
-			}
-			escapedValue := strings.ReplaceAll(value, `"`, `\"`)
-			jsonBuilder.WriteString(fmt.Sprintf(`"%s":"%s"`, field, escapedValue))
-			firstField = false // После добавления первого поля сбрасываем флаг
-		}
-	}
-
-	jsonBuilder.WriteString("}") // Закрываем объект
-	jsonBody := jsonBuilder.String()
-
-	req, err := http.NewRequestWithContext(ctxWithTimeout, "POST", os.Getenv("HOST_MISTRAL"), bytes.NewBufferString(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("ошибка при создании запроса: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при отправке запроса: %v", err)
-	}
-	defer resp.Body.Close()
+// processPlaceNoAuth описывает и озвучивает одно место без кеша описаний и истории
+func (s *PlaceService) processPlaceNoAuth(ctx context.Context, providerName string, in placeInput) dto.PlaceResult {
+	placeResult := newInputResult(in)
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при чтении тела ответа: %v", err)
-	}
-
-	var response struct {
-		Text string `json:"message"`
-	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("ошибка парсинга JSON: %v", err)
+	// Отправляем запрос в LLM
+	text, err := s.describePlace(ctx, providerName, in.Data)
+	if ctx.Err() != nil {
+		placeResult.Fail(dto.PlaceStatusCancelled, ctx.Err().Error())
+		return placeResult
+	} else if err != nil {
+		placeResult.Fail(dto.PlaceStatusLLMError, err.Error())
+		return placeResult
 	}
+	placeResult.Response = text
 
-	if err := checkResponseError(response.Text, "LLM"); err != nil {
-		return "", err
+	// Генерируем аудио голосом языка описания
+	asset, err := s.generateAudio(ctx, text, in.Data["language"])
+	if ctx.Err() != nil {
+		placeResult.Fail(dto.PlaceStatusCancelled, ctx.Err().Error())
+		return placeResult
+	} else if err != nil {
+		placeResult.Fail(dto.PlaceStatusTTSError, err.Error())
+		return placeResult
 	}
 
-	return response.Text, nil
+	// Успешный результат
+	placeResult.Audio = s.audioRef(asset)
+	placeResult.Status = dto.PlaceStatusSuccess
+	s.rememberPlace(ctx, providerName, in, in.Data, placeResult, true)
+	return placeResult
 }
-
-// func (s *PlaceService) SendBatchToMistral(places []map[string]string) (string, error) {
-// 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-// 	defer cancel()
-
-// 	// Поскольку мы работаем с заглушкой, берём только первый элемент
-// 	if len(places) == 0 {
-// 		return "", fmt.Errorf("массив мест пуст")
-// 	}
-// 	place := places[0]
-
-// 	// Формируем JSON вручную в формате одиночного объекта
-// 	var jsonBuilder strings.Builder
-// 	jsonBuilder.WriteString("{")
-
-// 	// Поля в точном порядке, как в PlaceData
-// 	fields := []struct {
-// 		key   string
-// 		value string
-// 	}{
-// 		{"addr:city", place["addr:city"]},
-// 		{"addr:street", place["addr:street"]},
-// 		{"addr:housenumber", place["addr:housenumber"]},
-// 		{"name", place["name"]},
-// 		{"amenity", place["amenity"]},
-// 		{"tourism", place["tourism"]},
-// 		{"highway", place["highway"]},
-// 		{"leisure", place["leisure"]},
-// 		{"building", place["building"]},
-// 		{"inscription", place["inscription"]},
-// 		{"description", place["description"]},
-// 	}
-
-// 	for i, field := range fields {
-// 		if i > 0 {
-// 			jsonBuilder.WriteString(",")
-// 		}
-// 		// Экранируем значение для JSON
-// 		escapedValue := strings.ReplaceAll(field.value, `"`, `\"`)
-// 		jsonBuilder.WriteString(fmt.Sprintf(`"%s":"%s"`, field.key, escapedValue))
-// 	}
-
-// 	jsonBuilder.WriteString("}")
-// 	jsonBody := jsonBuilder.String()
-
-// 	req, err := http.NewRequestWithContext(ctxWithTimeout, "POST", os.Getenv("HOST_MISTRAL"), bytes.NewBufferString(jsonBody))
-// 	if err != nil {
-// 		return "", fmt.Errorf("ошибка при создании запроса: %v", err)
-// 	}
-// 	req.Header.Set("Content-Type", "application/json")
-
-// 	client := &http.Client{}
-// 	resp, err := client.Do(req)
-// 	if err != nil {
-// 		return "", fmt.Errorf("ошибка при отправке запроса: %v", err)
-// 	}
-// 	defer resp.Body.Close()
-
-// 	if resp.StatusCode != http.StatusOK {
-// 		return "", fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
-// 	}
-
-// 	body, err := io.ReadAll(resp.Body)
-// 	if err != nil {
-// 		return "", fmt.Errorf("ошибка при чтении тела ответа: %v", err)
-// 	}
-
-// 	var response struct {
-// 		Text string `json:"message"`
-// 	}
-// 	if err := json.Unmarshal(body, &response); err != nil {
-// 		return "", fmt.Errorf("ошибка парсинга JSON: %v", err)
-// 	}
-
-// 	// Проверяем текст на ошибки
-// 	if err := checkResponseError(response.Text, "LLM"); err != nil {
-// 		return "", err
-// 	}
-
-// 	return response.Text, nil
-// }
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"new/dto"
+	"new/models"
+	"new/services/llm"
+)
+
+// Причина пропуска объекта, не подходящего под интересы пользователя
+const skipReasonInterests = "объект не соответствует интересам пользователя"
+
+// tagPattern — условие на тег OSM: key=value, key=v1|v2 или key=* (любое значение)
+type tagPattern struct {
+	Key    string
+	Values []string // Пусто — подходит любое непустое значение
+}
+
+// matches проверяет условие на тегах объекта
+func (p tagPattern) matches(tags map[string]string) bool {
+	value := tags[p.Key]
+	if value == "" {
+		return false
+	}
+	if len(p.Values) == 0 {
+		return true
+	}
+	for _, v := range p.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTagPatterns разбирает список условий вида "historic=*,tourism=museum|gallery,artwork_type"
+func ParseTagPatterns(spec string) ([]tagPattern, error) {
+	var patterns []tagPattern
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " *|") {
+			return nil, fmt.Errorf("некорректное условие на тег: %q", part)
+		}
+		pattern := tagPattern{Key: key}
+		value = strings.TrimSpace(value)
+		if hasValue && value != "*" {
+			for _, v := range strings.Split(value, "|") {
+				if v = strings.TrimSpace(v); v != "" {
+					pattern.Values = append(pattern.Values, v)
+				}
+			}
+			if len(pattern.Values) == 0 {
+				return nil, fmt.Errorf("некорректное условие на тег: %q", part)
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// interest — выбранный пользователем элемент каталога и его условия на теги
+type interest struct {
+	Name     string
+	Patterns []tagPattern
+}
+
+// userInterests — интересы пользователя, влияющие на промпт и порядок мест
+type userInterests struct {
+	Items []interest
+	Hash  string // Отпечаток набора предпочтений для персонального кеша; пусто — предпочтений нет
+}
+
+// loadInterests загружает предпочтения пользователя вместе с элементами каталога
+func (s *PlaceService) loadInterests(ctx context.Context, userID uint) (*userInterests, error) {
+	result := &userInterests{}
+	if userID == 0 || s.DB == nil {
+		return result, nil
+	}
+
+	var preferences []models.Preference
+	if err := s.DB.WithContext(ctx).Preload("ListPreference").Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("ошибка загрузки предпочтений: %v", err)
+	}
+	if len(preferences) == 0 {
+		return result, nil
+	}
+
+	sort.Slice(preferences, func(i, j int) bool {
+		a, b := preferences[i].ListPreference, preferences[j].ListPreference
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+
+	ids := make([]string, 0, len(preferences))
+	for _, preference := range preferences {
+		ids = append(ids, strconv.FormatUint(uint64(preference.ListPreferenceID), 10))
+
+		patterns, err := ParseTagPatterns(preference.ListPreference.OSMTags)
+		if err != nil {
+			// Ошибочные условия не должны ломать обработку: интерес остаётся в промпте
+			fmt.Printf("Некорректные теги у элемента каталога %d: %v\n", preference.ListPreferenceID, err)
+		}
+		result.Items = append(result.Items, interest{Name: preference.ListPreference.Name, Patterns: patterns})
+	}
+	sort.Strings(ids)
+
+	sum := sha1.Sum([]byte(strings.Join(ids, ",")))
+	result.Hash = hex.EncodeToString(sum[:])[:12]
+	return result, nil
+}
+
+// Names возвращает названия интересов в порядке каталога
+func (u *userInterests) Names() []string {
+	names := make([]string, 0, len(u.Items))
+	for _, item := range u.Items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// match возвращает названия интересов, под которые подходит объект с тегами tags
+func (u *userInterests) match(tags map[string]string) []string {
+	var matched []string
+	for _, item := range u.Items {
+		for _, pattern := range item.Patterns {
+			if pattern.matches(tags) {
+				matched = append(matched, item.Name)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// hasPatterns сообщает, задан ли хотя бы у одного интереса способ сопоставления с объектами
+func (u *userInterests) hasPatterns() bool {
+	for _, item := range u.Items {
+		if len(item.Patterns) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// inputTags возвращает теги, по которым место сопоставляется с интересами
+func inputTags(in placeInput) map[string]string {
+	if in.Object != nil {
+		return in.Object.Tags
+	}
+	return in.Data
+}
+
+// filterByInterests включает отбрасывание мест без совпадений с интересами (PREFERENCES_FILTER=true)
+func filterByInterests() bool {
+	value, _ := strconv.ParseBool(os.Getenv("PREFERENCES_FILTER"))
+	return value
+}
+
+// rankInputs упорядочивает места по числу совпавших интересов (стабильно, при равенстве — исходный порядок).
+// При включённом PREFERENCES_FILTER места без совпадений возвращаются как пропущенные
+func (u *userInterests) rankInputs(inputs []placeInput) ([]placeInput, []dto.PlaceResult) {
+	if !u.hasPatterns() {
+		return inputs, nil
+	}
+
+	scores := make(map[int]int, len(inputs))
+	ranked := make([]placeInput, 0, len(inputs))
+	var skipped []dto.PlaceResult
+	filter := filterByInterests()
+
+	for _, in := range inputs {
+		score := len(u.match(inputTags(in)))
+		if score == 0 && filter {
+			result := newInputResult(in)
+			result.Status = dto.PlaceStatusSkipped
+			result.Reason = skipReasonInterests
+			skipped = append(skipped, result)
+			continue
+		}
+		scores[in.Position] = score
+		ranked = append(ranked, in)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].Position] > scores[ranked[j].Position]
+	})
+	return ranked, skipped
+}
+
+// withInterests возвращает копию данных места с интересами пользователя для промпта
+func (u *userInterests) withInterests(place map[string]string) map[string]string {
+	if len(u.Items) == 0 {
+		return place
+	}
+	data := make(map[string]string, len(place)+1)
+	for k, v := range place {
+		data[k] = v
+	}
+	data[llm.InterestsField] = strings.Join(u.Names(), ", ")
+	return data
+}
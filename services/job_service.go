@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"new/database"
+	"new/dto"
+	"new/models"
+	"new/services/geo"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// jobQueueKey — список Redis с идентификаторами задач, ожидающих обработки
+const jobQueueKey = "jobs:queue"
+
+// defaultTaskLease — через сколько выполняющаяся или ожидающая задача считается брошенной
+const defaultTaskLease = 30 * time.Minute
+
+// ErrInvalidJob возвращается при пустом массиве мест или неизвестном LLM-провайдере задания
+var ErrInvalidJob = errors.New("некорректное задание")
+
+// JobService управляет асинхронными заданиями на обработку мест
+type JobService struct {
+	DB           *gorm.DB
+	Places       *PlaceService
+	LeaseTimeout time.Duration // Через сколько выполняющаяся или не взятая в работу задача возвращается в очередь, по умолчанию 30 минут
+}
+
+// CreateJob сохраняет задание с задачами по каждому OSM-объекту и ставит задачи в очередь.
+// Пустые объекты сразу отмечаются как пропущенные. Пустой массив и неизвестный провайдер дают ErrInvalidJob
+func (s *JobService) CreateJob(ctx context.Context, providerName, language string, osmObjects []dto.OSMObject) (*models.Job, error) {
+	if len(osmObjects) == 0 {
+		return nil, fmt.Errorf("%w: массив мест пуст", ErrInvalidJob)
+	}
+	if s.Places.LLM != nil {
+		if _, err := s.Places.LLM.Get(providerName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+	}
+
+	job := &models.Job{
+		Provider: providerName,
+		Language: language,
+		Status:   models.JobStatusQueued,
+		Total:    len(osmObjects),
+	}
+	var tasks []models.JobTask
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+
+		// Каждая задача обрабатывается отдельно, поэтому координаты узлов и участников переносятся в сам объект
+		index := geo.NewIndex(osmObjects)
+		tasks = make([]models.JobTask, 0, len(osmObjects))
+		for i, obj := range osmObjects {
+			input, err := json.Marshal(index.Embed(obj))
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации объекта %d: %v", i, err)
+			}
+			task := models.JobTask{
+				JobID:    job.ID,
+				Position: i,
+				OSMType:  obj.Type,
+				OSMID:    obj.ID,
+				Input:    string(input),
+				Status:   models.TaskStatusPending,
+			}
+			if isPlaceEmpty(obj.Tags) {
+				task.Status = models.TaskStatusSkipped
+				task.Error = skipReasonEmpty
+				job.Skipped++
+			}
+			tasks = append(tasks, task)
+		}
+		if err := tx.Create(&tasks).Error; err != nil {
+			return err
+		}
+
+		if job.Skipped == job.Total {
+			now := time.Now()
+			job.Status = models.JobStatusCompleted
+			job.FinishedAt = &now
+		}
+		return tx.Save(job).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания задания: %v", err)
+	}
+
+	// Задание уже сохранено: не поставленные в очередь задачи вернет RequeueStale
+	for _, task := range tasks {
+		if task.Status != models.TaskStatusPending {
+			continue
+		}
+		if err := s.Enqueue(ctx, task.ID); err != nil {
+			fmt.Printf("Задача %d задания %d не поставлена в очередь: %v\n", task.ID, job.ID, err)
+		}
+	}
+
+	return job, nil
+}
+
+// Enqueue ставит задачу в очередь Redis
+func (s *JobService) Enqueue(ctx context.Context, taskID uint) error {
+	if err := database.RedisClient.LPush(ctx, jobQueueKey, taskID).Err(); err != nil {
+		return fmt.Errorf("ошибка постановки задачи в очередь: %v", err)
+	}
+	return nil
+}
+
+// Dequeue ждёт следующую задачу из очереди. ok=false, если за timeout задач не появилось.
+// При отмене ctx ожидание прерывается
+func (s *JobService) Dequeue(ctx context.Context, timeout time.Duration) (taskID uint, ok bool, err error) {
+	values, err := database.RedisClient.BRPop(ctx, timeout, jobQueueKey).Result()
+	if err == redis.Nil {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	id, err := strconv.ParseUint(values[1], 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("некорректный идентификатор задачи в очереди: %s", values[1])
+	}
+	return uint(id), true, nil
+}
+
+// RequeueStale возвращает в очередь задачи, которые выполняются дольше LeaseTimeout: их обработчик,
+// скорее всего, остановился вместе с сервером. Задачи pending, не взятые в работу за то же время, ставятся
+// в очередь повторно: их могли не поставить из-за ошибки Redis или потерять вместе со списком.
+// Лишняя копия в очереди безопасна — задачу обрабатывает только тот, кто первым ее занял
+func (s *JobService) RequeueStale(ctx context.Context) (int, error) {
+	staleBefore := time.Now().Add(-s.leaseTimeout())
+	statuses := []string{models.TaskStatusRunning, models.TaskStatusPending}
+	var ids []uint
+	if err := s.DB.WithContext(ctx).Model(&models.JobTask{}).
+		Where("status IN ? AND updated_at < ?", statuses, staleBefore).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, id := range ids {
+		// Задачу могли за это время взять, завершить или вернуть в очередь на другом экземпляре.
+		// Новое updated_at откладывает следующую попытку для этой задачи на LeaseTimeout
+		update := s.DB.WithContext(ctx).Model(&models.JobTask{}).
+			Where("id = ? AND status IN ? AND updated_at < ?", id, statuses, staleBefore).
+			Updates(map[string]interface{}{"status": models.TaskStatusPending, "updated_at": time.Now()})
+		if update.Error != nil {
+			return requeued, update.Error
+		}
+		if update.RowsAffected == 0 {
+			continue
+		}
+		if err := s.Enqueue(ctx, id); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+func (s *JobService) leaseTimeout() time.Duration {
+	if s.LeaseTimeout > 0 {
+		return s.LeaseTimeout
+	}
+	return defaultTaskLease
+}
+
+// ProcessTask обрабатывает одну задачу и обновляет прогресс задания.
+// Задачу обрабатывает только тот, кто первым перевел ее из pending в running, повторные копии в очереди пропускаются.
+// Прерванная отменой ctx задача возвращается в очередь
+func (s *JobService) ProcessTask(ctx context.Context, taskID uint) error {
+	var task models.JobTask
+	if err := s.DB.Preload("Job").First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("задача %d не найдена: %v", taskID, err)
+	}
+
+	claim := s.DB.Model(&models.JobTask{}).
+		Where("id = ? AND status = ?", task.ID, models.TaskStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.TaskStatusRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		// Задачу уже обрабатывают или обработали
+		return nil
+	}
+	s.DB.Model(&models.Job{}).Where("id = ? AND status = ?", task.JobID, models.JobStatusQueued).
+		Update("status", models.JobStatusRunning)
+
+	var obj dto.OSMObject
+	if err := json.Unmarshal([]byte(task.Input), &obj); err != nil {
+		return s.finishTask(&task, models.TaskStatusFailed, nil, fmt.Sprintf("некорректный объект: %v", err))
+	}
+
+	results, err := s.Places.ProcessJSONNoAuth(ctx, DescribeOptions{Provider: task.Job.Provider, Language: task.Job.Language}, []dto.OSMObject{obj})
+	if ctx.Err() != nil {
+		return s.releaseTask(ctx, task.ID)
+	} else if err != nil {
+		return s.finishTask(&task, models.TaskStatusFailed, nil, err.Error())
+	}
+	if len(results) == 0 {
+		return s.finishTask(&task, models.TaskStatusFailed, nil, "нет результата обработки")
+	}
+
+	result := results[0]
+	result.Index = task.Position
+	if result.Status == dto.PlaceStatusSkipped {
+		return s.finishTask(&task, models.TaskStatusSkipped, &result, result.Reason)
+	}
+	if result.Status != dto.PlaceStatusSuccess {
+		message := string(result.Status)
+		if result.Error != nil {
+			message = result.Error.Message
+		}
+		return s.finishTask(&task, models.TaskStatusFailed, &result, message)
+	}
+	return s.finishTask(&task, models.TaskStatusDone, &result, "")
+}
+
+// releaseTask возвращает прерванную задачу в очередь
+func (s *JobService) releaseTask(ctx context.Context, taskID uint) error {
+	ctx = context.WithoutCancel(ctx)
+	update := s.DB.WithContext(ctx).Model(&models.JobTask{}).
+		Where("id = ? AND status = ?", taskID, models.TaskStatusRunning).
+		Updates(map[string]interface{}{"status": models.TaskStatusPending, "updated_at": time.Now()})
+	if update.Error != nil {
+		return update.Error
+	}
+	if update.RowsAffected == 0 {
+		return nil
+	}
+	return s.Enqueue(ctx, taskID)
+}
+
+// finishTask сохраняет результат задачи, увеличивает счётчики задания и завершает его после последней задачи.
+// Счетчики меняются, только если задача еще выполнялась, поэтому повторное завершение их не увеличивает
+func (s *JobService) finishTask(task *models.JobTask, status string, result *dto.PlaceResult, message string) error {
+	var resultJSON string
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации результата: %v", err)
+		}
+		resultJSON = string(data)
+	}
+
+	counter := "done"
+	switch status {
+	case models.TaskStatusFailed:
+		counter = "failed"
+	case models.TaskStatusSkipped:
+		counter = "skipped"
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&models.JobTask{}).
+			Where("id = ? AND status = ?", task.ID, models.TaskStatusRunning).
+			Updates(map[string]interface{}{
+				"status": status,
+				"result": resultJSON,
+				"error":  message,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Job{}).Where("id = ?", task.JobID).
+			Update(counter, gorm.Expr(counter+" + 1")).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Job{}).
+			Where("id = ? AND done + failed + skipped >= total", task.JobID).
+			Updates(map[string]interface{}{
+				"status":      models.JobStatusCompleted,
+				"finished_at": time.Now(),
+			}).Error
+	})
+}
+
+// GetJob возвращает задание с текущим прогрессом
+func (s *JobService) GetJob(jobID uint) (*models.Job, error) {
+	var job models.Job
+	if err := s.DB.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("задание %d не найдено: %w", jobID, err)
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobResults возвращает результаты всех задач задания в порядке входного массива,
+// включая ещё не обработанные
+func (s *JobService) GetJobResults(jobID uint) ([]dto.JobTaskResultDTO, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, err
+	}
+
+	var tasks []models.JobTask
+	if err := s.DB.Where("job_id = ?", jobID).Order("position").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.JobTaskResultDTO, 0, len(tasks))
+	for _, task := range tasks {
+		item := dto.JobTaskResultDTO{
+			Position: task.Position,
+			OSMType:  task.OSMType,
+			OSMID:    task.OSMID,
+			Status:   task.Status,
+			Error:    task.Error,
+		}
+		if task.Result != "" {
+			var result dto.PlaceResult
+			if err := json.Unmarshal([]byte(task.Result), &result); err != nil {
+				return nil, fmt.Errorf("некорректный результат задачи %d: %v", task.ID, err)
+			}
+			item.Result = &result
+		}
+		results = append(results, item)
+	}
+	return results, nil
+}
@@ -1 +1,276 @@
 package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"new/dto"
+	"new/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCatalogNameRequired — у элемента каталога нет названия
+	ErrCatalogNameRequired = errors.New("не указано название элемента каталога")
+	// ErrCatalogNameTaken — элемент каталога с таким названием уже есть
+	ErrCatalogNameTaken = errors.New("элемент каталога с таким названием уже существует")
+	// ErrInvalidTags — условия на теги OSM записаны с ошибкой
+	ErrInvalidTags = errors.New("некорректные условия на теги OSM")
+)
+
+// ListPreferenceService управляет каталогом интересов
+type ListPreferenceService struct {
+	DB *gorm.DB
+}
+
+// List возвращает весь каталог, включая выведенные элементы, с переводами
+func (s *ListPreferenceService) List() ([]models.ListPreference, error) {
+	var items []models.ListPreference
+	err := s.DB.Preload("Translations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("language")
+	}).Order("position, id").Find(&items).Error
+	return items, err
+}
+
+// Catalog возвращает действующие элементы каталога с названиями на языке lang
+func (s *ListPreferenceService) Catalog(lang string) ([]dto.CatalogItemDTO, error) {
+	var items []models.ListPreference
+	query := s.DB.Where("retired_at IS NULL").Order("position, id")
+	if lang != "" {
+		query = query.Preload("Translations", "language IN ?", languageCandidates(lang))
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.CatalogItemDTO, 0, len(items))
+	for _, item := range items {
+		result = append(result, dto.CatalogItemDTO{
+			ID:       item.ID,
+			Name:     localizedName(item, lang),
+			Position: item.Position,
+		})
+	}
+	return result, nil
+}
+
+// localizedName выбирает перевод для lang, иначе — исходное название
+func localizedName(item models.ListPreference, lang string) string {
+	for _, candidate := range languageCandidates(lang) {
+		for _, t := range item.Translations {
+			if t.Language == candidate {
+				return t.Name
+			}
+		}
+	}
+	return item.Name
+}
+
+// Create добавляет элемент в каталог
+func (s *ListPreferenceService) Create(input dto.CreateListPreferenceDTO) (*models.ListPreference, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrCatalogNameRequired
+	}
+	if _, err := ParseTagPatterns(input.OSMTags); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTags, err)
+	}
+	item := &models.ListPreference{Name: name, OSMTags: strings.TrimSpace(input.OSMTags)}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCatalogName(tx, name, 0); err != nil {
+			return err
+		}
+		if input.Position != nil {
+			item.Position = *input.Position
+		} else {
+			var last struct{ Max *int }
+			if err := tx.Model(&models.ListPreference{}).Select("MAX(position) AS max").Scan(&last).Error; err != nil {
+				return err
+			}
+			if last.Max != nil {
+				item.Position = *last.Max + 1
+			}
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return catalogNameError(err)
+		}
+		return setTranslations(tx, item.ID, input.Translations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.get(item.ID)
+}
+
+// Rename меняет исходное название элемента каталога
+func (s *ListPreferenceService) Rename(id uint, name string) (*models.ListPreference, error) {
+	name = strings.TrimSpace(name)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.ListPreference{}, id).Error; err != nil {
+			return err
+		}
+		if err := checkCatalogName(tx, name, id); err != nil {
+			return err
+		}
+		return catalogNameError(tx.Model(&models.ListPreference{}).Where("id = ?", id).Update("name", name).Error)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.get(id)
+}
+
+// SetTags задает условия на теги OSM, по которым элемент сопоставляется с объектами
+func (s *ListPreferenceService) SetTags(id uint, spec string) (*models.ListPreference, error) {
+	if _, err := ParseTagPatterns(spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTags, err)
+	}
+	res := s.DB.Model(&models.ListPreference{}).Where("id = ?", id).Update("osm_tags", strings.TrimSpace(spec))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.get(id)
+}
+
+// Reorder задает порядок каталога: сначала ids в указанном порядке, затем остальные в прежнем
+func (s *ListPreferenceService) Reorder(ids []uint) ([]models.ListPreference, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.ListPreference
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("position, id").Find(&items).Error; err != nil {
+			return err
+		}
+		known := make(map[uint]bool, len(items))
+		for _, item := range items {
+			known[item.ID] = true
+		}
+
+		order := make([]uint, 0, len(items))
+		seen := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			if !known[id] {
+				return fmt.Errorf("%w: элемент каталога %d", gorm.ErrRecordNotFound, id)
+			}
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+		for _, item := range items {
+			if !seen[item.ID] {
+				order = append(order, item.ID)
+			}
+		}
+
+		for position, id := range order {
+			if err := tx.Model(&models.ListPreference{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.List()
+}
+
+// SetTranslations добавляет, меняет или удаляет (пустое название) переводы элемента
+func (s *ListPreferenceService) SetTranslations(id uint, translations map[string]string) (*models.ListPreference, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.ListPreference{}, id).Error; err != nil {
+			return err
+		}
+		return setTranslations(tx, id, translations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.get(id)
+}
+
+// Retire выводит элемент из каталога. Выбранные ранее предпочтения не удаляются
+func (s *ListPreferenceService) Retire(id uint) (*models.ListPreference, error) {
+	return s.setRetired(id, time.Now())
+}
+
+// Restore возвращает выведенный элемент в каталог
+func (s *ListPreferenceService) Restore(id uint) (*models.ListPreference, error) {
+	return s.setRetired(id, nil)
+}
+
+func (s *ListPreferenceService) setRetired(id uint, value interface{}) (*models.ListPreference, error) {
+	res := s.DB.Model(&models.ListPreference{}).Where("id = ?", id).Update("retired_at", value)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.get(id)
+}
+
+// get загружает элемент каталога с переводами
+func (s *ListPreferenceService) get(id uint) (*models.ListPreference, error) {
+	var item models.ListPreference
+	if err := s.DB.Preload("Translations").First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// checkCatalogName проверяет, что название не пустое и не занято другим элементом
+func checkCatalogName(tx *gorm.DB, name string, exceptID uint) error {
+	if name == "" {
+		return ErrCatalogNameRequired
+	}
+	var count int64
+	if err := tx.Model(&models.ListPreference{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCatalogNameTaken
+	}
+	return nil
+}
+
+// catalogNameError заменяет нарушение уникальности названия на ErrCatalogNameTaken: параллельный запрос
+// мог занять название после проверки в checkCatalogName
+func catalogNameError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrCatalogNameTaken
+	}
+	return err
+}
+
+// setTranslations сохраняет переводы элемента id
+func setTranslations(tx *gorm.DB, id uint, translations map[string]string) error {
+	for lang, name := range translations {
+		lang, err := normalizeLanguage(lang)
+		if err != nil {
+			return err
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			if err := tx.Where("list_preference_id = ? AND language = ?", id, lang).Delete(&models.ListPreferenceTranslation{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "list_preference_id"}, {Name: "language"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}).Create(&models.ListPreferenceTranslation{ListPreferenceID: id, Language: lang, Name: name}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MessageHostProvider — провайдер для внешних сервисов, которые принимают JSON
+// с тегами места и отвечают объектом {"message": "..."}
+type MessageHostProvider struct {
+	ProviderName string
+	URL          string
+	Timeout      time.Duration
+	Tags         *TagWhitelist // Теги места, передаваемые в сервис
+	Coordinates  bool          // Передавать координаты lat/lon
+	Client       *http.Client
+}
+
+// NewFastAPIProvider создает провайдер для FastAPI-сервиса (HOST_LLM)
+func NewFastAPIProvider(url string, tags *TagWhitelist) *MessageHostProvider {
+	return &MessageHostProvider{
+		ProviderName: "fastapi",
+		URL:          url,
+		Timeout:      9999 * time.Second,
+		Tags:         tags,
+		Client:       &http.Client{},
+	}
+}
+
+// NewMistralProvider создает провайдер для сервиса Mistral (HOST_MISTRAL).
+// В отличие от FastAPI, сюда дополнительно передаются координаты
+func NewMistralProvider(url string, tags *TagWhitelist) *MessageHostProvider {
+	return &MessageHostProvider{
+		ProviderName: "mistral",
+		URL:          url,
+		Timeout:      300 * time.Second,
+		Tags:         tags,
+		Coordinates:  true,
+		Client:       &http.Client{},
+	}
+}
+
+// Name возвращает имя провайдера
+func (p *MessageHostProvider) Name() string {
+	return p.ProviderName
+}
+
+// Describe отправляет данные одного места в сервис в формате одиночного объекта JSON
+func (p *MessageHostProvider) Describe(ctx context.Context, place map[string]string) (string, error) {
+	tags := p.Tags
+	if tags == nil {
+		tags = NewTagWhitelist()
+	}
+	body, err := NewDescribeRequest(place, tags, p.Coordinates).Body()
+	if err != nil {
+		return "", err
+	}
+	return p.post(ctx, body)
+}
+
+// Ask отправляет вопрос в сервис в виде {"message": "..."}
+func (p *MessageHostProvider) Ask(ctx context.Context, question string) (string, error) {
+	jsonBody, err := json.Marshal(map[string]string{"message": question})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+	}
+	return p.post(ctx, jsonBody)
+}
+
+// post выполняет запрос к сервису и извлекает поле message из ответа
+func (p *MessageHostProvider) post(ctx context.Context, jsonBody []byte) (string, error) {
+	if p.URL == "" {
+		return "", fmt.Errorf("адрес сервиса %s не задан", p.ProviderName)
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctxWithTimeout, "POST", p.URL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при отправке запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при чтении тела ответа: %v", err)
+	}
+
+	var response struct {
+		Text string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("ошибка парсинга JSON: %v", err)
+	}
+
+	if err := checkResponseError(response.Text, "LLM"); err != nil {
+		return "", err
+	}
+
+	return response.Text, nil
+}
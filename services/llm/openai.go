@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// describeSystemPrompt — системная инструкция для описания мест
+const describeSystemPrompt = "Ты — экскурсовод. Кратко и интересно расскажи о месте по его данным из OpenStreetMap. " +
+	"Не выдумывай факты, которых нет в данных, и не используй разметку."
+
+// OpenAIProvider — провайдер для OpenAI-совместимого endpoint /chat/completions
+type OpenAIProvider struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+	Tags    *TagWhitelist // Теги места, передаваемые модели
+	Client  *http.Client
+}
+
+// NewOpenAIProvider создает провайдер для OpenAI-совместимого API
+func NewOpenAIProvider(baseURL, apiKey, model string, tags *TagWhitelist) *OpenAIProvider {
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return &OpenAIProvider{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Model:   model,
+		Timeout: 120 * time.Second,
+		Tags:    tags,
+		Client:  &http.Client{},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Name возвращает имя провайдера
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Describe просит модель описать место по его тегам
+func (p *OpenAIProvider) Describe(ctx context.Context, place map[string]string) (string, error) {
+	tags := p.Tags
+	if tags == nil {
+		tags = NewTagWhitelist()
+	}
+	lines := tagLines(place, tags)
+	if len(lines) == 0 {
+		return "", fmt.Errorf("нет данных для описания места")
+	}
+
+	systemPrompt := place[PromptField]
+	if systemPrompt == "" {
+		systemPrompt = describeSystemPrompt
+		if interests := place[InterestsField]; interests != "" {
+			systemPrompt += " Слушателю интересно: " + interests + ". Сделай акцент на том, что связано с этими темами."
+		}
+		if language := place[LanguageField]; language != "" {
+			systemPrompt += " Отвечай на языке с кодом " + language + "."
+		}
+	}
+
+	return p.complete(ctx, []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: strings.Join(lines, "\n")},
+	})
+}
+
+// Ask задаёт модели произвольный вопрос
+func (p *OpenAIProvider) Ask(ctx context.Context, question string) (string, error) {
+	return p.complete(ctx, []chatMessage{{Role: "user", Content: question}})
+}
+
+// complete выполняет запрос к /chat/completions и возвращает текст первого ответа
+func (p *OpenAIProvider) complete(ctx context.Context, messages []chatMessage) (string, error) {
+	jsonBody, err := json.Marshal(chatRequest{Model: p.Model, Messages: messages})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при отправке запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при чтении тела ответа: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var response chatResponse
+		if json.Unmarshal(body, &response) == nil && response.Error != nil {
+			return "", fmt.Errorf("ошибка: статус ответа %d: %s", resp.StatusCode, response.Error.Message)
+		}
+		return "", fmt.Errorf("ошибка: статус ответа %d: %s", resp.StatusCode, truncateBody(body))
+	}
+
+	var response chatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("ошибка парсинга JSON: %v: %s", err, truncateBody(body))
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("пустой ответ от LLM")
+	}
+
+	return strings.TrimSpace(response.Choices[0].Message.Content), nil
+}
+
+// maxErrorBody — сколько байт тела ответа попадает в текст ошибки
+const maxErrorBody = 512
+
+// truncateBody возвращает начало тела ответа для сообщения об ошибке
+func truncateBody(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) <= maxErrorBody {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxErrorBody], "") + "…"
+}
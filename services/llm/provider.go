@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// InterestsField — поле данных места с интересами слушателя через запятую.
+// Провайдеры учитывают его в промпте, если оно задано
+const InterestsField = "interests"
+
+// PromptField — поле данных места с готовой инструкцией для модели из шаблона промпта.
+// Если оно задано, провайдер использует его вместо встроенной инструкции
+const PromptField = "prompt"
+
+// LanguageField — поле данных места с кодом языка, на котором нужен ответ (ru, en, pt-br)
+const LanguageField = "language"
+
+// Provider описывает источник текстов от языковой модели.
+// Запросы прерываются при отмене ctx
+type Provider interface {
+	// Name возвращает имя провайдера, под которым он зарегистрирован
+	Name() string
+	// Describe возвращает описание места по его тегам
+	Describe(ctx context.Context, place map[string]string) (string, error)
+	// Ask задаёт модели произвольный вопрос
+	Ask(ctx context.Context, question string) (string, error)
+}
+
+// Registry хранит провайдеры по имени
+type Registry struct {
+	mu          sync.RWMutex
+	providers   map[string]Provider
+	defaultName string
+}
+
+// NewRegistry создает пустой реестр провайдеров
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register добавляет провайдер в реестр. Первый зарегистрированный провайдер становится провайдером по умолчанию
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[p.Name()] = p
+	if r.defaultName == "" {
+		r.defaultName = p.Name()
+	}
+}
+
+// SetDefault задает провайдер по умолчанию
+func (r *Registry) SetDefault(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("неизвестный LLM-провайдер: %s", name)
+	}
+	r.defaultName = name
+	return nil
+}
+
+// Get возвращает провайдер по имени. Пустое имя означает провайдер по умолчанию
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный LLM-провайдер: %s", name)
+	}
+	return p, nil
+}
+
+// Default возвращает провайдер по умолчанию
+func (r *Registry) Default() (Provider, error) {
+	return r.Get("")
+}
+
+// Names возвращает отсортированный список зарегистрированных провайдеров
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRegistryFromEnv собирает реестр из переменных окружения:
+// HOST_LLM — FastAPI-сервис, HOST_MISTRAL — Mistral,
+// OPENAI_BASE_URL, OPENAI_API_KEY, OPENAI_MODEL — OpenAI-совместимый endpoint,
+// LLM_PROVIDER — имя провайдера по умолчанию,
+// LLM_EXTRA_TAGS — дополнительные теги места через запятую (wikipedia,name:*)
+func NewRegistryFromEnv() (*Registry, error) {
+	r := NewRegistry()
+	tags := ParseTagWhitelist(os.Getenv("LLM_EXTRA_TAGS"))
+
+	r.Register(NewFastAPIProvider(os.Getenv("HOST_LLM"), tags))
+	r.Register(NewMistralProvider(os.Getenv("HOST_MISTRAL"), tags))
+
+	if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+		r.Register(NewOpenAIProvider(baseURL, os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_MODEL"), tags))
+	}
+
+	if name := strings.TrimSpace(os.Getenv("LLM_PROVIDER")); name != "" {
+		if err := r.SetDefault(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// checkResponseError проверяет текст ответа на наличие ошибок
+func checkResponseError(responseText string, source string) error {
+	if strings.Contains(responseText, "ОШИБКА") {
+		return fmt.Errorf("ошибка от %s: %s", source, responseText)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// placeFields — теги места, которые всегда отправляются во внешний сервис
+var placeFields = []string{
+	"addr:city",
+	"addr:street",
+	"addr:housenumber",
+	"name",
+	"amenity",
+	"tourism",
+	"highway",
+	"leisure",
+	"building",
+	"inscription",
+	"description",
+}
+
+// TagWhitelist — теги места, которые разрешено передавать в LLM.
+// Помимо базовых полей можно разрешить точные ключи (wikipedia) и префиксы (name:*)
+type TagWhitelist struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// NewTagWhitelist создает белый список из базовых полей и дополнительных тегов extra
+func NewTagWhitelist(extra ...string) *TagWhitelist {
+	w := &TagWhitelist{exact: make(map[string]bool, len(placeFields)+len(extra))}
+	for _, key := range placeFields {
+		w.exact[key] = true
+	}
+	for _, key := range extra {
+		key = strings.TrimSpace(key)
+		switch {
+		case key == "":
+		case strings.HasSuffix(key, "*"):
+			w.prefixes = append(w.prefixes, strings.TrimSuffix(key, "*"))
+		default:
+			w.exact[key] = true
+		}
+	}
+	return w
+}
+
+// ParseTagWhitelist разбирает список дополнительных тегов через запятую (LLM_EXTRA_TAGS)
+func ParseTagWhitelist(spec string) *TagWhitelist {
+	return NewTagWhitelist(strings.Split(spec, ",")...)
+}
+
+// Allowed проверяет, можно ли передать тег key
+func (w *TagWhitelist) Allowed(key string) bool {
+	if w.exact[key] {
+		return true
+	}
+	for _, prefix := range w.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter возвращает непустые разрешенные теги места
+func (w *TagWhitelist) Filter(place map[string]string) map[string]string {
+	tags := make(map[string]string)
+	for key, value := range place {
+		if value != "" && w.Allowed(key) {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// DescribeRequest — тело запроса на описание места для сервисов с протоколом {"message": "..."}.
+// Базовые поля передаются под именами тегов OSM, дополнительные теги из белого списка — на верхнем уровне рядом с ними
+type DescribeRequest struct {
+	City        string `json:"addr:city,omitempty"`
+	Street      string `json:"addr:street,omitempty"`
+	HouseNumber string `json:"addr:housenumber,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Amenity     string `json:"amenity,omitempty"`
+	Tourism     string `json:"tourism,omitempty"`
+	Highway     string `json:"highway,omitempty"`
+	Leisure     string `json:"leisure,omitempty"`
+	Building    string `json:"building,omitempty"`
+	Inscription string `json:"inscription,omitempty"`
+	Description string `json:"description,omitempty"`
+	Lat         string `json:"lat,omitempty"`
+	Lon         string `json:"lon,omitempty"`
+	Interests   string `json:"interests,omitempty"` // Интересы слушателя через запятую
+	Prompt      string `json:"prompt,omitempty"`    // Инструкция из шаблона промпта
+	Language    string `json:"language,omitempty"`  // Язык ответа
+
+	Extra map[string]string `json:"-"` // Дополнительные теги из белого списка
+}
+
+// NewDescribeRequest собирает тело запроса из данных места.
+// withCoordinates добавляет координаты lat/lon
+func NewDescribeRequest(place map[string]string, whitelist *TagWhitelist, withCoordinates bool) DescribeRequest {
+	tags := whitelist.Filter(place)
+	req := DescribeRequest{
+		City:        tags["addr:city"],
+		Street:      tags["addr:street"],
+		HouseNumber: tags["addr:housenumber"],
+		Name:        tags["name"],
+		Amenity:     tags["amenity"],
+		Tourism:     tags["tourism"],
+		Highway:     tags["highway"],
+		Leisure:     tags["leisure"],
+		Building:    tags["building"],
+		Inscription: tags["inscription"],
+		Description: tags["description"],
+		Interests:   place[InterestsField],
+		Prompt:      place[PromptField],
+		Language:    place[LanguageField],
+	}
+	if withCoordinates {
+		req.Lat, req.Lon = place["lat"], place["lon"]
+	}
+
+	for _, key := range placeFields {
+		delete(tags, key)
+	}
+	// Служебные поля не могут прийти как дополнительные теги
+	for _, key := range []string{"lat", "lon", InterestsField, PromptField, LanguageField} {
+		delete(tags, key)
+	}
+	if len(tags) > 0 {
+		req.Extra = tags
+	}
+	return req
+}
+
+// MarshalJSON кодирует запрос одним объектом: базовые поля и дополнительные теги
+func (r DescribeRequest) MarshalJSON() ([]byte, error) {
+	type plain DescribeRequest
+	if len(r.Extra) == 0 {
+		return json.Marshal(plain(r))
+	}
+
+	base, err := json.Marshal(plain(r))
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(base, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range r.Extra {
+		if _, exists := fields[key]; exists {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = encoded
+	}
+	return json.Marshal(fields)
+}
+
+// Body кодирует запрос в JSON
+func (r DescribeRequest) Body() ([]byte, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+	}
+	return body, nil
+}
+
+// tagLines выводит разрешенные теги строками "ключ: значение": сначала базовые поля, затем остальные по алфавиту
+func tagLines(place map[string]string, whitelist *TagWhitelist) []string {
+	tags := whitelist.Filter(place)
+	var lines []string
+	for _, key := range placeFields {
+		if value, ok := tags[key]; ok {
+			lines = append(lines, key+": "+value)
+			delete(tags, key)
+		}
+	}
+	delete(tags, InterestsField)
+	delete(tags, PromptField)
+	delete(tags, LanguageField)
+
+	extra := make([]string, 0, len(tags))
+	for key := range tags {
+		extra = append(extra, key)
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		lines = append(lines, key+": "+tags[key])
+	}
+	return lines
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"new/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidLanguage — код языка не похож на BCP 47 (ru, en, pt-br)
+var ErrInvalidLanguage = errors.New("некорректный код языка")
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// normalizeLanguage приводит код языка к нижнему регистру и проверяет формат
+func normalizeLanguage(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(lang, "_", "-")))
+	if !languagePattern.MatchString(lang) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLanguage, lang)
+	}
+	return lang, nil
+}
+
+// languageCandidates — язык и его основной вариант: pt-br -> [pt-br, pt]
+func languageCandidates(lang string) []string {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	candidates := []string{lang}
+	if i := strings.IndexByte(lang, '-'); i > 0 {
+		candidates = append(candidates, lang[:i])
+	}
+	return candidates
+}
+
+// RequestLanguage выбирает язык HTTP-запроса: из параметра lang, иначе из Accept-Language.
+// Ошибка возвращается, только если lang задан явно и некорректен. Из Accept-Language берется
+// первый по весу тег, который удается разобрать, при необходимости без последних подтегов:
+// zh-Hant-TW → zh-hant. Пустой результат означает язык по умолчанию или язык из профиля
+func RequestLanguage(lang, acceptLanguage string) (string, error) {
+	if strings.TrimSpace(lang) != "" {
+		return normalizeLanguage(lang)
+	}
+	for _, tag := range acceptedLanguages(acceptLanguage) {
+		for tag != "" {
+			if normalized, err := normalizeLanguage(tag); err == nil {
+				return normalized, nil
+			}
+			i := strings.LastIndexAny(tag, "-_")
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	return "", nil
+}
+
+// acceptedLanguages возвращает теги Accept-Language по убыванию веса q, без * и тегов с q=0
+func acceptedLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// localizedTag выбирает значение тега key на языке lang: name:pt-br → name:pt → name
+func localizedTag(tags map[string]string, key, lang string) string {
+	if lang != "" {
+		for _, candidate := range languageCandidates(lang) {
+			if value := tags[key+":"+candidate]; value != "" {
+				return value
+			}
+		}
+	}
+	return tags[key]
+}
+
+// ResolveLanguage приводит язык запроса к коду BCP 47. Пустой язык означает язык по умолчанию
+func ResolveLanguage(lang string) (string, error) {
+	if strings.TrimSpace(lang) == "" {
+		return DefaultLanguage, nil
+	}
+	return normalizeLanguage(lang)
+}
+
+// userLanguage возвращает язык из профиля пользователя или язык по умолчанию
+func (s *PlaceService) userLanguage(ctx context.Context, userID uint) string {
+	if s.DB == nil || userID == 0 {
+		return DefaultLanguage
+	}
+	var user models.User
+	if err := s.DB.WithContext(ctx).Select("locale").First(&user, userID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Printf("Ошибка при загрузке языка пользователя: %v\n", err)
+		}
+		return DefaultLanguage
+	}
+	if user.Locale == "" {
+		return DefaultLanguage
+	}
+	return user.Locale
+}
+
+// requestLanguage выбирает язык описаний: из запроса, иначе из профиля пользователя
+func (s *PlaceService) requestLanguage(ctx context.Context, userID uint, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return s.userLanguage(ctx, userID)
+}
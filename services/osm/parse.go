@@ -0,0 +1,550 @@
+// Package osm разбирает входные данные с объектами OpenStreetMap: собственный формат {"json_data": [...]},
+// Overpass JSON ({"elements": [...]}) и OSM XML, и проверяет каждый элемент
+package osm
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+
+	"new/dto"
+)
+
+// Поддерживаемые форматы входных данных
+const (
+	FormatWrapped  = "json_data" // {"json_data": [...]} — собственный формат API
+	FormatOverpass = "overpass"  // {"elements": [...]} — Overpass JSON
+	FormatArray    = "array"     // [...] — массив объектов без обертки
+	FormatXML      = "xml"       // <osm>...</osm> — OSM XML и Overpass XML
+)
+
+// DefaultMaxInputBytes — наибольший размер входных данных OSM по умолчанию, 32 МБ
+const DefaultMaxInputBytes = 32 << 20
+
+// MaxInputBytes — наибольший размер входных данных OSM в одном запросе или сообщении WebSocket (OSM_MAX_BODY_BYTES)
+func MaxInputBytes() int64 {
+	value, err := strconv.ParseInt(os.Getenv("OSM_MAX_BODY_BYTES"), 10, 64)
+	if err != nil || value <= 0 {
+		return DefaultMaxInputBytes
+	}
+	return value
+}
+
+// ErrUnsupportedFormat — тип содержимого или структура документа не распознаны
+var ErrUnsupportedFormat = errors.New("unsupported input format")
+
+// ElementError — ошибка в конкретном элементе входных данных
+type ElementError struct {
+	Index   int    `json:"index"`          // Позиция элемента во входном массиве
+	Type    string `json:"type,omitempty"` // Тип элемента, если удалось прочитать
+	ID      int64  `json:"id,omitempty"`   // Идентификатор элемента, если удалось прочитать
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error описывает ошибку с указанием элемента
+func (e ElementError) Error() string {
+	where := fmt.Sprintf("element %d", e.Index)
+	if e.Type != "" && e.ID != 0 {
+		where += fmt.Sprintf(" (%s/%d)", e.Type, e.ID)
+	}
+	if e.Field != "" {
+		where += ", " + e.Field
+	}
+	return where + ": " + e.Message
+}
+
+// ValidationError — список ошибок по элементам
+type ValidationError struct {
+	Elements []ElementError
+}
+
+// Error выводит первую ошибку и количество остальных
+func (e *ValidationError) Error() string {
+	if len(e.Elements) == 1 {
+		return "invalid osm input: " + e.Elements[0].Error()
+	}
+	return fmt.Sprintf("invalid osm input: %s (and %d more)", e.Elements[0].Error(), len(e.Elements)-1)
+}
+
+// maxElementErrors — сколько ошибок элементов возвращается клиенту
+const maxElementErrors = 50
+
+// errorList собирает ошибки элементов
+type errorList struct {
+	items []ElementError
+}
+
+func (l *errorList) add(e ElementError) {
+	if len(l.items) < maxElementErrors {
+		l.items = append(l.items, e)
+	}
+}
+
+func (l *errorList) err() error {
+	if len(l.items) == 0 {
+		return nil
+	}
+	return &ValidationError{Elements: l.items}
+}
+
+// DetectFormat определяет формат по Content-Type, а для неуказанного или общего типа — по содержимому
+func DetectFormat(contentType string, body []byte) (string, error) {
+	mediaType := ""
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+		mediaType = parsed
+	}
+
+	switch {
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return FormatXML, nil
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return detectJSON(body)
+	case mediaType == "", mediaType == "text/plain", mediaType == "application/octet-stream":
+		trimmed := bytes.TrimLeft(body, " \t\r\n\ufeff")
+		if len(trimmed) > 0 && trimmed[0] == '<' {
+			return FormatXML, nil
+		}
+		return detectJSON(body)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, mediaType)
+	}
+}
+
+// detectJSON различает обертку json_data, Overpass JSON и массив объектов
+func detectJSON(body []byte) (string, error) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return FormatArray, nil
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return "", jsonError(err)
+	}
+	if _, ok := probe["elements"]; ok {
+		return FormatOverpass, nil
+	}
+	if _, ok := probe["json_data"]; ok {
+		return FormatWrapped, nil
+	}
+	return "", fmt.Errorf("%w: expected \"elements\" or \"json_data\"", ErrUnsupportedFormat)
+}
+
+// Decode разбирает входные данные в формате, определенном по Content-Type или содержимому
+func Decode(contentType string, body []byte) ([]dto.OSMObject, error) {
+	format, err := DetectFormat(contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatXML:
+		return ParseXML(bytes.NewReader(body))
+	case FormatOverpass:
+		return parseJSONDocument(body, "elements")
+	case FormatWrapped:
+		return parseJSONDocument(body, "json_data")
+	default:
+		return ParseJSONElements(body)
+	}
+}
+
+// ParseOverpassJSON разбирает ответ Overpass API в формате JSON
+func ParseOverpassJSON(r io.Reader) ([]dto.OSMObject, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONDocument(body, "elements")
+}
+
+// parseJSONDocument читает массив элементов из поля key объекта верхнего уровня
+func parseJSONDocument(body []byte, key string) ([]dto.OSMObject, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, jsonError(err)
+	}
+	elements, ok := doc[key]
+	if !ok || string(elements) == "null" {
+		return nil, fmt.Errorf("%w: field %q is required", ErrUnsupportedFormat, key)
+	}
+	return ParseJSONElements(elements)
+}
+
+// ParseJSONElements разбирает JSON-массив элементов. Каждый элемент разбирается отдельно,
+// чтобы ошибка указывала на конкретный элемент
+func ParseJSONElements(body []byte) ([]dto.OSMObject, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, jsonError(err)
+	}
+
+	objects := make([]dto.OSMObject, 0, len(raw))
+	var errs errorList
+	for i, data := range raw {
+		var el jsonElement
+		if err := json.Unmarshal(data, &el); err != nil {
+			e := ElementError{Index: i, Message: jsonError(err).Error()}
+			// Тип и идентификатор помогают найти элемент, даже если в нем ошибка
+			var head struct {
+				Type string
+				ID   int64
+			}
+			if json.Unmarshal(data, &head) == nil {
+				e.Type, e.ID = head.Type, head.ID
+			}
+			if typeErr := (*json.UnmarshalTypeError)(nil); errors.As(err, &typeErr) {
+				e.Field = typeErr.Field
+			}
+			errs.add(e)
+			continue
+		}
+		obj, elementErrs := el.object(i)
+		for _, e := range elementErrs {
+			errs.add(e)
+		}
+		if len(elementErrs) == 0 {
+			objects = append(objects, obj)
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// jsonError добавляет к ошибке синтаксиса позицию в документе
+func jsonError(err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid JSON at offset %d: %v", syntaxErr.Offset, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "value"
+		}
+		return fmt.Errorf("%s must be %s, got %s", field, typeErr.Type, typeErr.Value)
+	}
+	return err
+}
+
+// point — координаты, у которых важно отличать отсутствие от нуля
+type point struct {
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// jsonMember — участник relation в Overpass JSON
+type jsonMember struct {
+	Type     string   `json:"type"`
+	Ref      int64    `json:"ref"`
+	Role     string   `json:"role"`
+	Lat      *float64 `json:"lat"`
+	Lon      *float64 `json:"lon"`
+	Geometry []*point `json:"geometry"`
+}
+
+// jsonElement — элемент Overpass JSON (и собственного формата, поля которого совпадают)
+type jsonElement struct {
+	Type     string            `json:"type"`
+	ID       int64             `json:"id"`
+	Lat      *float64          `json:"lat"`
+	Lon      *float64          `json:"lon"`
+	Tags     map[string]string `json:"tags"`
+	Nodes    []int64           `json:"nodes"`
+	Members  []jsonMember      `json:"members"`
+	Center   *point            `json:"center"`
+	Geometry []*point          `json:"geometry"`
+}
+
+// object проверяет элемент и переводит его во внутренний формат
+func (el jsonElement) object(index int) (dto.OSMObject, []ElementError) {
+	v := validator{index: index, typ: strings.ToLower(el.Type), id: el.ID}
+	v.checkHeader()
+
+	obj := dto.OSMObject{ID: el.ID, Type: v.typ, Tags: el.Tags, Nodes: el.Nodes}
+	if el.Lat != nil || el.Lon != nil {
+		if c, ok := v.coordinates("lat/lon", el.Lat, el.Lon); ok {
+			obj.Lat, obj.Lon = c.Lat, c.Lon
+		}
+	}
+	if el.Center != nil {
+		if c, ok := v.coordinates("center", el.Center.Lat, el.Center.Lon); ok {
+			obj.Center = &c
+		}
+	}
+	for i, p := range el.Geometry {
+		// Overpass пишет null вместо координат узлов, которых нет в выборке
+		if p == nil {
+			continue
+		}
+		if c, ok := v.coordinates(fmt.Sprintf("geometry[%d]", i), p.Lat, p.Lon); ok {
+			obj.Geometry = append(obj.Geometry, c)
+		}
+	}
+	for i, ref := range el.Nodes {
+		if ref <= 0 {
+			v.fail(fmt.Sprintf("nodes[%d]", i), "node reference must be positive")
+		}
+	}
+	for i, m := range el.Members {
+		member := dto.OSMMember{Type: strings.ToLower(m.Type), Ref: m.Ref, Role: m.Role}
+		field := fmt.Sprintf("members[%d]", i)
+		v.checkMember(field, member)
+		if m.Lat != nil || m.Lon != nil {
+			if c, ok := v.coordinates(field+".lat/lon", m.Lat, m.Lon); ok {
+				member.Lat, member.Lon = c.Lat, c.Lon
+			}
+		}
+		for j, p := range m.Geometry {
+			if p == nil {
+				continue
+			}
+			if c, ok := v.coordinates(fmt.Sprintf("%s.geometry[%d]", field, j), p.Lat, p.Lon); ok {
+				member.Geometry = append(member.Geometry, c)
+			}
+		}
+		obj.Members = append(obj.Members, member)
+	}
+	v.checkKind(obj)
+	return obj, v.errs
+}
+
+// validator собирает ошибки одного элемента
+type validator struct {
+	index int
+	typ   string
+	id    int64
+	errs  []ElementError
+}
+
+func (v *validator) fail(field, message string) {
+	v.errs = append(v.errs, ElementError{Index: v.index, Type: v.typ, ID: v.id, Field: field, Message: message})
+}
+
+// checkHeader проверяет тип и идентификатор элемента
+func (v *validator) checkHeader() {
+	switch v.typ {
+	case "node", "way", "relation":
+	case "":
+		v.fail("type", "type is required")
+	default:
+		v.fail("type", fmt.Sprintf("unsupported element type %q, expected node, way or relation", v.typ))
+	}
+	if v.id <= 0 {
+		v.fail("id", "id must be a positive integer")
+	}
+}
+
+// checkKind проверяет поля, допустимые только для определенного типа
+func (v *validator) checkKind(obj dto.OSMObject) {
+	if obj.Type != "way" && len(obj.Nodes) > 0 {
+		v.fail("nodes", "only ways can have nodes")
+	}
+	if obj.Type != "relation" && len(obj.Members) > 0 {
+		v.fail("members", "only relations can have members")
+	}
+}
+
+// checkMember проверяет участника relation
+func (v *validator) checkMember(field string, m dto.OSMMember) {
+	switch m.Type {
+	case "node", "way", "relation":
+	default:
+		v.fail(field+".type", fmt.Sprintf("unsupported member type %q", m.Type))
+	}
+	if m.Ref <= 0 {
+		v.fail(field+".ref", "member reference must be positive")
+	}
+}
+
+// coordinates проверяет, что заданы обе координаты и они в допустимых пределах
+func (v *validator) coordinates(field string, lat, lon *float64) (dto.Coordinates, bool) {
+	if lat == nil || lon == nil {
+		v.fail(field, "both lat and lon are required")
+		return dto.Coordinates{}, false
+	}
+	if *lat < -90 || *lat > 90 {
+		v.fail(field, fmt.Sprintf("latitude %v is out of range [-90, 90]", *lat))
+		return dto.Coordinates{}, false
+	}
+	if *lon < -180 || *lon > 180 {
+		v.fail(field, fmt.Sprintf("longitude %v is out of range [-180, 180]", *lon))
+		return dto.Coordinates{}, false
+	}
+	return dto.Coordinates{Lat: *lat, Lon: *lon}, true
+}
+
+// xmlTag — тег <tag k="..." v="..."/>
+type xmlTag struct {
+	Key   string `xml:"k,attr"`
+	Value string `xml:"v,attr"`
+}
+
+// xmlPoint — координаты в атрибутах lat/lon (<center>, <nd> и <member> в out geom)
+type xmlPoint struct {
+	Lat string `xml:"lat,attr"`
+	Lon string `xml:"lon,attr"`
+}
+
+type xmlNd struct {
+	Ref string `xml:"ref,attr"`
+	xmlPoint
+}
+
+type xmlMember struct {
+	Type string `xml:"type,attr"`
+	Ref  string `xml:"ref,attr"`
+	Role string `xml:"role,attr"`
+	xmlPoint
+	Nds []xmlPoint `xml:"nd"`
+}
+
+// xmlElement — элемент <node>, <way> или <relation>
+type xmlElement struct {
+	XMLName xml.Name
+	ID      string `xml:"id,attr"`
+	xmlPoint
+	Tags    []xmlTag    `xml:"tag"`
+	Nds     []xmlNd     `xml:"nd"`
+	Members []xmlMember `xml:"member"`
+	Center  *xmlPoint   `xml:"center"`
+}
+
+// xmlSkipped — служебные элементы документа, которые не являются объектами
+var xmlSkipped = map[string]bool{"note": true, "meta": true, "bounds": true, "remark": true}
+
+// ParseXML разбирает OSM XML (<osm><node/><way/><relation/></osm>), в том числе вывод Overpass с center и geom
+func ParseXML(r io.Reader) ([]dto.OSMObject, error) {
+	decoder := xml.NewDecoder(r)
+
+	// Находим корневой элемент <osm>
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: root element <osm> not found", ErrUnsupportedFormat)
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid XML at offset %d: %v", decoder.InputOffset(), err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "osm" {
+				return nil, fmt.Errorf("%w: root element must be <osm>, got <%s>", ErrUnsupportedFormat, start.Name.Local)
+			}
+			break
+		}
+	}
+
+	var objects []dto.OSMObject
+	var errs errorList
+	index := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("invalid XML: unexpected end of document, </osm> expected")
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid XML at offset %d: %v", decoder.InputOffset(), err)
+		}
+		if _, ok := token.(xml.EndElement); ok {
+			break // </osm>
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if xmlSkipped[start.Name.Local] {
+			if err := decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("invalid XML at offset %d: %v", decoder.InputOffset(), err)
+			}
+			continue
+		}
+
+		var el xmlElement
+		if err := decoder.DecodeElement(&el, &start); err != nil {
+			return nil, fmt.Errorf("invalid XML in element %d at offset %d: %v", index, decoder.InputOffset(), err)
+		}
+		obj, elementErrs := el.object(index)
+		for _, e := range elementErrs {
+			errs.add(e)
+		}
+		if len(elementErrs) == 0 {
+			objects = append(objects, obj)
+		}
+		index++
+	}
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// object переводит элемент XML в элемент JSON, чтобы проверки были общими
+func (el xmlElement) object(index int) (dto.OSMObject, []ElementError) {
+	v := validator{index: index, typ: el.XMLName.Local}
+	j := jsonElement{Type: el.XMLName.Local}
+
+	j.ID = v.integer("id", el.ID)
+	v.id = j.ID
+	j.Lat, j.Lon = v.float("lat", el.Lat), v.float("lon", el.Lon)
+	if len(el.Tags) > 0 {
+		j.Tags = make(map[string]string, len(el.Tags))
+		for _, tag := range el.Tags {
+			j.Tags[tag.Key] = tag.Value
+		}
+	}
+	if el.Center != nil {
+		j.Center = &point{Lat: v.float("center.lat", el.Center.Lat), Lon: v.float("center.lon", el.Center.Lon)}
+	}
+	for i, nd := range el.Nds {
+		j.Nodes = append(j.Nodes, v.integer(fmt.Sprintf("nd[%d].ref", i), nd.Ref))
+		if nd.Lat != "" || nd.Lon != "" {
+			j.Geometry = append(j.Geometry, &point{Lat: v.float("nd.lat", nd.Lat), Lon: v.float("nd.lon", nd.Lon)})
+		}
+	}
+	for i, m := range el.Members {
+		member := jsonMember{Type: m.Type, Ref: v.integer(fmt.Sprintf("member[%d].ref", i), m.Ref), Role: m.Role}
+		member.Lat, member.Lon = v.float("member.lat", m.Lat), v.float("member.lon", m.Lon)
+		for _, nd := range m.Nds {
+			member.Geometry = append(member.Geometry, &point{Lat: v.float("member.nd.lat", nd.Lat), Lon: v.float("member.nd.lon", nd.Lon)})
+		}
+		j.Members = append(j.Members, member)
+	}
+	if len(v.errs) > 0 {
+		return dto.OSMObject{}, v.errs
+	}
+	return j.object(index)
+}
+
+// integer разбирает целочисленный атрибут
+func (v *validator) integer(field, value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		v.fail(field, fmt.Sprintf("expected integer, got %q", value))
+	}
+	return n
+}
+
+// float разбирает атрибут с координатой; пустой атрибут означает отсутствие координаты
+func (v *validator) float(field, value string) *float64 {
+	if value == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		v.fail(field, fmt.Sprintf("expected number, got %q", value))
+		return nil
+	}
+	return &f
+}
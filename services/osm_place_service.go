@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"new/dto"
+	"new/models"
+	"new/services/geo"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ограничения поиска мест рядом с точкой
+const (
+	DefaultNearbyRadius = 500.0  // Метры
+	MaxNearbyRadius     = 5000.0 // Метры
+	DefaultNearbyLimit  = 50
+	MaxNearbyLimit      = 200
+)
+
+// ErrInvalidNearbyQuery возвращается при некорректных координатах, радиусе или лимите поиска
+var ErrInvalidNearbyQuery = errors.New("некорректный запрос мест рядом")
+
+// ErrPlaceTagsMismatch возвращается, если описание получено по тегам, отличным от сохраненных для места
+var ErrPlaceTagsMismatch = errors.New("теги места отличаются от сохраненных, описание не сохранено")
+
+// OSMPlaceService хранит обработанные места с координатами и описаниями, общими для всех пользователей,
+// и ищет их рядом с точкой по геохешу
+type OSMPlaceService struct {
+	DB    *gorm.DB
+	Audio *AudioCacheService
+}
+
+// ResolveNearbyQuery проверяет параметры поиска и подставляет значения по умолчанию
+func ResolveNearbyQuery(q dto.NearbyQuery) (dto.NearbyQuery, error) {
+	// Сравнения записаны так, чтобы NaN тоже считался ошибкой
+	if !(q.Lat >= -90 && q.Lat <= 90 && q.Lon >= -180 && q.Lon <= 180) {
+		return q, fmt.Errorf("%w: координаты вне допустимого диапазона", ErrInvalidNearbyQuery)
+	}
+	switch {
+	case q.Radius == 0:
+		q.Radius = DefaultNearbyRadius
+	case !(q.Radius > 0 && q.Radius <= MaxNearbyRadius):
+		return q, fmt.Errorf("%w: радиус должен быть от 0 до %.0f метров", ErrInvalidNearbyQuery, MaxNearbyRadius)
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultNearbyLimit
+	case q.Limit < 1 || q.Limit > MaxNearbyLimit:
+		return q, fmt.Errorf("%w: лимит должен быть от 1 до %d", ErrInvalidNearbyQuery, MaxNearbyLimit)
+	}
+	language, err := ResolveLanguage(q.Language)
+	if err != nil {
+		return q, err
+	}
+	q.Language = language
+	return q, nil
+}
+
+// Remember сохраняет место из результата обработки вместе с тегами OSM. Место добавляется один раз:
+// данные следующих запросов не заменяют сохраненную запись. Если передано описание, оно сохраняется
+// для языка и провайдера описания, заменяя прежнее, но только когда получено по сохраненным тегам
+func (s *OSMPlaceService) Remember(ctx context.Context, result dto.PlaceResult, tags map[string]string, description *models.OSMPlaceDescription) error {
+	if result.Coordinates == nil || result.OSMType == "" {
+		return fmt.Errorf("у места нет объекта OSM или координат")
+	}
+	osmID, err := strconv.ParseInt(result.PlaceID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("некорректный идентификатор места %q: %v", result.PlaceID, err)
+	}
+
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации тегов: %v", err)
+	}
+
+	point := geo.Point{Lat: result.Coordinates.Lat, Lon: result.Coordinates.Lon}
+	place := models.OSMPlace{
+		OSMType:   result.OSMType,
+		OSMID:     osmID,
+		Name:      result.PlaceName,
+		Address:   result.Address,
+		City:      result.City,
+		Lat:       point.Lat,
+		Lon:       point.Lon,
+		Geohash:   geo.Geohash(point, geo.GeohashPrecision),
+		Tags:      string(tagsJSON),
+		UpdatedAt: time.Now(),
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "osm_type"}, {Name: "osm_id"}},
+			DoNothing: true,
+		}).Create(&place).Error; err != nil {
+			return fmt.Errorf("ошибка сохранения места: %v", err)
+		}
+		if description == nil {
+			return nil
+		}
+
+		var stored models.OSMPlace
+		if err := tx.Where("osm_type = ? AND osm_id = ?", place.OSMType, place.OSMID).First(&stored).Error; err != nil {
+			return fmt.Errorf("ошибка загрузки места: %v", err)
+		}
+		// Описание по другим тегам могло быть получено из подмененных данных, его не показываем другим
+		if stored.Tags != place.Tags {
+			return fmt.Errorf("%w: %s/%d", ErrPlaceTagsMismatch, place.OSMType, place.OSMID)
+		}
+
+		description.PlaceID = stored.ID
+		description.UpdatedAt = time.Now()
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "place_id"}, {Name: "language"}, {Name: "provider"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "prompt_version", "text", "audio_hash", "updated_at"}),
+		}).Create(description).Error; err != nil {
+			return fmt.Errorf("ошибка сохранения описания места: %v", err)
+		}
+		return nil
+	})
+}
+
+// Nearby возвращает описанные места в радиусе от точки, ближайшие первыми.
+// Кандидаты выбираются по префиксам геохеша, затем отсекаются по точному расстоянию
+func (s *OSMPlaceService) Nearby(ctx context.Context, q dto.NearbyQuery) ([]dto.NearbyPlace, error) {
+	q, err := ResolveNearbyQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	center := geo.Point{Lat: q.Lat, Lon: q.Lon}
+
+	prefixes := geo.CoverPrefixes(center, q.Radius)
+	conditions := make([]string, len(prefixes))
+	args := make([]interface{}, len(prefixes))
+	for i, prefix := range prefixes {
+		conditions[i] = "geohash LIKE ?"
+		args[i] = prefix + "%"
+	}
+
+	described := s.DB.WithContext(ctx).Table("osm_place_descriptions").Select("1").
+		Where("osm_place_descriptions.place_id = osm_places.id AND osm_place_descriptions.language = ?", q.Language)
+	if q.Provider != "" {
+		described = described.Where("osm_place_descriptions.provider = ?", q.Provider)
+	}
+
+	var places []models.OSMPlace
+	err = s.DB.WithContext(ctx).Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Where("EXISTS (?)", described).
+		Preload("Descriptions", func(db *gorm.DB) *gorm.DB {
+			db = db.Where("language = ?", q.Language)
+			if q.Provider != "" {
+				db = db.Where("provider = ?", q.Provider)
+			}
+			return db.Order("updated_at DESC")
+		}).
+		Find(&places).Error
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска мест: %v", err)
+	}
+
+	results := make([]dto.NearbyPlace, 0, len(places))
+	audioHashes := make(map[string]string) // тип/ID объекта -> хеш аудио
+	for _, place := range places {
+		distance := geo.Distance(center, geo.Point{Lat: place.Lat, Lon: place.Lon})
+		if distance > q.Radius || len(place.Descriptions) == 0 {
+			continue
+		}
+		// Описания отсортированы по времени обновления: берем самое свежее
+		description := place.Descriptions[0]
+		name := description.Name
+		if name == "" {
+			name = place.Name
+		}
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(place.Tags), &tags); err != nil {
+			fmt.Printf("Некорректные теги места %d: %v\n", place.ID, err)
+		}
+		placeID := place.OSMType + "/" + strconv.FormatInt(place.OSMID, 10)
+		if description.AudioHash != "" {
+			audioHashes[placeID] = description.AudioHash
+		}
+		results = append(results, dto.NearbyPlace{
+			PlaceID:     strconv.FormatInt(place.OSMID, 10),
+			OSMType:     place.OSMType,
+			PlaceName:   name,
+			Address:     place.Address,
+			City:        place.City,
+			Coordinates: dto.Coordinates{Lat: place.Lat, Lon: place.Lon},
+			Tags:        tags,
+			Distance:    distance,
+			Language:    description.Language,
+			Provider:    description.Provider,
+			Response:    description.Text,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Distance < results[j].Distance })
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	if err := s.attachAudio(ctx, results, audioHashes); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// attachAudio добавляет к местам ссылки на аудио, которые еще есть в кеше аудио, одним запросом
+func (s *OSMPlaceService) attachAudio(ctx context.Context, results []dto.NearbyPlace, audioHashes map[string]string) error {
+	if s.Audio == nil || len(audioHashes) == 0 {
+		return nil
+	}
+	hashes := make([]string, 0, len(results))
+	for _, r := range results {
+		if hash := audioHashes[r.OSMType+"/"+r.PlaceID]; hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	var assets []models.AudioAsset
+	if err := s.DB.WithContext(ctx).Where("hash IN ?", hashes).Find(&assets).Error; err != nil {
+		return fmt.Errorf("ошибка загрузки аудио: %v", err)
+	}
+	byHash := make(map[string]*models.AudioAsset, len(assets))
+	for i := range assets {
+		byHash[assets[i].Hash] = &assets[i]
+	}
+	for i := range results {
+		if asset := byHash[audioHashes[results[i].OSMType+"/"+results[i].PlaceID]]; asset != nil {
+			results[i].Audio = &dto.AudioRef{ID: asset.Hash, URL: s.Audio.URL(asset), ContentType: asset.ContentType}
+		}
+	}
+	return nil
+}
+
+// rememberPlace сохраняет обработанное место для поиска по соседству. Описание сохраняется только общее:
+// персональные описания (shared=false) зависят от интересов пользователя и другим не показываются
+func (s *PlaceService) rememberPlace(ctx context.Context, providerName string, in placeInput, place map[string]string, result dto.PlaceResult, shared bool) {
+	if s.OSMPlaces == nil || result.Coordinates == nil || result.OSMType == "" {
+		return
+	}
+
+	var description *models.OSMPlaceDescription
+	if shared {
+		key, err := s.descriptionKey(providerName, place)
+		if err != nil {
+			fmt.Printf("Ошибка при сохранении места: %v\n", err)
+			return
+		}
+		description = &models.OSMPlaceDescription{
+			Language:      key.Language,
+			Provider:      key.Provider,
+			Name:          result.PlaceName,
+			PromptVersion: key.PromptVersion,
+			Text:          result.Response,
+		}
+		if result.Audio != nil {
+			description.AudioHash = result.Audio.ID
+		}
+	}
+
+	if err := s.OSMPlaces.Remember(ctx, result, placeTags(in), description); err != nil {
+		fmt.Printf("Ошибка при сохранении места: %v\n", err)
+	}
+}
+
+// placeTags возвращает теги OSM места без служебных полей конвейера
+func placeTags(in placeInput) map[string]string {
+	if in.Object != nil {
+		return in.Object.Tags
+	}
+	tags := make(map[string]string, len(in.Data))
+	for k, v := range in.Data {
+		if !promptServiceKeys[k] && k != "lat" && k != "lon" {
+			tags[k] = v
+		}
+	}
+	return tags
+}
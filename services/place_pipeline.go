@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"new/dto"
+	"new/services/geo"
+)
+
+// Причина пропуска объекта без названия и адреса
+const skipReasonEmpty = "у объекта нет названия и адреса"
+
+// DescribeOptions — параметры описания мест, общие для всего запроса
+type DescribeOptions struct {
+	Provider string // Имя LLM-провайдера, пусто — провайдер по умолчанию
+	Audience string // Аудитория для выбора варианта шаблона промпта, пусто — по умолчанию
+	Language string // Язык описаний (ru, en, pt-br), пусто — язык по умолчанию
+}
+
+// apply дополняет данные места параметрами запроса. Язык, уже заданный в данных места, не меняется
+func (o DescribeOptions) apply(inputs []placeInput) {
+	for i := range inputs {
+		if o.Audience != "" {
+			inputs[i].Data["audience"] = o.Audience
+		}
+		if o.Language != "" && inputs[i].Data["language"] == "" {
+			inputs[i].Data["language"] = o.Language
+		}
+	}
+}
+
+// placeInput — место на пути через конвейер обработки. Хранит позицию и исходный объект OSM,
+// чтобы результат всегда сопоставлялся с тем объектом, из которого он получен
+type placeInput struct {
+	Position int               // Индекс объекта во входном массиве
+	Object   *dto.OSMObject    // Исходный объект, nil для мест, переданных без OSM-объекта
+	Data     map[string]string // Данные места для LLM (buildPlaceData)
+	Shape    *geo.Shape        // Геометрия объекта, nil если координаты неизвестны
+}
+
+// preparePlaces отделяет объекты, которые можно описать, от пропущенных.
+// Для пропущенных сразу формируется результат со статусом skipped и причиной.
+// Названия выбираются на языке language. Геометрия way и relation собирается
+// из узлов и участников того же массива
+func preparePlaces(osmObjects []dto.OSMObject, language string) ([]placeInput, []dto.PlaceResult) {
+	inputs := make([]placeInput, 0, len(osmObjects))
+	var skipped []dto.PlaceResult
+	index := geo.NewIndex(osmObjects)
+
+	for i := range osmObjects {
+		obj := &osmObjects[i]
+		shape := index.Resolve(*obj)
+		if isPlaceEmpty(obj.Tags) {
+			skipped = append(skipped, skippedResult(i, *obj, shape, skipReasonEmpty, language))
+			continue
+		}
+		data := buildPlaceData(*obj, language)
+		// У way и relation нет собственных координат — передаем точку на объекте
+		if shape != nil && data["lat"] == "" {
+			point := shape.RepresentativePoint()
+			data["lat"] = fmt.Sprintf("%f", point.Lat)
+			data["lon"] = fmt.Sprintf("%f", point.Lon)
+		}
+		inputs = append(inputs, placeInput{Position: i, Object: obj, Data: data, Shape: shape})
+	}
+
+	return inputs, skipped
+}
+
+// placeInputsFromData оборачивает готовые данные мест без исходных объектов OSM
+func placeInputsFromData(places []map[string]string) []placeInput {
+	inputs := make([]placeInput, len(places))
+	for i, place := range places {
+		inputs[i] = placeInput{Position: i, Data: place}
+	}
+	return inputs
+}
+
+// skippedResult формирует результат для пропущенного объекта
+func skippedResult(position int, obj dto.OSMObject, shape *geo.Shape, reason, language string) dto.PlaceResult {
+	result := dto.PlaceResult{Index: position}
+	applyPlaceOutput(&result, obj, shape, language)
+	result.Status = dto.PlaceStatusSkipped
+	result.Reason = reason
+	return result
+}
+
+// newInputResult создает результат для места с сохранением его позиции и исходного объекта
+func newInputResult(in placeInput) dto.PlaceResult {
+	result := newPlaceResult(in.Data)
+	result.Index = in.Position
+	if in.Object != nil {
+		applyPlaceOutput(&result, *in.Object, in.Shape, in.Data["language"])
+	}
+	return result
+}
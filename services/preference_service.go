@@ -1,12 +1,20 @@
 package services
 
 import (
+	"errors"
 	"new/dto"
 	"new/models"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrCatalogEntryNotFound — элемента каталога нет или он выведен из оборота
+	ErrCatalogEntryNotFound = errors.New("элемент каталога не найден")
+	// ErrPreferenceExists — пользователь уже выбрал этот элемент каталога
+	ErrPreferenceExists = errors.New("этот элемент каталога уже выбран")
+)
+
 type PreferenceService struct {
 	DB *gorm.DB
 }
@@ -17,25 +25,54 @@ func NewPreferenceService(db *gorm.DB) *PreferenceService {
 
 // CreatePreference добавляет новое предпочтение для пользователя
 func (s *PreferenceService) CreatePreference(userID uint, input dto.CreatePreferenceDTO) (*models.Preference, error) {
-	// Создаем новое предпочтение
 	preference := &models.Preference{
-		UserID:         userID,
-		ListPreference: models.ListPreference{},
+		UserID:           userID,
+		ListPreferenceID: input.ListPreferenceID,
 	}
 
-	// Сохраняем предпочтение в базе данных
-	if err := s.DB.Create(preference).Error; err != nil {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		// Выбрать можно только действующий элемент каталога
+		var entry models.ListPreference
+		if err := tx.Where("id = ? AND retired_at IS NULL", input.ListPreferenceID).First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCatalogEntryNotFound
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.Preference{}).
+			Where("user_id = ? AND list_preference_id = ?", userID, input.ListPreferenceID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrPreferenceExists
+		}
+
+		// Сохраняем предпочтение в базе данных. Параллельный запрос мог успеть раньше проверки выше:
+		// тогда сработает уникальный индекс
+		if err := tx.Omit("ListPreference", "User").Create(preference).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrPreferenceExists
+			}
+			return err
+		}
+		preference.ListPreference = entry
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return preference, nil
 }
 
-// GetPreferencesByUserID возвращает список предпочтений пользователя
+// GetPreferencesByUserID возвращает список предпочтений пользователя вместе с элементами каталога
 func (s *PreferenceService) GetPreferencesByUserID(userID uint) ([]models.Preference, error) {
 	var preferences []models.Preference
 
-	if err := s.DB.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+	if err := s.DB.Preload("ListPreference").Where("user_id = ?", userID).Order("id").Find(&preferences).Error; err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Data — переменные, доступные в шаблоне промпта
+type Data struct {
+	Name      string            // Название места
+	Address   string            // Улица и номер дома
+	City      string            // Город
+	Tags      map[string]string // Теги места (непустые)
+	Interests []string          // Интересы пользователя
+	Language  string            // Язык ответа, например ru
+	Audience  string            // Аудитория, например kids; пусто — по умолчанию
+}
+
+// funcs — функции, доступные в шаблоне
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+	// tags выводит теги строками "ключ: значение" в алфавитном порядке
+	"tags": func(tags map[string]string) string {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		lines := make([]string, 0, len(keys))
+		for _, k := range keys {
+			lines = append(lines, k+": "+tags[k])
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+// Parse проверяет синтаксис шаблона
+func Parse(body string) (*template.Template, error) {
+	tpl, err := template.New("prompt").Funcs(funcs).Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора шаблона: %v", err)
+	}
+	return tpl, nil
+}
+
+// Render подставляет данные в шаблон и возвращает текст промпта
+func Render(body string, data Data) (string, error) {
+	tpl, err := Parse(body)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("ошибка подстановки в шаблон: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// SampleData — данные для проверки шаблона при сохранении и предпросмотра по умолчанию
+func SampleData() Data {
+	return Data{
+		Name:      "Эрмитаж",
+		Address:   "Дворцовая площадь, 2",
+		City:      "Санкт-Петербург",
+		Tags:      map[string]string{"name": "Эрмитаж", "tourism": "museum", "addr:city": "Санкт-Петербург"},
+		Interests: []string{"История", "Искусство"},
+		Language:  "ru",
+	}
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"new/dto"
+	"new/models"
+	"new/services/llm"
+	"new/services/prompt"
+
+	"gorm.io/gorm"
+)
+
+// DescribeTemplate — имя шаблона промпта для описания мест
+const DescribeTemplate = "describe"
+
+// promptCacheTTL — сколько активная версия шаблона хранится в памяти процесса
+const promptCacheTTL = 30 * time.Second
+
+// ErrInvalidTemplate — шаблон не разбирается или не выполняется на тестовых данных
+var ErrInvalidTemplate = errors.New("некорректный шаблон промпта")
+
+// PromptTemplateService хранит версии шаблонов промптов и выбирает активный вариант
+type PromptTemplateService struct {
+	DB *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]cachedTemplate
+}
+
+type cachedTemplate struct {
+	template *models.PromptTemplate // nil — шаблона нет
+	loadedAt time.Time
+}
+
+// Resolve возвращает активную версию шаблона name, наиболее точно подходящую к языку и аудитории:
+// (язык, аудитория) → (язык, по умолчанию) → (по умолчанию, аудитория) → (по умолчанию, по умолчанию).
+// Если шаблона нет, возвращается nil без ошибки
+func (s *PromptTemplateService) Resolve(name, language, audience string) (*models.PromptTemplate, error) {
+	cacheKey := name + "|" + language + "|" + audience
+	s.mu.Lock()
+	if cached, ok := s.cache[cacheKey]; ok && time.Since(cached.loadedAt) < promptCacheTTL {
+		s.mu.Unlock()
+		return cached.template, nil
+	}
+	s.mu.Unlock()
+
+	var candidates []models.PromptTemplate
+	err := s.DB.Where("name = ? AND active AND language IN ? AND audience IN ?",
+		name, []string{language, ""}, []string{audience, ""}).Find(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки шаблона промпта: %v", err)
+	}
+
+	var best *models.PromptTemplate
+	bestScore := -1
+	for i := range candidates {
+		score := 0
+		if language != "" && candidates[i].Language == language {
+			score += 2
+		}
+		if audience != "" && candidates[i].Audience == audience {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = make(map[string]cachedTemplate)
+	}
+	s.cache[cacheKey] = cachedTemplate{template: best, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return best, nil
+}
+
+// invalidate сбрасывает кеш шаблонов после изменений
+func (s *PromptTemplateService) invalidate() {
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
+
+// List возвращает версии шаблонов, при необходимости только с именем name
+func (s *PromptTemplateService) List(name string) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	query := s.DB.Order("name, language, audience, version DESC")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	err := query.Find(&templates).Error
+	return templates, err
+}
+
+// Create сохраняет новую версию варианта шаблона и при необходимости делает её активной
+func (s *PromptTemplateService) Create(userID uint, input dto.CreatePromptTemplateDTO) (*models.PromptTemplate, error) {
+	if _, err := prompt.Render(input.Body, prompt.SampleData()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	language := strings.ToLower(strings.TrimSpace(input.Language))
+	if language != "" {
+		var err error
+		if language, err = normalizeLanguage(language); err != nil {
+			return nil, err
+		}
+	}
+
+	tpl := &models.PromptTemplate{
+		Name:     strings.TrimSpace(input.Name),
+		Language: language,
+		Audience: strings.ToLower(strings.TrimSpace(input.Audience)),
+		Body:     input.Body,
+	}
+	if userID != 0 {
+		tpl.CreatedBy = &userID
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Max *int }
+		err := tx.Model(&models.PromptTemplate{}).
+			Where("name = ? AND language = ? AND audience = ?", tpl.Name, tpl.Language, tpl.Audience).
+			Select("MAX(version) AS max").Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		tpl.Version = 1
+		if last.Max != nil {
+			tpl.Version = *last.Max + 1
+		}
+		if err := tx.Create(tpl).Error; err != nil {
+			return err
+		}
+		if input.Activate {
+			return activate(tx, tpl)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return tpl, nil
+}
+
+// Activate делает версию активной для её варианта
+func (s *PromptTemplateService) Activate(id uint) (*models.PromptTemplate, error) {
+	var tpl models.PromptTemplate
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&tpl, id).Error; err != nil {
+			return err
+		}
+		return activate(tx, &tpl)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &tpl, nil
+}
+
+// activate снимает активность с остальных версий варианта и активирует tpl
+func activate(tx *gorm.DB, tpl *models.PromptTemplate) error {
+	err := tx.Model(&models.PromptTemplate{}).
+		Where("name = ? AND language = ? AND audience = ? AND id <> ?", tpl.Name, tpl.Language, tpl.Audience, tpl.ID).
+		Update("active", false).Error
+	if err != nil {
+		return err
+	}
+	tpl.Active = true
+	return tx.Model(tpl).Update("active", true).Error
+}
+
+// Preview подставляет данные в шаблон: в сохранённую версию templateID или в переданный текст body
+func (s *PromptTemplateService) Preview(input dto.PreviewPromptDTO) (string, error) {
+	body := input.Body
+	if input.TemplateID != 0 {
+		var tpl models.PromptTemplate
+		if err := s.DB.First(&tpl, input.TemplateID).Error; err != nil {
+			return "", err
+		}
+		body = tpl.Body
+	}
+	if body == "" {
+		return "", fmt.Errorf("%w: нужен body или template_id", ErrInvalidTemplate)
+	}
+
+	data := prompt.SampleData()
+	if len(input.Place) > 0 {
+		data = promptData(input.Place)
+	}
+	if input.Interests != nil {
+		data.Interests = input.Interests
+	}
+	if input.Language != "" {
+		data.Language = input.Language
+	}
+	if input.Audience != "" {
+		data.Audience = input.Audience
+	}
+
+	text, err := prompt.Render(body, data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return text, nil
+}
+
+// promptVersionKey — поле данных места с меткой версии шаблона, по которому построен промпт
+const promptVersionKey = "prompt_version"
+
+// promptServiceKeys — служебные поля данных места, которые не являются тегами
+var promptServiceKeys = map[string]bool{
+	"type": true, "osm_id": true, "place_name": true, "language": true, "audience": true,
+	llm.InterestsField: true, llm.PromptField: true, promptVersionKey: true,
+}
+
+// promptData собирает переменные шаблона из данных места
+func promptData(place map[string]string) prompt.Data {
+	tags := make(map[string]string, len(place))
+	for k, v := range place {
+		if v != "" && !promptServiceKeys[k] {
+			tags[k] = v
+		}
+	}
+	var interests []string
+	for _, name := range strings.Split(place[llm.InterestsField], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			interests = append(interests, name)
+		}
+	}
+
+	language := place["language"]
+	if language == "" {
+		language = DefaultLanguage
+	}
+	return prompt.Data{
+		Name:      place["place_name"],
+		Address:   buildAddress(place),
+		City:      place["addr:city"],
+		Tags:      tags,
+		Interests: interests,
+		Language:  language,
+		Audience:  place["audience"],
+	}
+}
+
+// placeTemplate возвращает активный шаблон описания для языка и аудитории места; nil — шаблонов нет
+func (s *PlaceService) placeTemplate(place map[string]string) (*models.PromptTemplate, error) {
+	if s.Prompts == nil {
+		return nil, nil
+	}
+	language := place["language"]
+	if language == "" {
+		language = DefaultLanguage
+	}
+	return s.Prompts.Resolve(DescribeTemplate, language, place["audience"])
+}
+
+// preparePrompt находит шаблон для места и возвращает копию данных с текстом промпта и версией шаблона.
+// Если шаблонов нет, данные возвращаются без изменений
+func (s *PlaceService) preparePrompt(place map[string]string) (map[string]string, error) {
+	if _, ok := place[llm.PromptField]; ok {
+		return place, nil
+	}
+	tpl, err := s.placeTemplate(place)
+	if err != nil || tpl == nil {
+		return place, err
+	}
+	text, err := prompt.Render(tpl.Body, promptData(place))
+	if err != nil {
+		return nil, fmt.Errorf("шаблон %s: %v", tpl.Tag(), err)
+	}
+
+	data := make(map[string]string, len(place)+2)
+	for k, v := range place {
+		data[k] = v
+	}
+	data[llm.PromptField] = text
+	data[promptVersionKey] = tpl.Tag()
+	return data, nil
+}
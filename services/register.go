@@ -4,6 +4,7 @@ import (
 	"errors"
 	"new/dto"
 	"new/models"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -31,11 +32,21 @@ func (service *RegistService) RegisterUser(userDTO dto.RegisterUserDTO) (*models
 		return nil, err
 	}
 
+	// Язык описаний необязателен, но если задан, должен быть корректным кодом
+	locale := ""
+	if strings.TrimSpace(userDTO.Locale) != "" {
+		if locale, err = normalizeLanguage(userDTO.Locale); err != nil {
+			return nil, err
+		}
+	}
+
 	// Создаем нового пользователя с хэшированным паролем
 	newUser := models.User{
 		Username: userDTO.Username,
 		Password: string(hashedPassword), // Храним хэш пароля
 		Email:    userDTO.Email,
+		Role:     models.RoleUser,
+		Locale:   locale,
 	}
 
 	// Сохраняем нового пользователя в базу данных
@@ -44,3 +55,28 @@ func (service *RegistService) RegisterUser(userDTO dto.RegisterUserDTO) (*models
 	}
 	return &newUser, nil
 }
+
+// SetLocale сохраняет язык описаний по умолчанию в профиле пользователя. Пустое значение сбрасывает язык
+func (service *RegistService) SetLocale(userID uint, locale string) (*models.User, error) {
+	if strings.TrimSpace(locale) != "" {
+		var err error
+		if locale, err = normalizeLanguage(locale); err != nil {
+			return nil, err
+		}
+	} else {
+		locale = ""
+	}
+
+	res := service.DB.Model(&models.User{}).Where("id = ?", userID).Update("locale", locale)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var user models.User
+	if err := service.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"new/database"
+	"new/models"
+	"new/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken — refresh-токен не найден или истёк
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused — повторное использование уже заменённого refresh-токена
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+const (
+	denyJTIPrefix    = "auth:deny:jti:"
+	denyFamilyPrefix = "auth:deny:family:"
+)
+
+// TokenPair — пара access- и refresh-токенов
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time // Срок действия access-токена
+}
+
+// TokenService выдает, ротирует и отзывает токены.
+// Refresh-токены хранятся в Postgres в виде хешей, отозванные access-токены — в Redis
+type TokenService struct {
+	DB *gorm.DB
+}
+
+// refreshTokenTTL — время жизни refresh-токена (REFRESH_TOKEN_TTL, по умолчанию 30 дней)
+func refreshTokenTTL() time.Duration {
+	return utils.EnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// hashRefreshToken возвращает sha256-хеш refresh-токена для хранения в базе
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueTokens выдает новую пару токенов и открывает новое семейство refresh-токенов
+func (s *TokenService) IssueTokens(userID uint) (*TokenPair, error) {
+	family, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var pair *TokenPair
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		pair, _, txErr = s.issue(tx, userID, family)
+		return txErr
+	})
+	return pair, err
+}
+
+// issue создает refresh-токен в семействе family и подписывает access-токен
+func (s *TokenService) issue(tx *gorm.DB, userID uint, family string) (*TokenPair, *models.RefreshToken, error) {
+	refresh, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hashRefreshToken(refresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка сохранения refresh-токена: %v", err)
+	}
+
+	// Роль читается при каждой выдаче, поэтому её изменение вступает в силу при обновлении токена
+	var user models.User
+	if err := tx.Select("id", "role").First(&user, userID).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения роли пользователя: %v", err)
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	access, claims, err := utils.GenerateAccessToken(userID, user.Role, family)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, record, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару. Старый токен становится недействительным;
+// повторное предъявление уже заменённого токена отзывает всё семейство
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	var (
+		pair   *TokenPair
+		reused *models.RefreshToken
+	)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashRefreshToken(refreshToken)).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if current.RevokedAt != nil {
+			reused = &current
+			return ErrRefreshTokenReused
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Помечаем токен использованным; если его уже успел использовать
+		// параллельный запрос, это тоже считается повторным использованием
+		now := time.Now()
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Update("revoked_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = &current
+			return ErrRefreshTokenReused
+		}
+
+		next, record, err := s.issue(tx, current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&current).Update("replaced_by", record.ID).Error; err != nil {
+			return err
+		}
+		pair = next
+		return nil
+	})
+
+	if reused != nil {
+		if err := s.RevokeFamily(reused.FamilyID); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout отзывает текущий access-токен и всё семейство его refresh-токенов
+func (s *TokenService) Logout(claims *utils.AccessClaims, refreshToken string) error {
+	if err := s.DenyAccessToken(claims); err != nil {
+		return err
+	}
+
+	family := claims.Family
+	if refreshToken != "" {
+		var record models.RefreshToken
+		err := s.DB.Where("token_hash = ?", hashRefreshToken(refreshToken)).First(&record).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if userID, _ := claims.UserID(); record.UserID != userID {
+				return ErrInvalidRefreshToken
+			}
+			family = record.FamilyID
+		}
+	}
+	if family == "" {
+		return nil
+	}
+	return s.RevokeFamily(family)
+}
+
+// RevokeFamily отзывает все refresh-токены семейства и выданные по ним access-токены
+func (s *TokenService) RevokeFamily(family string) error {
+	err := s.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва семейства токенов: %v", err)
+	}
+
+	if database.RedisClient == nil {
+		return nil
+	}
+	// Access-токены семейства живут не дольше AccessTokenTTL
+	if err := database.RedisClient.Set(context.Background(), denyFamilyPrefix+family, 1, utils.AccessTokenTTL()).Err(); err != nil {
+		return fmt.Errorf("ошибка записи в deny-list: %v", err)
+	}
+	return nil
+}
+
+// RevokeUser отзывает все семейства refresh-токенов пользователя (например, после смены роли)
+func (s *TokenService) RevokeUser(userID uint) error {
+	var families []string
+	err := s.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().Pluck("family_id", &families).Error
+	if err != nil {
+		return fmt.Errorf("ошибка чтения токенов пользователя: %v", err)
+	}
+	for _, family := range families {
+		if err := s.RevokeFamily(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DenyAccessToken добавляет access-токен в deny-list до истечения его срока
+func (s *TokenService) DenyAccessToken(claims *utils.AccessClaims) error {
+	if database.RedisClient == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := database.RedisClient.Set(context.Background(), denyJTIPrefix+claims.ID, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("ошибка записи в deny-list: %v", err)
+	}
+	return nil
+}
+
+// CheckDenyList проверяет при запуске, что deny-list доступен. Без Redis отзыв токенов не работает,
+// поэтому сервер запускается без него, только если это явно разрешено через AUTH_ALLOW_NO_DENY_LIST=true
+func (s *TokenService) CheckDenyList() error {
+	if database.RedisClient != nil {
+		return nil
+	}
+	if os.Getenv("AUTH_ALLOW_NO_DENY_LIST") != "true" {
+		return errors.New("Redis не подключен, отзыв токенов не будет работать; установите AUTH_ALLOW_NO_DENY_LIST=true, чтобы запустить сервер без него")
+	}
+	log.Println("Внимание: Redis не подключен, отозванные access-токены действуют до истечения срока")
+	return nil
+}
+
+// IsRevoked проверяет, отозван ли access-токен или его семейство.
+// Без Redis отзыв не проверяется, см. CheckDenyList
+func (s *TokenService) IsRevoked(claims *utils.AccessClaims) (bool, error) {
+	if database.RedisClient == nil {
+		return false, nil
+	}
+	keys := []string{denyJTIPrefix + claims.ID}
+	if claims.Family != "" {
+		keys = append(keys, denyFamilyPrefix+claims.Family)
+	}
+	n, err := database.RedisClient.Exists(context.Background(), keys...).Result()
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки deny-list: %v", err)
+	}
+	return n > 0, nil
+}
+
+// Authenticate проверяет access-токен: подпись, срок действия и отсутствие в deny-list
+func (s *TokenService) Authenticate(token string) (uint, *utils.AccessClaims, error) {
+	claims, err := utils.ParseAccessToken(token)
+	if err != nil {
+		return 0, nil, err
+	}
+	revoked, err := s.IsRevoked(claims)
+	if err != nil {
+		return 0, nil, err
+	}
+	if revoked {
+		return 0, nil, errors.New("token revoked")
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return 0, nil, err
+	}
+	return userID, claims, nil
+}
@@ -0,0 +1,198 @@
+// Package tour упорядочивает остановки пешей экскурсии: ближайший сосед с улучшением 2-opt
+// в пределах бюджета по времени и расстоянию
+package tour
+
+import (
+	"math"
+	"time"
+
+	"new/services/geo"
+)
+
+// WalkingSpeed — скорость пешехода по умолчанию, м/с (4,5 км/ч)
+const WalkingSpeed = 1.25
+
+// Budget — ограничения экскурсии. Нулевые значения означают отсутствие ограничения
+type Budget struct {
+	Distance     float64       // Суммарная длина переходов, м
+	Duration     time.Duration // Переходы и время на остановках
+	StopDuration time.Duration // Время на одной остановке
+	Speed        float64       // Скорость ходьбы, м/с; 0 — WalkingSpeed
+	MaxStops     int
+}
+
+// WalkTime возвращает время на переход длиной distance метров
+func (b Budget) WalkTime(distance float64) time.Duration {
+	speed := b.Speed
+	if speed <= 0 {
+		speed = WalkingSpeed
+	}
+	return time.Duration(distance / speed * float64(time.Second))
+}
+
+// Total возвращает длительность экскурсии с переходами общей длиной distance и stops остановками
+func (b Budget) Total(distance float64, stops int) time.Duration {
+	return b.WalkTime(distance) + time.Duration(stops)*b.StopDuration
+}
+
+// Fits проверяет, укладывается ли экскурсия в бюджет
+func (b Budget) Fits(distance float64, stops int) bool {
+	if b.MaxStops > 0 && stops > b.MaxStops {
+		return false
+	}
+	if b.Distance > 0 && distance > b.Distance {
+		return false
+	}
+	if b.Duration > 0 && b.Total(distance, stops) > b.Duration {
+		return false
+	}
+	return true
+}
+
+// Route — порядок остановок экскурсии
+type Route struct {
+	Order    []int         // Индексы точек в порядке обхода
+	Legs     []float64     // Длина перехода к каждой остановке, м; для первой без старта — 0
+	Distance float64       // Суммарная длина переходов, м
+	Duration time.Duration // С учетом времени на остановках
+}
+
+// Plan выбирает и упорядочивает остановки из points. Маршрут начинается в start,
+// а если start не задан — в первой точке, поэтому самую важную точку стоит передавать первой.
+// Маршрут строится жадно по ближайшему соседу, затем сокращается 2-opt; освободившийся бюджет
+// снова заполняется ближайшими точками, пока добавлять нечего
+func Plan(start *geo.Point, points []geo.Point, budget Budget) Route {
+	p := planner{points: points, budget: budget, used: make([]bool, len(points))}
+	if start != nil {
+		p.start = *start
+		p.hasStart = true
+	} else if len(points) > 0 && budget.Fits(0, 1) {
+		p.start = points[0]
+		p.add(0)
+	}
+
+	for p.extend() {
+		p.twoOpt()
+	}
+	return p.route()
+}
+
+type planner struct {
+	points   []geo.Point
+	budget   Budget
+	start    geo.Point
+	hasStart bool
+	order    []int
+	used     []bool
+}
+
+func (p *planner) add(i int) {
+	p.order = append(p.order, i)
+	p.used[i] = true
+}
+
+// at возвращает k-ю вершину пути: 0 — старт (если задан), далее остановки
+func (p *planner) at(k int) geo.Point {
+	if p.hasStart {
+		if k == 0 {
+			return p.start
+		}
+		return p.points[p.order[k-1]]
+	}
+	return p.points[p.order[k]]
+}
+
+// vertices — число вершин пути вместе со стартом
+func (p *planner) vertices() int {
+	if p.hasStart {
+		return len(p.order) + 1
+	}
+	return len(p.order)
+}
+
+func (p *planner) length() float64 {
+	total := 0.0
+	for k := 1; k < p.vertices(); k++ {
+		total += geo.Distance(p.at(k-1), p.at(k))
+	}
+	return total
+}
+
+// extend добавляет в конец пути ближайшие точки, пока они укладываются в бюджет.
+// Возвращает true, если добавлена хотя бы одна точка
+func (p *planner) extend() bool {
+	added := false
+	distance := p.length()
+	for {
+		if p.vertices() == 0 {
+			return added
+		}
+		last := p.at(p.vertices() - 1)
+
+		best, bestDistance := -1, math.Inf(1)
+		for i, point := range p.points {
+			if p.used[i] {
+				continue
+			}
+			d := geo.Distance(last, point)
+			if d < bestDistance && p.budget.Fits(distance+d, len(p.order)+1) {
+				best, bestDistance = i, d
+			}
+		}
+		if best < 0 {
+			return added
+		}
+		p.add(best)
+		distance += bestDistance
+		added = true
+	}
+}
+
+// twoOpt разворачивает участки пути, пока это сокращает его. Первая вершина пути неподвижна,
+// конец пути свободен
+func (p *planner) twoOpt() {
+	offset := 0
+	if p.hasStart {
+		offset = 1
+	}
+	n := p.vertices()
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				before := geo.Distance(p.at(i-1), p.at(i))
+				after := geo.Distance(p.at(i-1), p.at(j))
+				if j+1 < n {
+					before += geo.Distance(p.at(j), p.at(j+1))
+					after += geo.Distance(p.at(i), p.at(j+1))
+				}
+				if after < before-1e-6 {
+					reverse(p.order[i-offset : j-offset+1])
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+func reverse(order []int) {
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+}
+
+func (p *planner) route() Route {
+	route := Route{Order: p.order, Legs: make([]float64, len(p.order))}
+	for k := range p.order {
+		if k > 0 || p.hasStart {
+			vertex := k
+			if p.hasStart {
+				vertex = k + 1
+			}
+			route.Legs[k] = geo.Distance(p.at(vertex-1), p.at(vertex))
+		}
+		route.Distance += route.Legs[k]
+	}
+	route.Duration = p.budget.Total(route.Distance, len(p.order))
+	return route
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"new/dto"
+	"new/services/geo"
+	"new/services/tour"
+)
+
+// Параметры экскурсии по умолчанию и ограничения
+const (
+	DefaultTourMinutes     = 45.0
+	DefaultTourStopMinutes = 5.0
+	DefaultTourRadius      = 1000.0 // Метры
+	DefaultTourStops       = 10
+	MaxTourStops           = 20
+)
+
+// ErrInvalidTour возвращается при некорректных параметрах экскурсии
+var ErrInvalidTour = errors.New("invalid tour request")
+
+// compassNames — направления по компасу для текста переходов
+var compassNames = map[string]map[string]string{
+	"ru": {"N": "север", "NE": "северо-восток", "E": "восток", "SE": "юго-восток", "S": "юг", "SW": "юго-запад", "W": "запад", "NW": "северо-запад"},
+	"en": {"N": "north", "NE": "northeast", "E": "east", "SE": "southeast", "S": "south", "SW": "southwest", "W": "west", "NW": "northwest"},
+}
+
+// ResolveTourQuery проверяет параметры экскурсии и подставляет значения по умолчанию.
+// Если не задан ни бюджет времени, ни бюджет расстояния, экскурсия рассчитывается на DefaultTourMinutes
+func ResolveTourQuery(q dto.TourQuery) (dto.TourQuery, error) {
+	if q.Start != nil && !(q.Start.Lat >= -90 && q.Start.Lat <= 90 && q.Start.Lon >= -180 && q.Start.Lon <= 180) {
+		return q, fmt.Errorf("%w: start coordinates out of range", ErrInvalidTour)
+	}
+	if !(q.DurationMinutes >= 0) || !(q.DistanceMeters >= 0) || !(q.StopMinutes >= 0) || !(q.Radius >= 0) {
+		return q, fmt.Errorf("%w: duration, distance, stop time and radius must not be negative", ErrInvalidTour)
+	}
+	if q.DurationMinutes == 0 && q.DistanceMeters == 0 {
+		q.DurationMinutes = DefaultTourMinutes
+	}
+	if q.StopMinutes == 0 {
+		q.StopMinutes = DefaultTourStopMinutes
+	}
+	switch {
+	case q.Radius == 0:
+		q.Radius = DefaultTourRadius
+	case q.Radius > MaxNearbyRadius:
+		return q, fmt.Errorf("%w: radius must not exceed %.0f meters", ErrInvalidTour, MaxNearbyRadius)
+	}
+	switch {
+	case q.MaxStops == 0:
+		q.MaxStops = DefaultTourStops
+	case q.MaxStops < 1 || q.MaxStops > MaxTourStops:
+		return q, fmt.Errorf("%w: max stops must be between 1 and %d", ErrInvalidTour, MaxTourStops)
+	}
+	if q.Language != "" {
+		language, err := ResolveLanguage(q.Language)
+		if err != nil {
+			return q, err
+		}
+		q.Language = language
+	}
+	return q, nil
+}
+
+// tourCandidate — место, которое может стать остановкой экскурсии
+type tourCandidate struct {
+	Point  geo.Point
+	Tags   map[string]string
+	Input  *placeInput      // Объект из запроса, описание еще предстоит получить
+	Nearby *dto.NearbyPlace // Сохраненное место с готовым описанием
+	Score  int              // Число совпавших интересов пользователя
+}
+
+// name возвращает название места для текста перехода
+func (c tourCandidate) name() string {
+	if c.Nearby != nil {
+		return c.Nearby.PlaceName
+	}
+	return c.Input.Data["place_name"]
+}
+
+// PlanTour строит пешую экскурсию для пользователя. Остановки берутся из osmObjects,
+// а если они не переданы — из уже описанных мест рядом с начальной точкой.
+// Места отбираются по интересам пользователя, упорядочиваются в пределах бюджета,
+// для каждой остановки формируются описание, аудио и переход от предыдущей
+func (s *PlaceService) PlanTour(ctx context.Context, userID uint, q dto.TourQuery, osmObjects []dto.OSMObject) (*dto.Tour, error) {
+	q, err := ResolveTourQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	language := s.requestLanguage(ctx, userID, q.Language)
+
+	interests, err := s.loadInterests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.tourCandidates(ctx, q, language, osmObjects)
+	if err != nil {
+		return nil, err
+	}
+	candidates = rankTourCandidates(interests, candidates)
+
+	var start *geo.Point
+	if q.Start != nil {
+		start = &geo.Point{Lat: q.Start.Lat, Lon: q.Start.Lon}
+	}
+	points := make([]geo.Point, len(candidates))
+	for i, c := range candidates {
+		points[i] = c.Point
+	}
+	budget := tour.Budget{
+		Distance:     q.DistanceMeters,
+		Duration:     time.Duration(q.DurationMinutes * float64(time.Minute)),
+		StopDuration: time.Duration(q.StopMinutes * float64(time.Minute)),
+		MaxStops:     q.MaxStops,
+	}
+	route := tour.Plan(start, points, budget)
+
+	result := &dto.Tour{
+		Language:        language,
+		Start:           q.Start,
+		Distance:        math.Round(route.Distance),
+		DurationMinutes: math.Round(route.Duration.Minutes()),
+		Candidates:      len(candidates),
+		Stops:           make([]dto.TourStop, len(route.Order)),
+	}
+
+	// Описания остановок и переходы готовятся параллельно, не больше 5 одновременно
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 5)
+	for k, index := range route.Order {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(k, index int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			stop := candidates[index]
+			result.Stops[k] = dto.TourStop{Order: k + 1, Place: s.tourStopPlace(ctx, userID, interests, stop)}
+			if k == 0 && start == nil {
+				return
+			}
+			from, fromName := geo.Point{}, ""
+			if k == 0 {
+				from = *start
+			} else {
+				previous := candidates[route.Order[k-1]]
+				from, fromName = previous.Point, previous.name()
+			}
+			result.Stops[k].Transition = s.tourTransition(ctx, language, from, stop.Point, fromName, stop.name(), budget)
+		}(k, index)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return result, nil
+}
+
+// tourCandidates собирает места с координатами: из переданных объектов или из сохраненных мест рядом со стартом
+func (s *PlaceService) tourCandidates(ctx context.Context, q dto.TourQuery, language string, osmObjects []dto.OSMObject) ([]tourCandidate, error) {
+	var candidates []tourCandidate
+	if len(osmObjects) > 0 {
+		inputs, _ := preparePlaces(osmObjects, language)
+		for i := range inputs {
+			if inputs[i].Shape == nil {
+				continue
+			}
+			candidates = append(candidates, tourCandidate{
+				Point: inputs[i].Shape.RepresentativePoint(),
+				Tags:  inputTags(inputs[i]),
+				Input: &inputs[i],
+			})
+		}
+		return candidates, nil
+	}
+
+	if q.Start == nil {
+		return nil, fmt.Errorf("%w: either OSM objects or a start point are required", ErrInvalidTour)
+	}
+	if s.OSMPlaces == nil {
+		return nil, fmt.Errorf("сохраненные места недоступны")
+	}
+	nearby, err := s.OSMPlaces.Nearby(ctx, dto.NearbyQuery{
+		Lat:      q.Start.Lat,
+		Lon:      q.Start.Lon,
+		Radius:   q.Radius,
+		Language: language,
+		Limit:    MaxNearbyLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range nearby {
+		candidates = append(candidates, tourCandidate{
+			Point:  geo.Point{Lat: nearby[i].Coordinates.Lat, Lon: nearby[i].Coordinates.Lon},
+			Tags:   nearby[i].Tags,
+			Nearby: &nearby[i],
+		})
+	}
+	return candidates, nil
+}
+
+// rankTourCandidates оставляет места, подходящие под интересы пользователя, самые подходящие — первыми.
+// Если под интересы не подходит ни одно место, экскурсия строится по всем
+func rankTourCandidates(interests *userInterests, candidates []tourCandidate) []tourCandidate {
+	if !interests.hasPatterns() {
+		return candidates
+	}
+	matched := make([]tourCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		c.Score = len(interests.match(c.Tags))
+		if c.Score > 0 {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) == 0 {
+		return candidates
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Score > matched[j].Score })
+	return matched
+}
+
+// tourStopPlace возвращает описание остановки: готовое для сохраненного места или полученное через processPlace
+func (s *PlaceService) tourStopPlace(ctx context.Context, userID uint, interests *userInterests, stop tourCandidate) dto.PlaceResult {
+	if stop.Input != nil {
+		return s.processPlace(ctx, userID, interests, *stop.Input)
+	}
+	nearby := stop.Nearby
+	coordinates := nearby.Coordinates
+	return dto.PlaceResult{
+		PlaceID:     nearby.PlaceID,
+		OSMType:     nearby.OSMType,
+		PlaceName:   nearby.PlaceName,
+		Status:      dto.PlaceStatusSuccess,
+		Response:    nearby.Response,
+		Address:     nearby.Address,
+		City:        nearby.City,
+		Coordinates: &coordinates,
+		Interests:   interests.match(nearby.Tags),
+		Audio:       nearby.Audio,
+	}
+}
+
+// tourTransition формирует переход между остановками: расстояние, направление, текст и его озвучку.
+// Без аудио переход остается в ответе — текст важнее
+func (s *PlaceService) tourTransition(ctx context.Context, language string, from, to geo.Point, fromName, toName string, budget tour.Budget) *dto.TourTransition {
+	distance := geo.Distance(from, to)
+	transition := &dto.TourTransition{
+		Distance:    math.Round(distance),
+		WalkMinutes: math.Max(1, math.Round(budget.WalkTime(distance).Minutes())),
+		Direction:   geo.Compass(geo.Bearing(from, to)),
+	}
+	transition.Text = s.narrateTransition(ctx, language, fromName, toName, transition)
+
+	asset, err := s.generateAudio(ctx, transition.Text, language)
+	if err != nil {
+		fmt.Printf("Ошибка при озвучивании перехода: %v\n", err)
+		return transition
+	}
+	transition.Audio = s.audioRef(asset)
+	return transition
+}
+
+// narrateTransition просит LLM связать две остановки одной-двумя фразами.
+// Если LLM недоступна, используется шаблонный текст
+func (s *PlaceService) narrateTransition(ctx context.Context, language, fromName, toName string, t *dto.TourTransition) string {
+	fallback := transitionText(language, toName, t)
+	if s.LLM == nil {
+		return fallback
+	}
+	provider, err := s.LLM.Get("")
+	if err != nil {
+		return fallback
+	}
+
+	from := "от начальной точки"
+	if fromName != "" {
+		from = fmt.Sprintf("от места «%s»", fromName)
+	}
+	question := fmt.Sprintf("Ты — экскурсовод на пешей экскурсии. Одной-двумя фразами подведи слушателя %s к месту «%s»: "+
+		"идти около %.0f м на %s, примерно %.0f мин. Не описывай место назначения подробно и не используй разметку. "+
+		"Отвечай на языке с кодом %s.",
+		from, toName, t.Distance, compassNames["ru"][t.Direction], t.WalkMinutes, language)
+	text, err := provider.Ask(ctx, question)
+	if err != nil || strings.TrimSpace(text) == "" {
+		if err != nil {
+			fmt.Printf("Ошибка при генерации перехода: %v\n", err)
+		}
+		return fallback
+	}
+	return strings.TrimSpace(text)
+}
+
+// transitionText — шаблонный текст перехода: на русском для ru, на английском для остальных языков
+func transitionText(language, toName string, t *dto.TourTransition) string {
+	if strings.HasPrefix(language, "ru") {
+		return fmt.Sprintf("Следующая остановка — «%s». Идём около %.0f м на %s, это примерно %.0f мин.",
+			toName, t.Distance, compassNames["ru"][t.Direction], t.WalkMinutes)
+	}
+	return fmt.Sprintf("Next stop: %s. Walk about %.0f m %s, roughly %.0f min.",
+		toName, t.Distance, compassNames["en"][t.Direction], t.WalkMinutes)
+}
@@ -0,0 +1,144 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HostTTSProvider — провайдер для текущего TTS-сервиса (HOST_TTS).
+// Сервис принимает {"message": "..."} и возвращает аудио в теле ответа
+type HostTTSProvider struct {
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// NewHostTTSProvider создает провайдер для HOST_TTS
+func NewHostTTSProvider(url string) *HostTTSProvider {
+	return &HostTTSProvider{
+		URL:     url,
+		Timeout: 9999 * time.Second,
+		Client:  &http.Client{},
+	}
+}
+
+// Name возвращает имя провайдера
+func (p *HostTTSProvider) Name() string {
+	return "host"
+}
+
+// Synthesize отправляет текст и параметры синтеза в HOST_TTS
+func (p *HostTTSProvider) Synthesize(ctx context.Context, text string, opts Options) (*Audio, error) {
+	reqBody := struct {
+		Message string `json:"message"`
+		Options
+	}{Message: text, Options: opts}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+	}
+
+	return doSynthesize(ctx, p.Client, p.Timeout, p.URL, "", jsonBody, opts.Format)
+}
+
+// HTTPProvider — универсальный HTTP TTS: принимает JSON с полями text, voice, language,
+// speed и format, возвращает аудио в теле ответа
+type HTTPProvider struct {
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// NewHTTPProvider создает универсальный HTTP TTS-провайдер
+func NewHTTPProvider(url, apiKey string) *HTTPProvider {
+	return &HTTPProvider{
+		URL:     url,
+		APIKey:  apiKey,
+		Timeout: 300 * time.Second,
+		Client:  &http.Client{},
+	}
+}
+
+// Name возвращает имя провайдера
+func (p *HTTPProvider) Name() string {
+	return "http"
+}
+
+// Synthesize отправляет текст и параметры синтеза в HTTP TTS
+func (p *HTTPProvider) Synthesize(ctx context.Context, text string, opts Options) (*Audio, error) {
+	reqBody := struct {
+		Text string `json:"text"`
+		Options
+	}{Text: text, Options: opts}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при маршалинге JSON: %v", err)
+	}
+
+	return doSynthesize(ctx, p.Client, p.Timeout, p.URL, p.APIKey, jsonBody, opts.Format)
+}
+
+// doSynthesize выполняет запрос к TTS-сервису и проверяет, что в ответе пришло аудио
+func doSynthesize(ctx context.Context, client *http.Client, timeout time.Duration, url, apiKey string, jsonBody []byte, format string) (*Audio, error) {
+	if url == "" {
+		return nil, fmt.Errorf("адрес TTS-сервиса не задан")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", ContentType(format))
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отправке запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка: статус ответа %d", resp.StatusCode)
+	}
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении тела ответа: %v", err)
+	}
+
+	// Сервис может вернуть JSON с текстом ошибки вместо аудио
+	var response struct {
+		Text string `json:"message"`
+	}
+	if err := json.Unmarshal(audioData, &response); err == nil {
+		if strings.Contains(response.Text, "ОШИБКА") {
+			return nil, fmt.Errorf("ошибка от TTS: %s", response.Text)
+		}
+	}
+
+	if len(audioData) == 0 {
+		return nil, fmt.Errorf("пустое тело ответа")
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "audio/") {
+		contentType = ContentType(format)
+	}
+
+	return &Audio{Data: audioData, Format: format, ContentType: contentType}, nil
+}
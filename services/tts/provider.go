@@ -0,0 +1,250 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Поддерживаемые форматы аудио
+const (
+	FormatMP3 = "mp3"
+	FormatOGG = "ogg"
+	FormatWAV = "wav"
+)
+
+// Options задает параметры синтеза речи. Пустые поля означают значения по умолчанию провайдера
+type Options struct {
+	Voice    string  `json:"voice,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Speed    float64 `json:"speed,omitempty"` // Скорость речи, 1.0 — обычная
+	Format   string  `json:"format,omitempty"`
+}
+
+// Audio — результат синтеза речи
+type Audio struct {
+	Data        []byte
+	Format      string
+	ContentType string
+}
+
+// Provider описывает сервис синтеза речи. Синтез прерывается при отмене ctx
+type Provider interface {
+	// Name возвращает имя провайдера, под которым он зарегистрирован
+	Name() string
+	// Synthesize озвучивает текст с указанными параметрами
+	Synthesize(ctx context.Context, text string, opts Options) (*Audio, error)
+}
+
+// ContentType возвращает MIME-тип для формата аудио
+func ContentType(format string) string {
+	switch format {
+	case FormatOGG:
+		return "audio/ogg"
+	case FormatWAV:
+		return "audio/wav"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+// Validate проверяет параметры синтеза
+func (o Options) Validate() error {
+	switch o.Format {
+	case "", FormatMP3, FormatOGG, FormatWAV:
+	default:
+		return fmt.Errorf("неподдерживаемый формат аудио: %s", o.Format)
+	}
+	if o.Speed < 0 || o.Speed > 4 {
+		return fmt.Errorf("скорость речи должна быть в диапазоне от 0 до 4")
+	}
+	return nil
+}
+
+// WithDefaults дополняет пустые поля значениями из defaults
+func (o Options) WithDefaults(defaults Options) Options {
+	if o.Voice == "" {
+		o.Voice = defaults.Voice
+	}
+	if o.Language == "" {
+		o.Language = defaults.Language
+	}
+	if o.Speed == 0 {
+		o.Speed = defaults.Speed
+	}
+	if o.Format == "" {
+		o.Format = defaults.Format
+	}
+	return o
+}
+
+// Registry хранит TTS-провайдеры по имени вместе с параметрами по умолчанию
+type Registry struct {
+	mu          sync.RWMutex
+	providers   map[string]Provider
+	defaultName string
+	Defaults    Options
+	Voices      map[string]string // Голос для языка: ru → ru-RU-Svetlana
+}
+
+// NewRegistry создает пустой реестр TTS-провайдеров
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+		Defaults:  Options{Format: FormatMP3},
+		Voices:    make(map[string]string),
+	}
+}
+
+// VoiceFor возвращает голос для языка: сначала точное совпадение (pt-br), затем основной язык (pt).
+// Пустая строка — голос для языка не настроен
+func (r *Registry) VoiceFor(language string) string {
+	language = strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	if voice, ok := r.Voices[language]; ok {
+		return voice
+	}
+	if i := strings.IndexByte(language, '-'); i > 0 {
+		return r.Voices[language[:i]]
+	}
+	return ""
+}
+
+// Resolve дополняет параметры синтеза: голос подбирается по языку, остальные поля — из параметров по умолчанию.
+// Явно указанный голос не меняется
+func (r *Registry) Resolve(opts Options) Options {
+	if opts.Voice == "" && opts.Language != "" {
+		opts.Voice = r.VoiceFor(opts.Language)
+	}
+	return opts.WithDefaults(r.Defaults)
+}
+
+// ParseVoices разбирает соответствие языков и голосов вида "ru=ru-RU-Svetlana,en=en-US-Aria"
+func ParseVoices(spec string) (map[string]string, error) {
+	voices := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		language, voice, ok := strings.Cut(pair, "=")
+		language = strings.ToLower(strings.TrimSpace(language))
+		voice = strings.TrimSpace(voice)
+		if !ok || language == "" || voice == "" {
+			return nil, fmt.Errorf("ожидается язык=голос, получено %q", pair)
+		}
+		voices[strings.ReplaceAll(language, "_", "-")] = voice
+	}
+	return voices, nil
+}
+
+// Register добавляет провайдер в реестр. Первый зарегистрированный провайдер становится провайдером по умолчанию
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[p.Name()] = p
+	if r.defaultName == "" {
+		r.defaultName = p.Name()
+	}
+}
+
+// SetDefault задает провайдер по умолчанию
+func (r *Registry) SetDefault(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("неизвестный TTS-провайдер: %s", name)
+	}
+	r.defaultName = name
+	return nil
+}
+
+// Get возвращает провайдер по имени. Пустое имя означает провайдер по умолчанию
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный TTS-провайдер: %s", name)
+	}
+	return p, nil
+}
+
+// Names возвращает отсортированный список зарегистрированных провайдеров
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Synthesize озвучивает текст провайдером с указанным именем, подбирая голос по языку и дополняя параметры значениями по умолчанию
+func (r *Registry) Synthesize(ctx context.Context, providerName, text string, opts Options) (*Audio, error) {
+	opts = r.Resolve(opts)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	provider, err := r.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Synthesize(ctx, text, opts)
+}
+
+// NewRegistryFromEnv собирает реестр из переменных окружения:
+// HOST_TTS — текущий TTS-сервис, TTS_HTTP_URL и TTS_HTTP_API_KEY — универсальный HTTP TTS,
+// TTS_PROVIDER — провайдер по умолчанию, TTS_VOICE, TTS_LANGUAGE, TTS_SPEED, TTS_FORMAT — параметры по умолчанию,
+// TTS_VOICES — голоса для языков, например "ru=ru-RU-Svetlana,en=en-US-Aria"
+func NewRegistryFromEnv() (*Registry, error) {
+	r := NewRegistry()
+
+	r.Register(NewHostTTSProvider(os.Getenv("HOST_TTS")))
+	if url := os.Getenv("TTS_HTTP_URL"); url != "" {
+		r.Register(NewHTTPProvider(url, os.Getenv("TTS_HTTP_API_KEY")))
+	}
+
+	if name := strings.TrimSpace(os.Getenv("TTS_PROVIDER")); name != "" {
+		if err := r.SetDefault(name); err != nil {
+			return nil, err
+		}
+	}
+
+	voices, err := ParseVoices(os.Getenv("TTS_VOICES"))
+	if err != nil {
+		return nil, fmt.Errorf("некорректное значение TTS_VOICES: %v", err)
+	}
+	r.Voices = voices
+
+	r.Defaults.Voice = os.Getenv("TTS_VOICE")
+	r.Defaults.Language = os.Getenv("TTS_LANGUAGE")
+	if format := os.Getenv("TTS_FORMAT"); format != "" {
+		r.Defaults.Format = format
+	}
+	if speed := os.Getenv("TTS_SPEED"); speed != "" {
+		value, err := strconv.ParseFloat(speed, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное значение TTS_SPEED: %v", err)
+		}
+		r.Defaults.Speed = value
+	}
+	if err := r.Defaults.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -2,13 +2,15 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"new/dto"
-	"new/utils"
+	"new/services/osm"
 
 	"github.com/gorilla/websocket"
 )
@@ -16,15 +18,17 @@ import (
 // WebSocketHandler для обработки WebSocket-соединений
 type WebSocketHandler struct {
 	PlaceService *PlaceService
+	Tokens       *TokenService
 	Clients      map[*websocket.Conn]bool
 	mu           sync.Mutex
 }
 
 // NewWebSocketHandler создаёт новый обработчик WebSocket
-func NewWebSocketHandler(placeService *PlaceService) *WebSocketHandler {
+func NewWebSocketHandler(placeService *PlaceService, tokens *TokenService) *WebSocketHandler {
 	log.Printf("Инициализация нового WebSocketHandler")
 	return &WebSocketHandler{
 		PlaceService: placeService,
+		Tokens:       tokens,
 		Clients:      make(map[*websocket.Conn]bool),
 	}
 }
@@ -71,67 +75,64 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	token := query.Get("token")
 	if token == "" {
 		log.Printf("Отсутствует токен в параметрах URL для соединения с %s", r.RemoteAddr)
-		if err := conn.WriteJSON(map[string]string{"error": "Токен отсутствует в параметрах URL"}); err != nil {
-			log.Printf("Не удалось отправить ошибку отсутствия токена клиенту: %v", err)
-		}
+		writeHandshakeError(conn, dto.WSErrorUnauthorized, "Токен отсутствует в параметрах URL")
 		return
 	}
-	log.Printf("Получен токен: %s", token)
-
-	// Извлекаем userID из токена
-	userID, err := utils.ExtractUserIDFromToken(token)
+	// Извлекаем userID из токена, отозванные токены не принимаются
+	userID, _, err := h.Tokens.Authenticate(token)
 	if err != nil {
 		log.Printf("Ошибка валидации токена для соединения с %s: %v", r.RemoteAddr, err)
-		if err := conn.WriteJSON(map[string]string{"error": "Недействительный или истёкший токен"}); err != nil {
-			log.Printf("Не удалось отправить ошибку недействительного токена клиенту: %v", err)
-		}
+		writeHandshakeError(conn, dto.WSErrorUnauthorized, "Недействительный или истёкший токен")
 		return
 	}
-	log.Printf("Пользователь аутентифицирован, userID: %s", userID)
+	log.Printf("Пользователь аутентифицирован, userID: %d", userID)
+
+	// Язык описаний из параметра lang, иначе из профиля пользователя
+	opts := DescribeOptions{Audience: query.Get("audience")}
+	if lang := query.Get("lang"); lang != "" {
+		if opts.Language, err = normalizeLanguage(lang); err != nil {
+			writeHandshakeError(conn, dto.WSErrorInvalidMessage, "Некорректный код языка")
+			return
+		}
+	}
+	// Радиус геозоны из параметра geofence, в метрах
+	radius := 0.0
+	if value := query.Get("geofence"); value != "" {
+		if radius, err = strconv.ParseFloat(value, 64); err != nil || !(radius > 0) {
+			writeHandshakeError(conn, dto.WSErrorInvalidMessage, "Некорректный радиус геозоны")
+			return
+		}
+	}
+
+	session := h.newWSSession(conn, userID, opts, radius)
+	defer session.close()
 
-	// Цикл обработки сообщений
+	// Сообщения с объектами ограничены так же, как тело HTTP-запроса; при превышении соединение закрывается с кодом 1009
+	conn.SetReadLimit(osm.MaxInputBytes())
+
+	// Цикл чтения сообщений; обработка запросов идет в горутинах сессии
 	for {
-		var osmObjects []dto.OSMObject
-		log.Printf("Ожидание сообщения от userID: %s", userID)
-		err := conn.ReadJSON(&osmObjects)
+		_, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("Неожиданное отключение клиента для userID: %s, ошибка: %v", userID, err)
+			if errors.Is(err, websocket.ErrReadLimit) {
+				log.Printf("Сообщение от userID: %d больше %d байт, соединение закрыто", userID, osm.MaxInputBytes())
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("Неожиданное отключение клиента для userID: %d, ошибка: %v", userID, err)
 			} else {
-				log.Printf("Ошибка чтения JSON от userID: %s, ошибка: %v", userID, err)
+				log.Printf("Ошибка чтения сообщения от userID: %d, ошибка: %v", userID, err)
 			}
 			break
 		}
-		log.Printf("Получено %d OSM-объектов от userID: %s", len(osmObjects), userID)
-
-		// Канал для получения результатов обработки
-		resultChan := make(chan map[string]interface{})
-		log.Printf("Создан канал результатов для обработки OSM-объектов для userID: %s", userID)
-
-		// Запускаем обработку в отдельной горутине
-		go func() {
-			defer close(resultChan)
-			log.Printf("Запуск StreamProcessJSON для userID: %s с %d OSM-объектами", userID, len(osmObjects))
-			startProcess := time.Now()
-			h.PlaceService.StreamProcessJSON(userID, osmObjects, resultChan)
-			log.Printf("StreamProcessJSON завершён для userID: %s, время: %v", userID, time.Since(startProcess))
-		}()
-
-		// Отправляем результаты по мере их поступления
-		for result := range resultChan {
-			// Сериализуем результат в JSON для подсчёта размера
-			resultBytes, err := json.Marshal(result)
-			if err != nil {
-				log.Printf("Ошибка сериализации результата для userID: %s, ошибка: %v", userID, err)
-				continue
-			}
-			log.Printf("Отправка результата userID: %s, размер результата: %d байт", userID, len(resultBytes))
-			if err := conn.WriteJSON(result); err != nil {
-				log.Printf("Ошибка отправки результата userID: %s, ошибка: %v", userID, err)
-				break
-			}
-			log.Printf("Результат успешно отправлен userID: %s", userID)
-		}
+		session.dispatch(message)
+	}
+	log.Printf("WebSocket-соединение закрыто для userID: %d, удалённый адрес: %s", userID, r.RemoteAddr)
+}
+
+// writeHandshakeError отправляет ошибку до начала сессии, после нее соединение закрывается
+func writeHandshakeError(conn *websocket.Conn, code, message string) {
+	data, _ := json.Marshal(dto.WSError{Code: code, Message: message})
+	envelope := dto.WSEnvelope{Version: dto.WSProtocolVersion, Type: dto.WSMessageError, Payload: data}
+	if err := conn.WriteJSON(envelope); err != nil {
+		log.Printf("Не удалось отправить ошибку клиенту: %v", err)
 	}
-	log.Printf("WebSocket-соединение закрыто для userID: %s, удалённый адрес: %s", userID, r.RemoteAddr)
 }
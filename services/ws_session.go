@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"new/dto"
+	"new/services/osm"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsSession — состояние одного WebSocket-соединения: запросы в работе, геозоны и запись в соединение.
+// Сообщения читаются в одной горутине, а обрабатываются и отправляются из нескольких
+type wsSession struct {
+	handler *WebSocketHandler
+	conn    *websocket.Conn
+	userID  uint
+	opts    DescribeOptions
+	fence   *Geofence
+
+	ctx      context.Context // Отменяется при закрытии соединения
+	stop     context.CancelFunc
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	requests map[string]context.CancelFunc // Выполняющиеся запросы process по request_id
+	wg       sync.WaitGroup
+
+	narrating    bool            // Идет проверка геозон; защищено mu
+	nextLocation *locationUpdate // Последнее положение, пришедшее во время проверки; защищено mu
+}
+
+// locationUpdate — сообщение location с request_id
+type locationUpdate struct {
+	requestID string
+	update    dto.LocationUpdate
+}
+
+// newWSSession создает сессию соединения. Язык описаний определяется один раз,
+// чтобы названия мест в геозонах и описания совпадали
+func (h *WebSocketHandler) newWSSession(conn *websocket.Conn, userID uint, opts DescribeOptions, geofenceRadius float64) *wsSession {
+	ctx, stop := context.WithCancel(context.Background())
+	opts.Language = h.PlaceService.requestLanguage(ctx, userID, opts.Language)
+	return &wsSession{
+		handler:  h,
+		conn:     conn,
+		userID:   userID,
+		opts:     opts,
+		fence:    NewGeofence(geofenceRadius),
+		ctx:      ctx,
+		stop:     stop,
+		requests: make(map[string]context.CancelFunc),
+	}
+}
+
+// send отправляет сообщение в конверте. Соединение допускает только одного писателя, поэтому запись под мьютексом
+func (s *wsSession) send(messageType, requestID string, payload interface{}) error {
+	envelope := dto.WSEnvelope{Version: dto.WSProtocolVersion, Type: messageType, RequestID: requestID}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации сообщения %s: %v", messageType, err)
+		}
+		envelope.Payload = data
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteJSON(envelope); err != nil {
+		return fmt.Errorf("ошибка отправки сообщения %s: %v", messageType, err)
+	}
+	return nil
+}
+
+// sendError отправляет ошибку с кодом; ошибки отправки только логируются
+func (s *wsSession) sendError(requestID, code, message string, elements interface{}) {
+	if err := s.send(dto.WSMessageError, requestID, dto.WSError{Code: code, Message: message, Elements: elements}); err != nil {
+		log.Printf("Не удалось отправить ошибку userID: %d: %v", s.userID, err)
+	}
+}
+
+// dispatch разбирает сообщение клиента и передает его обработчику по типу.
+// Сообщение без конверта — массив объектов, {"json_data": [...]}, Overpass JSON или OSM XML — считается запросом process
+func (s *wsSession) dispatch(message []byte) {
+	var envelope dto.WSEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil || envelope.Type == "" {
+		s.process("", message)
+		return
+	}
+	if envelope.Version != 0 && envelope.Version != dto.WSProtocolVersion {
+		s.sendError(envelope.RequestID, dto.WSErrorUnsupportedVersion,
+			fmt.Sprintf("версия протокола %d не поддерживается, текущая — %d", envelope.Version, dto.WSProtocolVersion), nil)
+		return
+	}
+
+	switch envelope.Type {
+	case dto.WSMessageProcess:
+		s.process(envelope.RequestID, envelope.Payload)
+	case dto.WSMessageCancel:
+		s.cancel(envelope.RequestID)
+	case dto.WSMessagePing:
+		if err := s.send(dto.WSMessagePong, envelope.RequestID, envelope.Payload); err != nil {
+			log.Printf("userID: %d: %v", s.userID, err)
+		}
+	case dto.WSMessageLocation:
+		s.location(envelope.RequestID, envelope.Payload)
+	case dto.WSMessagePlaces:
+		s.places(envelope.RequestID, envelope.Payload)
+	default:
+		s.sendError(envelope.RequestID, dto.WSErrorUnknownType, "неизвестный тип сообщения: "+envelope.Type, nil)
+	}
+}
+
+// decodeObjects разбирает объекты OSM из payload. XML передается JSON-строкой
+func decodeObjects(payload []byte) ([]dto.OSMObject, error) {
+	var text string
+	if json.Unmarshal(payload, &text) == nil {
+		payload = []byte(text)
+	}
+	return osm.Decode("", payload)
+}
+
+// sendDecodeError сообщает об ошибке разбора объектов вместе с ошибками отдельных элементов
+func (s *wsSession) sendDecodeError(requestID string, err error) {
+	var validationErr *osm.ValidationError
+	if errors.As(err, &validationErr) {
+		s.sendError(requestID, dto.WSErrorInvalidPayload, err.Error(), validationErr.Elements)
+		return
+	}
+	s.sendError(requestID, dto.WSErrorInvalidPayload, err.Error(), nil)
+}
+
+// process запускает описание объектов. Результаты, ход обработки и итог отправляются с request_id запроса
+func (s *wsSession) process(requestID string, payload []byte) {
+	osmObjects, err := decodeObjects(payload)
+	if err != nil {
+		log.Printf("Некорректные данные от userID: %d, ошибка: %v", s.userID, err)
+		s.sendDecodeError(requestID, err)
+		return
+	}
+	log.Printf("Получено %d OSM-объектов от userID: %d, request_id: %q", len(osmObjects), s.userID, requestID)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	if requestID != "" {
+		s.mu.Lock()
+		if _, running := s.requests[requestID]; running {
+			s.mu.Unlock()
+			cancel()
+			s.sendError(requestID, dto.WSErrorDuplicateRequest, "запрос с таким request_id уже выполняется", nil)
+			return
+		}
+		s.requests[requestID] = cancel
+		s.mu.Unlock()
+	}
+
+	// Описываемые сейчас места запоминаются в сессии и при приближении не рассказываются повторно
+	_, claimed := s.fence.AddInProgress(osmObjects, s.opts.Language)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			if requestID != "" {
+				s.mu.Lock()
+				delete(s.requests, requestID)
+				s.mu.Unlock()
+			}
+			cancel()
+		}()
+		s.stream(ctx, requestID, osmObjects, claimed)
+	}()
+}
+
+// stream передает объекты в StreamProcessJSON и пересылает результаты клиенту.
+// Отмена ctx прерывает обработку: обращения к LLM и TTS отменяются, а StreamProcessJSON закрывает канал.
+// Занятые места геозон освобождаются по мере готовности; не описанные успешно снова доступны для рассказа
+func (s *wsSession) stream(ctx context.Context, requestID string, osmObjects []dto.OSMObject, claimed []string) {
+	pending := make(map[string]bool, len(claimed))
+	for _, key := range claimed {
+		pending[key] = true
+	}
+	defer func() {
+		for key := range pending {
+			s.fence.Finish(key, false)
+		}
+	}()
+
+	resultChan := make(chan dto.PlaceResult)
+	go func() {
+		defer close(resultChan)
+		startProcess := time.Now()
+		s.handler.PlaceService.StreamProcessJSON(ctx, s.userID, s.opts, osmObjects, resultChan)
+		log.Printf("StreamProcessJSON завершён для userID: %d, время: %v", s.userID, time.Since(startProcess))
+	}()
+
+	done := dto.WSDone{Total: len(osmObjects)}
+	if err := s.send(dto.WSMessageProgress, requestID, dto.WSProgress{Total: done.Total}); err != nil {
+		log.Printf("userID: %d: %v", s.userID, err)
+	}
+	for result := range resultChan {
+		if ctx.Err() != nil {
+			continue
+		}
+		done.Completed++
+		if key := result.OSMType + "/" + result.PlaceID; pending[key] {
+			delete(pending, key)
+			s.fence.Finish(key, result.Status == dto.PlaceStatusSuccess)
+		} else if result.Status == dto.PlaceStatusSuccess {
+			s.fence.MarkNarrated(result.OSMType, result.PlaceID)
+		}
+		if result.Status == dto.PlaceStatusSkipped {
+			done.Skipped++
+		} else if result.Status != dto.PlaceStatusSuccess {
+			done.Failed++
+		}
+		if err := s.send(dto.WSMessageResult, requestID, result); err != nil {
+			log.Printf("userID: %d: %v", s.userID, err)
+			continue
+		}
+		if err := s.send(dto.WSMessageProgress, requestID, dto.WSProgress{Completed: done.Completed, Total: done.Total}); err != nil {
+			log.Printf("userID: %d: %v", s.userID, err)
+		}
+	}
+
+	done.Cancelled = ctx.Err() != nil
+	if err := s.send(dto.WSMessageDone, requestID, done); err != nil {
+		log.Printf("userID: %d: %v", s.userID, err)
+	}
+}
+
+// cancel отменяет выполняющийся запрос process. Итог придет сообщением done с cancelled: true
+func (s *wsSession) cancel(requestID string) {
+	s.mu.Lock()
+	cancel, ok := s.requests[requestID]
+	s.mu.Unlock()
+	if !ok {
+		s.sendError(requestID, dto.WSErrorNotFound, "нет выполняющегося запроса с таким request_id", nil)
+		return
+	}
+	log.Printf("userID: %d отменил запрос %q", s.userID, requestID)
+	cancel()
+}
+
+// location проверяет геозоны для нового положения пользователя. Если он вошел в геозону нового места,
+// рассказ отправляется сообщением narration; иначе ответа нет
+func (s *wsSession) location(requestID string, payload []byte) {
+	var update dto.LocationUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		s.sendError(requestID, dto.WSErrorInvalidPayload, "некорректное сообщение о положении", nil)
+		return
+	}
+	if !(update.Lat >= -90 && update.Lat <= 90 && update.Lon >= -180 && update.Lon <= 180) {
+		s.sendError(requestID, dto.WSErrorInvalidPayload, "координаты вне допустимого диапазона", nil)
+		return
+	}
+
+	// Описание может занять время, поэтому чтение следующих сообщений не ждет его. Одновременно идет
+	// только одна проверка: положения, пришедшие за это время, заменяют друг друга, и проверяется последнее
+	next := &locationUpdate{requestID: requestID, update: update}
+	s.mu.Lock()
+	if s.narrating {
+		s.nextLocation = next
+		s.mu.Unlock()
+		return
+	}
+	s.narrating = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for next != nil {
+			s.narrate(next.requestID, next.update)
+
+			s.mu.Lock()
+			next, s.nextLocation = s.nextLocation, nil
+			if next == nil || s.ctx.Err() != nil {
+				next = nil
+				s.narrating = false
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// narrate проверяет геозоны для положения и отправляет рассказ, если пользователь вошел в геозону нового места
+func (s *wsSession) narrate(requestID string, update dto.LocationUpdate) {
+	event, ok := s.handler.PlaceService.NarrateNearby(s.ctx, s.userID, s.opts, s.fence, update)
+	if !ok {
+		return
+	}
+	log.Printf("userID: %d вошёл в геозону места %s/%s", s.userID, event.Place.OSMType, event.Place.PlaceID)
+	if err := s.send(dto.WSMessageNarration, requestID, event); err != nil {
+		log.Printf("userID: %d: %v", s.userID, err)
+	}
+}
+
+// places добавляет места в геозоны сессии без немедленного описания
+func (s *wsSession) places(requestID string, payload []byte) {
+	osmObjects, err := decodeObjects(payload)
+	if err != nil {
+		s.sendDecodeError(requestID, err)
+		return
+	}
+	registered := s.fence.Add(osmObjects, s.opts.Language)
+	log.Printf("userID: %d добавил %d мест в геозоны, всего %d", s.userID, registered.Registered, registered.Total)
+	if err := s.send(dto.WSMessageRegistered, requestID, registered); err != nil {
+		log.Printf("userID: %d: %v", s.userID, err)
+	}
+}
+
+// close отменяет запросы сессии и ждет завершения их горутин. Вызывается при отключении клиента
+func (s *wsSession) close() {
+	s.stop()
+	s.wg.Wait()
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"new/controllers"
+	"new/models"
+	"new/services"
+	"new/services/blobstore"
+	"new/services/tts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAudioRouter создает /audio/:id поверх локального хранилища и сохраняет в нем аудио с текстом text
+func newAudioRouter(t *testing.T, text string) (*gin.Engine, *services.AudioCacheService, *models.AudioAsset) {
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	ttsRegistry := tts.NewRegistry()
+	ttsRegistry.Register(fakeTTS{})
+	service := services.NewAudioCacheService(newTestDB(t, &models.AudioAsset{}), store, ttsRegistry)
+	asset, err := service.GetOrCreate(context.Background(), "", text, tts.Options{Format: "mp3"})
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/audio/:id", (&controllers.AudioController{Service: service}).GetAudio)
+	return r, service, asset
+}
+
+// getAudio запрашивает аудио id с заголовками headers (имя, значение, ...)
+func getAudio(r *gin.Engine, id string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/audio/"+id, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetAudio(t *testing.T) {
+	r, _, asset := newAudioRouter(t, "0123456789")
+
+	w := getAudio(r, asset.Hash)
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Fatalf("expected full audio, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"`+asset.Hash+`"` {
+		t.Errorf("unexpected ETag %q", got)
+	}
+	if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("expected Accept-Ranges: bytes, got %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != asset.ContentType {
+		t.Errorf("expected Content-Type %q, got %q", asset.ContentType, got)
+	}
+
+	if w := getAudio(r, "unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown audio: expected 404, got %d", w.Code)
+	}
+}
+
+func TestGetAudioRange(t *testing.T) {
+	r, _, asset := newAudioRouter(t, "0123456789")
+
+	w := getAudio(r, asset.Hash, "Range", "bytes=2-5")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" {
+		t.Fatalf("expected 206 with bytes 2-5, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+		t.Errorf("unexpected Content-Range %q", got)
+	}
+	if w := getAudio(r, asset.Hash, "Range", "bytes=-3"); w.Code != http.StatusPartialContent || w.Body.String() != "789" {
+		t.Errorf("suffix range: expected 206 with last 3 bytes, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = getAudio(r, asset.Hash, "Range", "bytes=20-30")
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("range past the end: expected 416, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes */10" {
+		t.Errorf("unexpected Content-Range for 416: %q", got)
+	}
+}
+
+func TestGetAudioNotModified(t *testing.T) {
+	r, _, asset := newAudioRouter(t, "0123456789")
+
+	w := getAudio(r, asset.Hash, "If-None-Match", `"`+asset.Hash+`"`)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected 304 without body, got %d %q", w.Code, w.Body.String())
+	}
+	if w := getAudio(r, asset.Hash, "If-None-Match", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("other ETag: expected 200, got %d", w.Code)
+	}
+	// Устаревший If-Range отдает файл целиком вместо части
+	if w := getAudio(r, asset.Hash, "Range", "bytes=0-1", "If-Range", `"other"`); w.Code != http.StatusOK || w.Body.Len() != 10 {
+		t.Errorf("stale If-Range: expected full audio, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestGetAudioMissingInStore(t *testing.T) {
+	r, service, asset := newAudioRouter(t, "0123456789")
+	if err := service.Store.Delete(asset.StorageKey); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if w := getAudio(r, asset.Hash); w.Code != http.StatusNotFound {
+		t.Errorf("audio missing in store: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,266 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"new/dto"
+	"new/services"
+	"new/utils"
+
+	"github.com/gorilla/websocket"
+)
+
+// blockingLLM сразу описывает место «Первое», а на остальных ждет отмены контекста
+type blockingLLM struct {
+	started   chan string
+	cancelled int32
+}
+
+func newBlockingLLM() *blockingLLM {
+	return &blockingLLM{started: make(chan string, 16)}
+}
+
+func (b *blockingLLM) Name() string { return "blocking" }
+
+func (b *blockingLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	if place["name"] == "Первое" {
+		return "Описание первого места", nil
+	}
+	b.started <- place["name"]
+	<-ctx.Done()
+	atomic.AddInt32(&b.cancelled, 1)
+	return "", ctx.Err()
+}
+
+func (b *blockingLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+// waitStarted ждет, пока LLM начнет описывать место, на котором заблокируется
+func (b *blockingLLM) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-b.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("LLM was not called")
+	}
+}
+
+// waitCancelled ждет, пока заблокированный вызов LLM получит отмену
+func (b *blockingLLM) waitCancelled(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&b.cancelled) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("LLM call was not cancelled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForGoroutines ждет, пока число горутин вернется к исходному, и выводит стеки, если этого не произошло
+func waitForGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("goroutines leaked: %d > %d\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func streamObjects() []dto.OSMObject {
+	return []dto.OSMObject{
+		{ID: 1, Type: "node", Tags: map[string]string{"name": "Первое"}, Lat: 59.93, Lon: 30.30},
+		{ID: 2, Type: "node", Tags: map[string]string{"name": "Второе"}, Lat: 59.93, Lon: 30.31},
+		{ID: 3, Type: "node", Tags: map[string]string{"name": "Третье"}, Lat: 59.93, Lon: 30.32},
+	}
+}
+
+func TestStreamProcessJSONReturnsWhenReaderGoesAway(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	blocking := newBlockingLLM()
+	service := newTestPlaceService(blocking)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resultChan := make(chan dto.PlaceResult)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		service.StreamProcessJSON(ctx, 0, services.DescribeOptions{}, streamObjects(), resultChan)
+	}()
+
+	if result := <-resultChan; result.Status != dto.PlaceStatusSuccess {
+		t.Fatalf("expected the first place to succeed, got %+v", result)
+	}
+	blocking.waitStarted(t)
+
+	// Читатель пропадает: канал больше никто не читает, контекст отменяется
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("StreamProcessJSON did not return after cancellation")
+	}
+	blocking.waitCancelled(t)
+	waitForGoroutines(t, baseline)
+}
+
+func TestProcessJSONNoAuthStopsOnCancel(t *testing.T) {
+	blocking := newBlockingLLM()
+	service := newTestPlaceService(blocking)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		blocking.waitStarted(t)
+		cancel()
+	}()
+	if _, err := service.ProcessJSONNoAuth(ctx, services.DescribeOptions{}, streamObjects()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if n := atomic.LoadInt32(&blocking.cancelled); n != 1 {
+		t.Errorf("expected exactly one cancelled LLM call, got %d", n)
+	}
+}
+
+func TestCoalescerCancelsAbandonedGeneration(t *testing.T) {
+	var c services.Coalescer
+	lookup := func(context.Context) (string, bool, error) { return "", false, nil }
+	started := make(chan struct{})
+	generationErr := make(chan error, 1)
+	blocked := func(ctx context.Context) (string, error) {
+		close(started)
+		<-ctx.Done()
+		generationErr <- ctx.Err()
+		return "", ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, _, err := c.Do(ctx, "key", lookup, blocked); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case err := <-generationErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected generation error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("generation nobody waits for must be cancelled")
+	}
+
+	// Следующий вызов с тем же ключом не присоединяется к отмененной генерации
+	value, shared, err := c.Do(context.Background(), "key", lookup, func(context.Context) (string, error) { return "ok", nil })
+	if err != nil || value != "ok" || shared {
+		t.Errorf("expected a fresh generation, got %q %v %v", value, shared, err)
+	}
+}
+
+// dialAuthenticated подключается к WebSocket с действительным токеном пользователя
+func dialAuthenticated(t *testing.T, service *services.PlaceService) (*websocket.Conn, *httptest.Server) {
+	t.Helper()
+	withTestKeyring(t)
+	token, _, err := utils.GenerateAccessToken(1, "user", "family")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	handler := services.NewWebSocketHandler(service, &services.TokenService{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?token="+token, nil)
+	if err != nil {
+		server.Close()
+		t.Fatalf("dial: %v", err)
+	}
+	return conn, server
+}
+
+// sendProcess отправляет запрос process с объектами streamObjects
+func sendProcess(t *testing.T, conn *websocket.Conn, requestID string) {
+	t.Helper()
+	payload, _ := json.Marshal(streamObjects())
+	if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessageProcess, RequestID: requestID, Payload: payload}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// readUntil читает сообщения, пока не придет сообщение типа messageType
+func readUntil(t *testing.T, conn *websocket.Conn, messageType string) dto.WSEnvelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		var envelope dto.WSEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			t.Fatalf("waiting for %s: %v", messageType, err)
+		}
+		if envelope.Type == messageType {
+			return envelope
+		}
+	}
+}
+
+func TestWebSocketClientDropCancelsProcessing(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	blocking := newBlockingLLM()
+	conn, server := dialAuthenticated(t, newTestPlaceService(blocking))
+
+	sendProcess(t, conn, "r1")
+	result := readUntil(t, conn, dto.WSMessageResult)
+	if result.RequestID != "r1" {
+		t.Errorf("expected request_id r1, got %q", result.RequestID)
+	}
+	blocking.waitStarted(t)
+
+	// Клиент пропадает посреди потока результатов
+	conn.Close()
+	blocking.waitCancelled(t)
+	server.Close()
+	waitForGoroutines(t, baseline)
+}
+
+func TestWebSocketCancelMessage(t *testing.T) {
+	blocking := newBlockingLLM()
+	conn, server := dialAuthenticated(t, newTestPlaceService(blocking))
+	defer server.Close()
+	defer conn.Close()
+
+	sendProcess(t, conn, "r1")
+	readUntil(t, conn, dto.WSMessageResult)
+	blocking.waitStarted(t)
+
+	if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessageCancel, RequestID: "r1"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	done := readUntil(t, conn, dto.WSMessageDone)
+	var payload dto.WSDone
+	if err := json.Unmarshal(done.Payload, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if !payload.Cancelled || payload.Completed != 1 || payload.Total != 3 || done.RequestID != "r1" {
+		t.Errorf("unexpected done %+v for %q", payload, done.RequestID)
+	}
+	blocking.waitCancelled(t)
+
+	// Отмененный запрос больше не выполняется
+	if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessageCancel, RequestID: "r1"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	errEnvelope := readUntil(t, conn, dto.WSMessageError)
+	var wsErr dto.WSError
+	if err := json.Unmarshal(errEnvelope.Payload, &wsErr); err != nil || wsErr.Code != dto.WSErrorNotFound {
+		t.Errorf("expected not_found, got %+v (%v)", wsErr, err)
+	}
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"new/dto"
+	"new/models"
+	"new/services"
+
+	"gorm.io/gorm"
+)
+
+func newCatalogService(t *testing.T) *services.ListPreferenceService {
+	t.Helper()
+	return &services.ListPreferenceService{DB: newTestDB(t, &models.ListPreference{}, &models.ListPreferenceTranslation{})}
+}
+
+// createCatalogEntry добавляет элемент каталога и проверяет, что это удалось
+func createCatalogEntry(t *testing.T, service *services.ListPreferenceService, input dto.CreateListPreferenceDTO) *models.ListPreference {
+	t.Helper()
+	item, err := service.Create(input)
+	if err != nil {
+		t.Fatalf("Create %q: %v", input.Name, err)
+	}
+	return item
+}
+
+// catalogIDs возвращает идентификаторы каталога в порядке выдачи
+func catalogIDs(items []models.ListPreference) []uint {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestCatalogCreate(t *testing.T) {
+	service := newCatalogService(t)
+	museums := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{
+		Name:         " Музеи ",
+		OSMTags:      "tourism=museum",
+		Translations: map[string]string{"EN": "Museums"},
+	})
+	parks := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Парки"})
+
+	if museums.Name != "Музеи" || museums.OSMTags != "tourism=museum" {
+		t.Errorf("unexpected entry %+v", museums)
+	}
+	if len(museums.Translations) != 1 || museums.Translations[0].Language != "en" {
+		t.Errorf("expected a normalized en translation, got %+v", museums.Translations)
+	}
+	// Без позиции элемент добавляется в конец
+	if parks.Position != museums.Position+1 {
+		t.Errorf("expected position %d, got %d", museums.Position+1, parks.Position)
+	}
+
+	catalog, err := service.Catalog("en-US")
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	if len(catalog) != 2 || catalog[0].Name != "Museums" || catalog[1].Name != "Парки" {
+		t.Errorf("unexpected localized catalog %+v", catalog)
+	}
+}
+
+func TestCatalogCreateRejectsInvalidInput(t *testing.T) {
+	service := newCatalogService(t)
+	cases := []struct {
+		input dto.CreateListPreferenceDTO
+		want  error
+	}{
+		{dto.CreateListPreferenceDTO{Name: "  "}, services.ErrCatalogNameRequired},
+		{dto.CreateListPreferenceDTO{Name: "Музеи", OSMTags: "=museum"}, services.ErrInvalidTags},
+		{dto.CreateListPreferenceDTO{Name: "Музеи", Translations: map[string]string{"not a language": "x"}}, services.ErrInvalidLanguage},
+	}
+	for _, c := range cases {
+		if _, err := service.Create(c.input); !errors.Is(err, c.want) {
+			t.Errorf("%+v: expected %v, got %v", c.input, c.want, err)
+		}
+	}
+}
+
+func TestCatalogRejectsDuplicateNames(t *testing.T) {
+	service := newCatalogService(t)
+	// Латиница: LOWER в SQLite не меняет регистр кириллицы, в отличие от Postgres
+	createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Museums"})
+	parks := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Parks"})
+
+	if _, err := service.Create(dto.CreateListPreferenceDTO{Name: "museums"}); !errors.Is(err, services.ErrCatalogNameTaken) {
+		t.Errorf("expected ErrCatalogNameTaken for a case-insensitive duplicate, got %v", err)
+	}
+	if _, err := service.Rename(parks.ID, "MUSEUMS"); !errors.Is(err, services.ErrCatalogNameTaken) {
+		t.Errorf("expected ErrCatalogNameTaken on rename, got %v", err)
+	}
+	if _, err := service.Rename(parks.ID, " "); !errors.Is(err, services.ErrCatalogNameRequired) {
+		t.Errorf("expected ErrCatalogNameRequired on rename, got %v", err)
+	}
+	// Переименование в собственное название в другом регистре допустимо
+	if renamed, err := service.Rename(parks.ID, "PARKS"); err != nil || renamed.Name != "PARKS" {
+		t.Errorf("rename to own name: %+v %v", renamed, err)
+	}
+}
+
+func TestCatalogReorder(t *testing.T) {
+	service := newCatalogService(t)
+	a := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "A"})
+	b := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "B"})
+	c := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "C"})
+
+	// Указанные элементы идут первыми, остальные сохраняют прежний порядок; повторы игнорируются
+	items, err := service.Reorder([]uint{c.ID, a.ID, c.ID})
+	if err != nil {
+		t.Fatalf("Reorder: %v", err)
+	}
+	want := []uint{c.ID, a.ID, b.ID}
+	got := catalogIDs(items)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] || items[i].Position != i {
+			t.Fatalf("expected %v with positions 0..2, got %v (%+v)", want, got, items)
+		}
+	}
+
+	if _, err := service.Reorder([]uint{b.ID, 999}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for an unknown id, got %v", err)
+	}
+	items, _ = service.List()
+	if got := catalogIDs(items); got[0] != c.ID {
+		t.Errorf("failed reorder must not change the order, got %v", got)
+	}
+}
+
+func TestCatalogRetireAndRestore(t *testing.T) {
+	service := newCatalogService(t)
+	museums := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Музеи"})
+	createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Парки"})
+
+	retired, err := service.Retire(museums.ID)
+	if err != nil || retired.RetiredAt == nil {
+		t.Fatalf("Retire: %+v %v", retired, err)
+	}
+	catalog, _ := service.Catalog("")
+	if len(catalog) != 1 || catalog[0].Name != "Парки" {
+		t.Errorf("retired entry must leave the catalog, got %+v", catalog)
+	}
+	// Администратор по-прежнему видит выведенный элемент
+	if items, _ := service.List(); len(items) != 2 {
+		t.Errorf("List must include retired entries, got %d", len(items))
+	}
+
+	if _, err := service.Restore(museums.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if catalog, _ := service.Catalog(""); len(catalog) != 2 {
+		t.Errorf("restored entry must return to the catalog, got %+v", catalog)
+	}
+
+	if _, err := service.Retire(999); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestCatalogNameRaceReportsNameTaken(t *testing.T) {
+	service := newCatalogService(t)
+	parks := createCatalogEntry(t, service, dto.CreateListPreferenceDTO{Name: "Parks"})
+
+	// Параллельный запрос занимает название между проверкой и записью
+	race := func(tx *gorm.DB) {
+		if tx.Statement.Table == "list_preferences" {
+			tx.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO list_preferences (name, position, osm_tags) VALUES (?, 0, '')", "Museums")
+		}
+	}
+	if err := service.DB.Callback().Create().Before("gorm:create").Register("test:race", race); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	if _, err := service.Create(dto.CreateListPreferenceDTO{Name: "Museums"}); !errors.Is(err, services.ErrCatalogNameTaken) {
+		t.Errorf("create: expected ErrCatalogNameTaken, got %v", err)
+	}
+	if err := service.DB.Callback().Create().Remove("test:race"); err != nil {
+		t.Fatalf("remove callback: %v", err)
+	}
+
+	if err := service.DB.Callback().Update().Before("gorm:update").Register("test:race", race); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	if _, err := service.Rename(parks.ID, "Museums"); !errors.Is(err, services.ErrCatalogNameTaken) {
+		t.Errorf("rename: expected ErrCatalogNameTaken, got %v", err)
+	}
+}
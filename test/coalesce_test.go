@@ -0,0 +1,198 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"new/database"
+	"new/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// withRedis подключает database.RedisClient к miniredis на время теста
+func withRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	m := miniredis.RunT(t)
+	database.RedisClient = redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() {
+		database.RedisClient.Close()
+		database.RedisClient = nil
+	})
+	return m
+}
+
+// memoryCache — кеш, в который генерация сохраняет результат, а lookup его ищет
+type memoryCache struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (c *memoryCache) lookup(context.Context) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, c.value != "", nil
+}
+
+func (c *memoryCache) store(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = value
+}
+
+// runConcurrently вызывает Do из n горутин одновременно и возвращает полученные значения
+func runConcurrently(t *testing.T, coalescers []*services.Coalescer, n int, cache *memoryCache, generate func(context.Context) (string, error)) []string {
+	t.Helper()
+	values := make([]string, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			value, _, err := coalescers[i%len(coalescers)].Do(context.Background(), "place:1", cache.lookup, generate)
+			if err != nil {
+				t.Errorf("Do: %v", err)
+			}
+			values[i] = value
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return values
+}
+
+// countingGenerate считает вызовы, ждет delay и сохраняет результат в cache
+func countingGenerate(calls *int32, delay time.Duration, cache *memoryCache) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		cache.store("описание")
+		return "описание", nil
+	}
+}
+
+func TestCoalescerGeneratesOnceInProcess(t *testing.T) {
+	var calls int32
+	cache := &memoryCache{}
+	values := runConcurrently(t, []*services.Coalescer{{}}, 20, cache, countingGenerate(&calls, 50*time.Millisecond, cache))
+
+	if calls != 1 {
+		t.Errorf("expected one generation, got %d", calls)
+	}
+	for i, value := range values {
+		if value != "описание" {
+			t.Errorf("caller %d got %q", i, value)
+		}
+	}
+}
+
+func TestCoalescerGeneratesOnceAcrossInstances(t *testing.T) {
+	withRedis(t)
+	var calls int32
+	cache := &memoryCache{}
+	// Два экземпляра сервера с общим Redis
+	instances := []*services.Coalescer{{PollDelay: 10 * time.Millisecond}, {PollDelay: 10 * time.Millisecond}}
+	values := runConcurrently(t, instances, 20, cache, countingGenerate(&calls, 100*time.Millisecond, cache))
+
+	if calls != 1 {
+		t.Errorf("expected one generation, got %d", calls)
+	}
+	for i, value := range values {
+		if value != "описание" {
+			t.Errorf("caller %d got %q", i, value)
+		}
+	}
+}
+
+func TestCoalescerHoldsAndReleasesLock(t *testing.T) {
+	m := withRedis(t)
+	c := &services.Coalescer{LockTTL: 300 * time.Millisecond}
+	lookup := func(context.Context) (string, bool, error) { return "", false, nil }
+
+	_, _, err := c.Do(context.Background(), "place:1", lookup, func(context.Context) (string, error) {
+		if !m.Exists("lock:place:1") {
+			t.Error("lock must be held during generation")
+		}
+		// Время в Redis идет почти до истечения блокировки, а генерация продолжается
+		m.FastForward(250 * time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+		if ttl := m.TTL("lock:place:1"); ttl <= 50*time.Millisecond {
+			t.Errorf("lock must be extended, ttl %v", ttl)
+		}
+		m.FastForward(200 * time.Millisecond)
+		if !m.Exists("lock:place:1") {
+			t.Error("extended lock must not expire during generation")
+		}
+		return "описание", nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if m.Exists("lock:place:1") {
+		t.Error("lock must be released after generation")
+	}
+}
+
+func TestCoalescerDoesNotReleaseForeignLock(t *testing.T) {
+	m := withRedis(t)
+	var c services.Coalescer
+	lookup := func(context.Context) (string, bool, error) { return "", false, nil }
+
+	_, _, err := c.Do(context.Background(), "place:1", lookup, func(context.Context) (string, error) {
+		// Блокировка истекла и ее захватил другой экземпляр
+		m.Set("lock:place:1", "other")
+		return "описание", nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if value, _ := m.Get("lock:place:1"); value != "other" {
+		t.Errorf("foreign lock must stay, got %q", value)
+	}
+}
+
+func TestCoalescerWaiterTakesCachedValue(t *testing.T) {
+	m := withRedis(t)
+	m.Set("lock:place:1", "other")
+	cache := &memoryCache{}
+	c := &services.Coalescer{PollDelay: 10 * time.Millisecond}
+
+	go func() {
+		// Другой экземпляр завершает генерацию и снимает блокировку
+		time.Sleep(50 * time.Millisecond)
+		cache.store("чужое описание")
+		m.Del("lock:place:1")
+	}()
+	value, shared, err := c.Do(context.Background(), "place:1", cache.lookup, func(context.Context) (string, error) {
+		t.Error("waiter must not generate")
+		return "", nil
+	})
+	if err != nil || value != "чужое описание" || !shared {
+		t.Errorf("expected the cached value, got %q %v %v", value, shared, err)
+	}
+}
+
+func TestCoalescerWaiterGeneratesWhenOwnerGivesUp(t *testing.T) {
+	m := withRedis(t)
+	m.Set("lock:place:1", "other")
+	c := &services.Coalescer{PollDelay: 10 * time.Millisecond}
+	lookup := func(context.Context) (string, bool, error) { return "", false, nil }
+
+	go func() {
+		// Владелец блокировки завершился без результата
+		time.Sleep(50 * time.Millisecond)
+		m.Del("lock:place:1")
+	}()
+	value, shared, err := c.Do(context.Background(), "place:1", lookup, func(context.Context) (string, error) {
+		return "свое описание", nil
+	})
+	if err != nil || value != "свое описание" || shared {
+		t.Errorf("expected own generation, got %q %v %v", value, shared, err)
+	}
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"new/dto"
+	"new/services"
+	"new/services/geo"
+)
+
+func node(id int64, lat, lon float64) dto.OSMObject {
+	return dto.OSMObject{ID: id, Type: "node", Lat: lat, Lon: lon}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestResolveClosedWayAsPolygon(t *testing.T) {
+	objects := []dto.OSMObject{
+		node(1, 0, 0), node(2, 0, 2), node(3, 2, 2), node(4, 2, 0),
+		{ID: 10, Type: "way", Nodes: []int64{1, 2, 3, 4, 1}, Tags: map[string]string{"building": "yes"}},
+	}
+	shape := geo.NewIndex(objects).Resolve(objects[4])
+	if shape == nil || shape.Type() != geo.TypePolygon {
+		t.Fatalf("expected polygon, got %+v", shape)
+	}
+	if c := shape.Centroid(); !near(c.Lat, 1) || !near(c.Lon, 1) {
+		t.Errorf("unexpected centroid %+v", c)
+	}
+	if box := shape.BBox(); box != (geo.BBox{MinLat: 0, MinLon: 0, MaxLat: 2, MaxLon: 2}) {
+		t.Errorf("unexpected bbox %+v", box)
+	}
+	geometry := shape.GeoJSON()
+	rings := geometry.Coordinates.([][][2]float64)
+	if len(rings) != 1 || len(rings[0]) != 5 || rings[0][1] != [2]float64{2, 0} {
+		t.Errorf("coordinates must be [lon, lat]: %v", rings)
+	}
+}
+
+func TestRepresentativePointInsideConcavePolygon(t *testing.T) {
+	// Фигура в форме буквы U: центроид попадает в вырез
+	u := []geo.Point{
+		{Lat: 0, Lon: 0}, {Lat: 0, Lon: 3}, {Lat: 3, Lon: 3}, {Lat: 3, Lon: 2},
+		{Lat: 1, Lon: 2}, {Lat: 1, Lon: 1}, {Lat: 3, Lon: 1}, {Lat: 3, Lon: 0}, {Lat: 0, Lon: 0},
+	}
+	shape := &geo.Shape{Polygons: []geo.Polygon{{u}}}
+
+	c := shape.Centroid()
+	if !(c.Lon > 1 && c.Lon < 2 && c.Lat > 1) {
+		t.Fatalf("centroid expected inside the notch, got %+v", c)
+	}
+	p := shape.RepresentativePoint()
+	inBase := p.Lat > 0 && p.Lat < 1 && p.Lon > 0 && p.Lon < 3
+	inArm := p.Lat > 0 && p.Lat < 3 && (p.Lon > 0 && p.Lon < 1 || p.Lon > 2 && p.Lon < 3)
+	if !inBase && !inArm {
+		t.Errorf("representative point %+v is outside the polygon", p)
+	}
+}
+
+func TestResolveOpenWayAndFallbacks(t *testing.T) {
+	objects := []dto.OSMObject{
+		node(1, 0, 0), node(2, 0, 4),
+		{ID: 10, Type: "way", Nodes: []int64{1, 2}},
+		// Узла 99 нет в массиве — используется geometry из out geom
+		{ID: 11, Type: "way", Nodes: []int64{1, 99}, Geometry: []dto.Coordinates{{Lat: 5, Lon: 5}, {Lat: 5, Lon: 7}}},
+		{ID: 12, Type: "way", Nodes: []int64{98, 99}, Center: &dto.Coordinates{Lat: 7, Lon: 8}},
+		{ID: 13, Type: "way", Nodes: []int64{98, 99}},
+	}
+	index := geo.NewIndex(objects)
+
+	line := index.Resolve(objects[2])
+	if line.Type() != geo.TypeLineString {
+		t.Fatalf("expected linestring, got %s", line.Type())
+	}
+	if p := line.RepresentativePoint(); !near(p.Lat, 0) || !near(p.Lon, 2) {
+		t.Errorf("expected middle of the line, got %+v", p)
+	}
+	if p := index.Resolve(objects[3]).RepresentativePoint(); !near(p.Lat, 5) || !near(p.Lon, 6) {
+		t.Errorf("expected geometry fallback, got %+v", p)
+	}
+	if shape := index.Resolve(objects[4]); shape.Type() != geo.TypePoint || shape.Points[0].Lat != 7 {
+		t.Errorf("expected center fallback, got %+v", shape)
+	}
+	if shape := index.Resolve(objects[5]); shape != nil {
+		t.Errorf("expected nil for way without coordinates, got %+v", shape)
+	}
+}
+
+func TestResolveMultipolygonRelation(t *testing.T) {
+	objects := []dto.OSMObject{
+		node(1, 0, 0), node(2, 0, 4), node(3, 4, 4), node(4, 4, 0),
+		node(5, 1, 1), node(6, 1, 2), node(7, 2, 2), node(8, 2, 1),
+		// Внешнее кольцо разбито на два way, второй направлен в обратную сторону
+		{ID: 10, Type: "way", Nodes: []int64{1, 2, 3}},
+		{ID: 11, Type: "way", Nodes: []int64{1, 4, 3}},
+		{ID: 12, Type: "way", Nodes: []int64{5, 6, 7, 8, 5}},
+		{ID: 20, Type: "relation", Tags: map[string]string{"type": "multipolygon"}, Members: []dto.OSMMember{
+			{Type: "way", Ref: 10, Role: "outer"},
+			{Type: "way", Ref: 11, Role: "outer"},
+			{Type: "way", Ref: 12, Role: "inner"},
+			{Type: "relation", Ref: 20},
+		}},
+	}
+	shape := geo.NewIndex(objects).Resolve(objects[11])
+	if shape == nil || shape.Type() != geo.TypePolygon {
+		t.Fatalf("expected polygon, got %+v", shape)
+	}
+	if len(shape.Polygons[0]) != 2 {
+		t.Fatalf("expected outer ring with a hole, got %d rings", len(shape.Polygons[0]))
+	}
+	// Дыра смещает центроид от центра квадрата (2, 2) в сторону от себя
+	if c := shape.Centroid(); !(c.Lat > 2 && c.Lon > 2) {
+		t.Errorf("hole must shift centroid, got %+v", c)
+	}
+	p := shape.RepresentativePoint()
+	if p.Lat > 1 && p.Lat < 2 && p.Lon > 1 && p.Lon < 2 {
+		t.Errorf("representative point %+v is inside the hole", p)
+	}
+}
+
+func TestEmbedMakesObjectSelfContained(t *testing.T) {
+	objects := []dto.OSMObject{
+		node(1, 0, 0), node(2, 0, 2), node(3, 2, 2),
+		{ID: 10, Type: "way", Nodes: []int64{1, 2, 3, 1}},
+		{ID: 20, Type: "relation", Tags: map[string]string{"type": "route"}, Members: []dto.OSMMember{
+			{Type: "node", Ref: 3, Role: "stop"},
+			{Type: "way", Ref: 10},
+		}},
+	}
+	index := geo.NewIndex(objects)
+
+	way := index.Embed(objects[3])
+	if len(way.Geometry) != 4 || len(objects[3].Geometry) != 0 {
+		t.Fatalf("geometry must be copied into the way only: %+v", way.Geometry)
+	}
+	relation := index.Embed(objects[4])
+	if relation.Members[0].Lat != 2 || len(relation.Members[1].Geometry) != 4 || objects[4].Members[0].Lat != 0 {
+		t.Fatalf("unexpected members: %+v", relation.Members)
+	}
+
+	// Отдельно от массива объект разрешается так же, как и в нем
+	alone := geo.NewIndex([]dto.OSMObject{relation}).Resolve(relation)
+	if alone == nil || alone.Type() != geo.TypeGeometryCollection {
+		t.Fatalf("expected geometry collection, got %+v", alone)
+	}
+	if len(alone.GeoJSON().Geometries) != 2 {
+		t.Errorf("expected point and line geometries, got %+v", alone.GeoJSON())
+	}
+}
+
+func TestPlaceResultsHaveCoordinatesForWays(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{})
+	objects := []dto.OSMObject{
+		node(1, 59.93, 30.31), node(2, 59.93, 30.32), node(3, 59.94, 30.32),
+		{ID: 10, Type: "way", Nodes: []int64{1, 2, 3, 1}, Tags: map[string]string{"name": "Сквер"}},
+		{ID: 11, Type: "way", Nodes: []int64{1, 2}, Tags: map[string]string{}},
+	}
+
+	results, err := service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{}, objects)
+	if err != nil {
+		t.Fatalf("ProcessJSONNoAuth: %v", err)
+	}
+	byID := make(map[string]dto.PlaceResult)
+	for _, r := range results {
+		byID[r.PlaceID] = r
+	}
+
+	square := byID["10"]
+	if square.Coordinates == nil || square.Centroid == nil || len(square.BBox) != 4 {
+		t.Fatalf("way must have coordinates, centroid and bbox: %+v", square)
+	}
+	if square.Geometry == nil || square.Geometry.Type != geo.TypePolygon {
+		t.Errorf("expected polygon geometry, got %+v", square.Geometry)
+	}
+	if square.BBox[0] != 30.31 || square.BBox[3] != 59.94 {
+		t.Errorf("bbox must be [min_lon, min_lat, max_lon, max_lat], got %v", square.BBox)
+	}
+
+	// Пропущенный объект тоже получает точку на карте
+	if skipped := byID["11"]; skipped.Status != dto.PlaceStatusSkipped || skipped.Coordinates == nil {
+		t.Errorf("skipped way must have coordinates: %+v", skipped)
+	}
+	if byID["1"].Geometry != nil {
+		t.Errorf("nodes have no geometry field: %+v", byID["1"].Geometry)
+	}
+}
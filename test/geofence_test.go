@@ -0,0 +1,287 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"new/dto"
+	"new/services"
+	"new/services/geo"
+)
+
+func TestShapeDistanceTo(t *testing.T) {
+	// Квадрат около 111 × 56 м на широте Петербурга
+	square := &geo.Shape{Polygons: []geo.Polygon{{{
+		{Lat: 59.930, Lon: 30.300}, {Lat: 59.930, Lon: 30.302}, {Lat: 59.931, Lon: 30.302},
+		{Lat: 59.931, Lon: 30.300}, {Lat: 59.930, Lon: 30.300},
+	}}}}
+	if d := square.DistanceTo(geo.Point{Lat: 59.9305, Lon: 30.301}); d != 0 {
+		t.Errorf("point inside the polygon must be at zero distance, got %f", d)
+	}
+	// 0.0002° к северу от верхней стороны — около 22 м, ближе, чем до любой вершины
+	if d := square.DistanceTo(geo.Point{Lat: 59.9312, Lon: 30.301}); math.Abs(d-22.3) > 0.5 {
+		t.Errorf("expected about 22 m to the edge, got %.1f", d)
+	}
+
+	street := &geo.Shape{Lines: [][]geo.Point{{{Lat: 59.93, Lon: 30.30}, {Lat: 59.93, Lon: 30.31}}}}
+	beside := geo.Point{Lat: 59.9301, Lon: 30.305}
+	if d := street.DistanceTo(beside); math.Abs(d-11.1) > 0.5 {
+		t.Errorf("expected about 11 m to the street, got %.1f", d)
+	}
+	// За концом линии расстояние считается до конечной точки
+	beyond := geo.Point{Lat: 59.93, Lon: 30.311}
+	if d, want := street.DistanceTo(beyond), geo.Distance(beyond, geo.Point{Lat: 59.93, Lon: 30.31}); math.Abs(d-want) > 0.5 {
+		t.Errorf("expected %.1f m to the line end, got %.1f", want, d)
+	}
+
+	point := &geo.Shape{Points: []geo.Point{{Lat: 59.93, Lon: 30.30}}}
+	if d := point.DistanceTo(geo.Point{Lat: 59.93, Lon: 30.30}); d != 0 {
+		t.Errorf("expected zero distance to the point itself, got %f", d)
+	}
+}
+
+func sessionPlaces() []dto.OSMObject {
+	monument := node(1, 59.9300, 30.3000)
+	monument.Tags = map[string]string{"name": "Памятник", "historic": "monument"}
+	church := node(2, 59.9302, 30.3000) // Около 22 м к северу от памятника
+	church.Tags = map[string]string{"name": "Церковь", "amenity": "place_of_worship"}
+	unnamed := node(3, 59.9301, 30.3000)
+	unnamed.Tags = map[string]string{"bench": "yes"}
+	return []dto.OSMObject{monument, church, unnamed}
+}
+
+func TestGeofenceNarratesNearestPlaceOnce(t *testing.T) {
+	fence := services.NewGeofence(0)
+	if fence.Radius != services.DefaultGeofenceRadius {
+		t.Fatalf("expected default radius, got %f", fence.Radius)
+	}
+	registered := fence.Add(sessionPlaces(), "ru")
+	if registered != (dto.PlacesRegistered{Registered: 2, Skipped: 1, Total: 2}) {
+		t.Fatalf("unexpected registration %+v", registered)
+	}
+
+	if _, _, ok := fence.Next(geo.Point{Lat: 59.9320, Lon: 30.3000}); ok {
+		t.Fatal("no place is within 30 m of a point 200 m away")
+	}
+
+	// Ближе к памятнику, но в радиусе обоих мест
+	position := geo.Point{Lat: 59.93005, Lon: 30.3000}
+	key, distance, ok := fence.Next(position)
+	if !ok || key != "node/1" || math.Abs(distance-5.6) > 0.5 {
+		t.Fatalf("expected the monument at about 6 m, got %q %.1f %v", key, distance, ok)
+	}
+	if key, _, ok = fence.Next(position); !ok || key != "node/2" {
+		t.Fatalf("expected the church next, got %q %v", key, ok)
+	}
+	if _, _, ok = fence.Next(position); ok {
+		t.Error("every place must be narrated only once")
+	}
+
+	// Повторная передача мест не сбрасывает отметку о рассказе
+	if registered = fence.Add(sessionPlaces(), "ru"); registered.Total != 2 {
+		t.Errorf("places must be deduplicated by OSM identity, got %+v", registered)
+	}
+	if _, _, ok = fence.Next(position); ok {
+		t.Error("re-registered places must not be narrated again")
+	}
+}
+
+func TestGeofenceSkipsPlacesNarratedByDescription(t *testing.T) {
+	fence := services.NewGeofence(2 * services.MaxGeofenceRadius)
+	if fence.Radius != services.MaxGeofenceRadius {
+		t.Errorf("radius must be capped at %f, got %f", services.MaxGeofenceRadius, fence.Radius)
+	}
+	fence.Add(sessionPlaces(), "ru")
+	fence.MarkNarrated("node", "1")
+	if !fence.Narrated("node", "1") || fence.Narrated("node", "2") {
+		t.Fatal("unexpected narrated state")
+	}
+	if key, _, ok := fence.Next(geo.Point{Lat: 59.9300, Lon: 30.3000}); !ok || key != "node/2" {
+		t.Errorf("expected the church, got %q %v", key, ok)
+	}
+}
+
+func TestNarrateNearbyIgnoresInaccurateLocation(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{})
+	fence := services.NewGeofence(0)
+	fence.Add(sessionPlaces(), "ru")
+
+	update := dto.LocationUpdate{Lat: 59.93, Lon: 30.30, Accuracy: services.MaxLocationAccuracy + 1}
+	if _, ok := service.NarrateNearby(context.Background(), 0, services.DescribeOptions{}, fence, update); ok {
+		t.Error("inaccurate location must not trigger narration")
+	}
+	if fence.Narrated("node", "1") {
+		t.Error("inaccurate location must not mark places as narrated")
+	}
+}
+
+func TestGeofenceSkipsPlacesInProgress(t *testing.T) {
+	fence := services.NewGeofence(0)
+	fence.Add(sessionPlaces()[1:2], "ru")
+	fence.MarkNarrated("node", "2")
+	registered, claimed := fence.AddInProgress(sessionPlaces(), "ru")
+	if registered.Total != 2 || len(claimed) != 1 || claimed[0] != "node/1" {
+		t.Fatalf("only the unnarrated place must be claimed, got %+v %v", registered, claimed)
+	}
+
+	// Пока process описывает памятник, положение рядом с ним не вызывает рассказ
+	position := geo.Point{Lat: 59.9300, Lon: 30.3000}
+	if key, _, ok := fence.Next(position); ok {
+		t.Fatalf("place in progress must not be narrated, got %q", key)
+	}
+
+	// Неудачное описание освобождает место для рассказа при приближении
+	fence.Finish("node/1", false)
+	if fence.Narrated("node", "1") {
+		t.Fatal("failed description must not mark the place as narrated")
+	}
+	if key, _, ok := fence.Next(position); !ok || key != "node/1" {
+		t.Fatalf("released place must be narrated, got %q %v", key, ok)
+	}
+
+	_, claimed = fence.AddInProgress(sessionPlaces(), "ru")
+	if len(claimed) != 0 {
+		t.Errorf("narrated places must not be claimed again, got %v", claimed)
+	}
+}
+
+// flakyLLM считает вызовы и падает, пока fail = true
+type flakyLLM struct {
+	calls int32
+	fail  atomic.Bool
+}
+
+func (f *flakyLLM) Name() string { return "flaky" }
+
+func (f *flakyLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if f.fail.Load() {
+		return "", fmt.Errorf("LLM недоступна")
+	}
+	return "Описание: " + place["name"], nil
+}
+
+func (f *flakyLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+func TestNarrateNearbyRetriesFailedNarrationAfterDelay(t *testing.T) {
+	provider := &flakyLLM{}
+	provider.fail.Store(true)
+	service := newTestPlaceService(provider)
+	fence := services.NewGeofence(0)
+	fence.RetryDelay = 20 * time.Millisecond
+	fence.Add(sessionPlaces()[:1], "ru")
+	update := dto.LocationUpdate{Lat: 59.9300, Lon: 30.3000}
+	narrate := func() (*dto.GeofenceEvent, bool) {
+		return service.NarrateNearby(context.Background(), 0, services.DescribeOptions{}, fence, update)
+	}
+
+	if event, ok := narrate(); ok {
+		t.Fatalf("failed narration must not be sent, got %+v", event)
+	}
+	if fence.Narrated("node", "1") {
+		t.Fatal("failed narration must not mark the place as narrated")
+	}
+	// До конца паузы место не рассказывается и LLM не вызывается
+	if _, ok := narrate(); ok || atomic.LoadInt32(&provider.calls) != 1 {
+		t.Fatalf("expected no retry during the delay, got %d LLM calls", provider.calls)
+	}
+
+	time.Sleep(fence.RetryDelay)
+	provider.fail.Store(false)
+	event, ok := narrate()
+	if !ok || event.Place.Status != dto.PlaceStatusSuccess {
+		t.Fatalf("expected a successful retry, got %+v %v", event, ok)
+	}
+	if !fence.Narrated("node", "1") {
+		t.Error("successful narration must mark the place as narrated")
+	}
+	if _, ok = narrate(); ok {
+		t.Error("narrated place must not be narrated again")
+	}
+}
+
+func TestNarrateNearbyGivesUpAfterMaxAttempts(t *testing.T) {
+	provider := &flakyLLM{}
+	provider.fail.Store(true)
+	service := newTestPlaceService(provider)
+	fence := services.NewGeofence(0)
+	fence.RetryDelay = time.Millisecond
+	fence.Add(sessionPlaces()[:1], "ru")
+	update := dto.LocationUpdate{Lat: 59.9300, Lon: 30.3000}
+
+	// Пауза удваивается: 1, 2, 4 мс; после последней попытки место больше не выбирается
+	for i := 0; i < services.MaxNarrationAttempts+2; i++ {
+		if _, ok := service.NarrateNearby(context.Background(), 0, services.DescribeOptions{}, fence, update); ok {
+			t.Fatal("failed narration must not be sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls := atomic.LoadInt32(&provider.calls); calls != services.MaxNarrationAttempts {
+		t.Errorf("expected %d attempts, got %d", services.MaxNarrationAttempts, calls)
+	}
+}
+
+// slowLLM описывает место с задержкой и запоминает наибольшее число одновременных вызовов
+type slowLLM struct {
+	calls, inFlight, maxInFlight int32
+	delay                        time.Duration
+}
+
+func (s *slowLLM) Name() string { return "slow" }
+
+func (s *slowLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	atomic.AddInt32(&s.calls, 1)
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return "Описание: " + place["name"], nil
+}
+
+func (s *slowLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+func TestWebSocketRunsOneNarrationCheckAtATime(t *testing.T) {
+	provider := &slowLLM{delay: 200 * time.Millisecond}
+	conn, server := dialAuthenticated(t, newTestPlaceService(provider))
+	defer server.Close()
+	defer conn.Close()
+
+	var places []dto.OSMObject
+	for i := int64(1); i <= 5; i++ {
+		place := node(i, 59.9300, 30.3000)
+		place.Tags = map[string]string{"name": fmt.Sprintf("Место %d", i)}
+		places = append(places, place)
+	}
+	payload, _ := json.Marshal(places)
+	if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessagePlaces, Payload: payload}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	readUntil(t, conn, dto.WSMessageRegistered)
+
+	// Положения, пришедшие во время проверки, сливаются в одно: проверяются первое и последнее
+	location, _ := json.Marshal(dto.LocationUpdate{Lat: 59.9300, Lon: 30.3000})
+	for i := 0; i < 20; i++ {
+		if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessageLocation, RequestID: fmt.Sprint(i), Payload: location}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	first := readUntil(t, conn, dto.WSMessageNarration)
+	last := readUntil(t, conn, dto.WSMessageNarration)
+	if first.RequestID != "0" || last.RequestID != "19" {
+		t.Errorf("expected narrations for the first and the last location, got %q and %q", first.RequestID, last.RequestID)
+	}
+	time.Sleep(provider.delay)
+	if calls, max := atomic.LoadInt32(&provider.calls), atomic.LoadInt32(&provider.maxInFlight); calls != 2 || max != 1 {
+		t.Errorf("expected 2 sequential checks, got %d calls with up to %d at once", calls, max)
+	}
+}
@@ -0,0 +1,263 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"new/controllers"
+	"new/dto"
+	"new/models"
+	"new/services"
+	"new/services/llm"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestParseTagPatterns(t *testing.T) {
+	patterns, err := services.ParseTagPatterns("historic=*, tourism=museum|gallery ,artwork_type")
+	if err != nil {
+		t.Fatalf("ParseTagPatterns: %v", err)
+	}
+	if len(patterns) != 3 {
+		t.Fatalf("expected 3 patterns, got %d", len(patterns))
+	}
+
+	if patterns, err := services.ParseTagPatterns(""); err != nil || len(patterns) != 0 {
+		t.Fatalf("empty spec must give no patterns, got %v, %v", patterns, err)
+	}
+}
+
+func TestParseTagPatternsRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"=museum", "tourism=", "tour ism=museum", "*=yes"} {
+		if _, err := services.ParseTagPatterns(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+// newPreferenceDB создает базу с пользователем 1 и каталогом: история, архитектура и выведенный элемент
+func newPreferenceDB(t *testing.T) *gorm.DB {
+	db := newTestDB(t, &models.User{}, &models.ListPreference{}, &models.ListPreferenceTranslation{}, &models.Preference{})
+	retired := time.Now()
+	for _, record := range []interface{}{
+		&models.User{ID: 1, Username: "user", Email: "user@example.com"},
+		&models.ListPreference{ID: 1, Name: "История", Position: 1, OSMTags: "historic=*"},
+		&models.ListPreference{ID: 2, Name: "Архитектура", Position: 2, OSMTags: "building=church|cathedral,architect"},
+		&models.ListPreference{ID: 3, Name: "Фонтаны", Position: 3, OSMTags: "amenity=fountain", RetiredAt: &retired},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("create %T: %v", record, err)
+		}
+	}
+	return db
+}
+
+func TestCreatePreference(t *testing.T) {
+	service := services.NewPreferenceService(newPreferenceDB(t))
+
+	preference, err := service.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: 2})
+	if err != nil {
+		t.Fatalf("CreatePreference: %v", err)
+	}
+	if preference.ID == 0 || preference.UserID != 1 || preference.ListPreferenceID != 2 || preference.ListPreference.Name != "Архитектура" {
+		t.Fatalf("unexpected preference: %+v", preference)
+	}
+
+	var stored models.Preference
+	if err := service.DB.First(&stored, preference.ID).Error; err != nil {
+		t.Fatalf("load preference: %v", err)
+	}
+	if stored.UserID != 1 || stored.ListPreferenceID != 2 {
+		t.Errorf("list_preference_id must be stored, got %+v", stored)
+	}
+}
+
+func TestCreatePreferenceRejectsUnknownAndDuplicate(t *testing.T) {
+	service := services.NewPreferenceService(newPreferenceDB(t))
+
+	for _, id := range []uint{3, 42} {
+		if _, err := service.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: id}); !errors.Is(err, services.ErrCatalogEntryNotFound) {
+			t.Errorf("catalog entry %d: expected ErrCatalogEntryNotFound, got %v", id, err)
+		}
+	}
+
+	if _, err := service.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: 1}); err != nil {
+		t.Fatalf("CreatePreference: %v", err)
+	}
+	if _, err := service.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: 1}); !errors.Is(err, services.ErrPreferenceExists) {
+		t.Errorf("expected ErrPreferenceExists, got %v", err)
+	}
+
+	// Уникальный индекс срабатывает и без проверки в сервисе
+	err := service.DB.Omit("ListPreference", "User").Create(&models.Preference{UserID: 1, ListPreferenceID: 1}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("expected unique index violation, got %v", err)
+	}
+}
+
+func TestCreatePreferenceStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	controller := &controllers.PreferenceController{Service_prefernse: services.NewPreferenceService(newPreferenceDB(t))}
+	r.POST("/preferences", controller.CreatePreference)
+
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/preferences", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := post(`{"list_preference_id": 1}`); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if code := post(`{"list_preference_id": 1}`); code != http.StatusConflict {
+		t.Errorf("duplicate: expected 409, got %d", code)
+	}
+	if code := post(`{"list_preference_id": 3}`); code != http.StatusNotFound {
+		t.Errorf("retired entry: expected 404, got %d", code)
+	}
+	if code := post(`{"list_preference_id": 42}`); code != http.StatusNotFound {
+		t.Errorf("unknown entry: expected 404, got %d", code)
+	}
+}
+
+func TestGetPreferencesPreloadsCatalogEntry(t *testing.T) {
+	service := services.NewPreferenceService(newPreferenceDB(t))
+	for _, id := range []uint{2, 1} {
+		if _, err := service.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: id}); err != nil {
+			t.Fatalf("CreatePreference: %v", err)
+		}
+	}
+
+	preferences, err := service.GetPreferencesByUserID(1)
+	if err != nil {
+		t.Fatalf("GetPreferencesByUserID: %v", err)
+	}
+	if len(preferences) != 2 || preferences[0].ListPreference.Name != "Архитектура" || preferences[1].ListPreference.Name != "История" {
+		t.Fatalf("catalog entries must be preloaded, got %+v", preferences)
+	}
+	if preferences, err := service.GetPreferencesByUserID(2); err != nil || len(preferences) != 0 {
+		t.Errorf("expected no preferences for another user, got %v, %v", preferences, err)
+	}
+}
+
+// interestsLLM запоминает интересы, переданные в промпт для каждого места
+type interestsLLM struct {
+	mu        sync.Mutex
+	interests map[string]string
+}
+
+func (l *interestsLLM) Name() string { return "fake" }
+
+func (l *interestsLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interests[place["name"]] = place[llm.InterestsField]
+	return "Описание: " + place["name"], nil
+}
+
+func (l *interestsLLM) Ask(ctx context.Context, question string) (string, error) {
+	return question, nil
+}
+
+// newInterestsService создает сервис мест с пользователем 1, выбравшим историю и архитектуру
+func newInterestsService(t *testing.T) (*services.PlaceService, *interestsLLM) {
+	db := newPreferenceDB(t)
+	if err := db.AutoMigrate(&models.Place{}); err != nil {
+		t.Fatalf("migrate history: %v", err)
+	}
+	preferences := services.NewPreferenceService(db)
+	for _, id := range []uint{2, 1} {
+		if _, err := preferences.CreatePreference(1, dto.CreatePreferenceDTO{ListPreferenceID: id}); err != nil {
+			t.Fatalf("CreatePreference: %v", err)
+		}
+	}
+	provider := &interestsLLM{interests: make(map[string]string)}
+	service := newTestPlaceService(provider)
+	service.DB = db
+	return service, provider
+}
+
+// interestPlaces — места без совпадений, с одним и с двумя совпавшими интересами
+func interestPlaces() []map[string]string {
+	return []map[string]string{
+		{"name": "Сквер", "place_name": "Сквер"},
+		{"name": "Крепость", "place_name": "Крепость", "historic": "fort"},
+		{"name": "Собор", "place_name": "Собор", "historic": "yes", "building": "cathedral"},
+	}
+}
+
+func TestInterestsReachPrompt(t *testing.T) {
+	service, provider := newInterestsService(t)
+
+	if _, err := service.ProcessPlaces(context.Background(), 1, interestPlaces()); err != nil {
+		t.Fatalf("ProcessPlaces: %v", err)
+	}
+	// Интересы передаются в порядке каталога для всех мест, а не только совпавших
+	for _, name := range []string{"Сквер", "Крепость", "Собор"} {
+		if got := provider.interests[name]; got != "История, Архитектура" {
+			t.Errorf("%s: expected interests in catalog order, got %q", name, got)
+		}
+	}
+
+	// Без пользователя интересов в промпте нет
+	anonymous := newTestPlaceService(provider)
+	anonymous.DB = service.DB
+	if _, err := anonymous.ProcessPlaces(context.Background(), 0, []map[string]string{{"name": "Сад", "place_name": "Сад"}}); err != nil {
+		t.Fatalf("ProcessPlaces: %v", err)
+	}
+	if got := provider.interests["Сад"]; got != "" {
+		t.Errorf("anonymous request must have no interests, got %q", got)
+	}
+}
+
+func TestProcessPlacesRanksByInterests(t *testing.T) {
+	service, _ := newInterestsService(t)
+
+	results, err := service.ProcessPlaces(context.Background(), 1, interestPlaces())
+	if err != nil {
+		t.Fatalf("ProcessPlaces: %v", err)
+	}
+	var names []string
+	for _, result := range results {
+		if result.Status != dto.PlaceStatusSuccess {
+			t.Errorf("%s: expected success, got %s", result.PlaceName, result.Status)
+		}
+		names = append(names, result.PlaceName)
+	}
+	if got := strings.Join(names, ","); got != "Собор,Крепость,Сквер" {
+		t.Errorf("places must be ordered by matched interests, got %s", got)
+	}
+}
+
+func TestPreferencesFilterSkipsUnmatchedPlaces(t *testing.T) {
+	t.Setenv("PREFERENCES_FILTER", "true")
+	service, provider := newInterestsService(t)
+
+	results, err := service.ProcessPlaces(context.Background(), 1, interestPlaces())
+	if err != nil {
+		t.Fatalf("ProcessPlaces: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	skipped := results[2]
+	if skipped.PlaceName != "Сквер" || skipped.Status != dto.PlaceStatusSkipped || skipped.Reason == "" {
+		t.Errorf("unmatched place must be skipped with a reason, got %+v", skipped)
+	}
+	if _, described := provider.interests["Сквер"]; described {
+		t.Error("skipped place must not reach the LLM")
+	}
+	if results[0].PlaceName != "Собор" || results[1].PlaceName != "Крепость" {
+		t.Errorf("matched places must come first, got %s, %s", results[0].PlaceName, results[1].PlaceName)
+	}
+}
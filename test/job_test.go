@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"new/controllers"
+	"new/dto"
+	"new/models"
+	"new/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB создает базу SQLite во временном каталоге со схемой для указанных моделей
+func newTestDB(t *testing.T, schema ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql.DB: %v", err)
+	}
+	// Одно соединение: SQLite не допускает одновременной записи
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(schema...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// countingLLM считает вызовы и отвечает с задержкой, чтобы повторные обработки успели пересечься
+type countingLLM struct {
+	calls int32
+	delay time.Duration
+}
+
+func (c *countingLLM) Name() string { return "counting" }
+
+func (c *countingLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	time.Sleep(c.delay)
+	return "Описание: " + place["name"], nil
+}
+
+func (c *countingLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+// newTestJob создает задание из одного места и возвращает его задачу
+func newTestJob(t *testing.T, service *services.JobService) (*models.Job, models.JobTask) {
+	t.Helper()
+	job, err := service.CreateJob(context.Background(), "", "", []dto.OSMObject{
+		{ID: 1, Type: "node", Tags: map[string]string{"name": "Музей"}, Lat: 55.75, Lon: 37.61},
+	})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	var task models.JobTask
+	if err := service.DB.Where("job_id = ?", job.ID).First(&task).Error; err != nil {
+		t.Fatalf("task: %v", err)
+	}
+	return job, task
+}
+
+func TestProcessTaskTwiceRunsOnce(t *testing.T) {
+	withRedis(t)
+	llmProvider := &countingLLM{delay: 50 * time.Millisecond}
+	service := &services.JobService{
+		DB:     newTestDB(t, &models.Job{}, &models.JobTask{}),
+		Places: newTestPlaceService(llmProvider),
+	}
+	job, task := newTestJob(t, service)
+
+	// Одна и та же задача попала в очередь дважды и досталась двум обработчикам
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.ProcessTask(context.Background(), task.ID); err != nil {
+				t.Errorf("ProcessTask: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// И еще раз после завершения
+	if err := service.ProcessTask(context.Background(), task.ID); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+
+	if calls := atomic.LoadInt32(&llmProvider.calls); calls != 1 {
+		t.Errorf("expected one LLM call, got %d", calls)
+	}
+	updated, err := service.GetJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if updated.Done != 1 || updated.Failed != 0 || updated.Status != models.JobStatusCompleted {
+		t.Errorf("unexpected job %+v", updated)
+	}
+}
+
+func TestRequeueStaleReturnsOnlyAbandonedTasks(t *testing.T) {
+	m := withRedis(t)
+	service := &services.JobService{
+		DB:           newTestDB(t, &models.Job{}, &models.JobTask{}),
+		Places:       newTestPlaceService(&countingLLM{}),
+		LeaseTimeout: time.Minute,
+	}
+	_, pending := newTestJob(t, service)
+	_, fresh := newTestJob(t, service)
+	_, stale := newTestJob(t, service)
+	_, lost := newTestJob(t, service)
+	m.Del("jobs:queue")
+
+	service.DB.Model(&models.JobTask{}).Where("id = ?", fresh.ID).
+		Updates(map[string]interface{}{"status": models.TaskStatusRunning, "updated_at": time.Now()})
+	service.DB.Model(&models.JobTask{}).Where("id = ?", stale.ID).
+		Updates(map[string]interface{}{"status": models.TaskStatusRunning, "updated_at": time.Now().Add(-time.Hour)})
+	// Задача, которая так и не попала в очередь
+	service.DB.Model(&models.JobTask{}).Where("id = ?", lost.ID).Update("updated_at", time.Now().Add(-time.Hour))
+
+	n, err := service.RequeueStale(context.Background())
+	if err != nil {
+		t.Fatalf("RequeueStale: %v", err)
+	}
+	queue, _ := m.List("jobs:queue")
+	want := map[string]bool{fmt.Sprint(stale.ID): true, fmt.Sprint(lost.ID): true}
+	if n != 2 || len(queue) != 2 || !want[queue[0]] || !want[queue[1]] {
+		t.Fatalf("expected the stale and the lost task to be requeued, got %d %v (pending %d)", n, queue, pending.ID)
+	}
+	// Следующая проверка до истечения LeaseTimeout те же задачи не дублирует
+	if n, err = service.RequeueStale(context.Background()); err != nil || n != 0 {
+		t.Fatalf("second RequeueStale: %d %v", n, err)
+	}
+
+	// Возвращенные задачи снова доступны обработчику
+	for _, id := range []uint{stale.ID, lost.ID} {
+		if err := service.ProcessTask(context.Background(), id); err != nil {
+			t.Fatalf("ProcessTask: %v", err)
+		}
+		var task models.JobTask
+		service.DB.First(&task, id)
+		if task.Status != models.TaskStatusDone {
+			t.Errorf("expected the requeued task %d to be done, got %s", id, task.Status)
+		}
+	}
+}
+
+func TestCreateJobRejectsInvalidInput(t *testing.T) {
+	withRedis(t)
+	service := &services.JobService{
+		DB:     newTestDB(t, &models.Job{}, &models.JobTask{}),
+		Places: newTestPlaceService(&countingLLM{}),
+	}
+	if _, err := service.CreateJob(context.Background(), "", "", nil); !errors.Is(err, services.ErrInvalidJob) {
+		t.Errorf("empty objects: expected ErrInvalidJob, got %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/jobs", (&controllers.JobController{Service: service}).CreateJob)
+	post := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs"+query, strings.NewReader(placesBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := post("?provider=unknown"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown provider: expected 400, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post(""); w.Code != http.StatusAccepted {
+		t.Errorf("valid job: expected 202, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"new/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func withKeyring(t *testing.T, k *utils.Keyring) {
+	t.Helper()
+	utils.SetKeyring(k)
+	t.Cleanup(func() { utils.SetKeyring(nil) })
+}
+
+// withTestKeyring устанавливает набор с одним HMAC-ключом
+func withTestKeyring(t *testing.T) {
+	t.Helper()
+	withKeyring(t, utils.NewKeyring(utils.NewHMACKey("test", []byte("test-secret")), 0))
+}
+
+func TestKeyringAcceptsRetiredKeyDuringGrace(t *testing.T) {
+	old := utils.NewHMACKey("old", []byte("old-secret"))
+	withKeyring(t, utils.NewKeyring(old, time.Hour))
+	token, _, err := utils.GenerateAccessToken(5, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ротация: новый активный ключ, старый выведен из оборота только что
+	rotated := utils.NewKeyring(utils.NewHMACKey("new", []byte("new-secret")), time.Hour)
+	old.RetiredAt = time.Now()
+	rotated.Add(old)
+	withKeyring(t, rotated)
+
+	if _, err := utils.ParseAccessToken(token); err != nil {
+		t.Fatalf("token signed by retired key must be valid during grace: %v", err)
+	}
+
+	// Период Grace истёк
+	old.RetiredAt = time.Now().Add(-2 * time.Hour)
+	if _, err := utils.ParseAccessToken(token); err == nil {
+		t.Fatal("token signed by retired key must be rejected after grace")
+	}
+}
+
+func TestKeyringRejectsUnknownKid(t *testing.T) {
+	withKeyring(t, utils.NewKeyring(utils.NewHMACKey("a", []byte("secret-a")), 0))
+	token, _, err := utils.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withKeyring(t, utils.NewKeyring(utils.NewHMACKey("b", []byte("secret-a")), 0))
+	if _, err := utils.ParseAccessToken(token); err == nil {
+		t.Fatal("expected token with unknown kid to be rejected")
+	}
+}
+
+func TestKeyringAsymmetricSigningAndJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []*utils.SigningKey{
+		{ID: "rsa", Method: jwt.SigningMethodRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey},
+		{ID: "ed", Method: jwt.SigningMethodEdDSA, SignKey: edPriv, VerifyKey: edPub},
+	}
+	for _, key := range keys {
+		k := utils.NewKeyring(key, 0)
+		k.Add(utils.NewHMACKey("hmac", []byte("secret")))
+		withKeyring(t, k)
+
+		token, _, err := utils.GenerateAccessToken(9, "", "")
+		if err != nil {
+			t.Fatalf("%s: sign: %v", key.ID, err)
+		}
+		claims, err := utils.ParseAccessToken(token)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", key.ID, err)
+		}
+		if userID, _ := claims.UserID(); userID != 9 {
+			t.Fatalf("%s: unexpected user %d", key.ID, userID)
+		}
+
+		set := k.JWKS()
+		if len(set.Keys) != 1 || set.Keys[0].Kid != key.ID {
+			t.Fatalf("%s: JWKS must contain only the public key, got %+v", key.ID, set.Keys)
+		}
+	}
+}
+
+func TestKeyringRejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withKeyring(t, utils.NewKeyring(&utils.SigningKey{
+		ID: "rsa", Method: jwt.SigningMethodRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey,
+	}, 0))
+
+	// Токен HS256 с kid RSA-ключа не должен приниматься
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "1"})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString([]byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ParseAccessToken(signed); err == nil {
+		t.Fatal("expected algorithm mismatch to be rejected")
+	}
+}
+
+// clearKeyEnv сбрасывает переменные окружения с настройками ключей
+func clearKeyEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"JWT_KEYS_FILE", "JWT_ALG", "JWT_KEY_ID", "JWT_SECRET", "JWT_SECRET_FILE", "JWT_PRIVATE_KEY_FILE", "JWT_DEV_MODE"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadKeyringRequiresKeys(t *testing.T) {
+	clearKeyEnv(t)
+	if _, err := utils.LoadKeyringFromEnv(); err == nil {
+		t.Fatal("expected an error without configured keys")
+	}
+
+	// Режим разработки включается только явно, и ключ каждый раз новый
+	t.Setenv("JWT_DEV_MODE", "true")
+	first, err := utils.LoadKeyringFromEnv()
+	if err != nil {
+		t.Fatalf("dev mode: %v", err)
+	}
+	second, err := utils.LoadKeyringFromEnv()
+	if err != nil {
+		t.Fatalf("dev mode: %v", err)
+	}
+	withKeyring(t, first)
+	token, _, err := utils.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.SetKeyring(second)
+	if _, err := utils.ParseAccessToken(token); err == nil {
+		t.Error("dev keys must not be shared between processes")
+	}
+}
+
+func TestCurrentKeyringPanicsWhenUnset(t *testing.T) {
+	utils.SetKeyring(nil)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic without a keyring")
+		}
+	}()
+	utils.GenerateAccessToken(1, "", "")
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"new/services/llm"
+)
+
+func TestTagWhitelist(t *testing.T) {
+	w := llm.ParseTagWhitelist(" wikipedia , name:* ,,")
+	for _, key := range []string{"name", "addr:city", "wikipedia", "name:en", "name:"} {
+		if !w.Allowed(key) {
+			t.Errorf("%q must be allowed", key)
+		}
+	}
+	for _, key := range []string{"wikidata", "names", "opening_hours", "lat"} {
+		if w.Allowed(key) {
+			t.Errorf("%q must not be allowed", key)
+		}
+	}
+}
+
+func TestDescribeRequestBody(t *testing.T) {
+	place := map[string]string{
+		"name":          `Дом "Книги"`,
+		"description":   "строка 1\nстрока 2\\",
+		"wikipedia":     "ru:Дом книги",
+		"opening_hours": "24/7",
+		"lat":           "59.935",
+		"lon":           "30.326",
+		"osm_id":        "42",
+		llm.PromptField: "Расскажи\tкоротко",
+	}
+	body, err := llm.NewDescribeRequest(place, llm.ParseTagWhitelist("wikipedia"), true).Body()
+	if err != nil {
+		t.Fatalf("Body: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body is not valid JSON: %v\n%s", err, body)
+	}
+	want := map[string]string{
+		"name":          place["name"],
+		"description":   place["description"],
+		"wikipedia":     place["wikipedia"],
+		"lat":           "59.935",
+		"lon":           "30.326",
+		llm.PromptField: place[llm.PromptField],
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+
+	body, _ = llm.NewDescribeRequest(place, llm.NewTagWhitelist(), false).Body()
+	got = nil
+	json.Unmarshal(body, &got)
+	if _, ok := got["lat"]; ok {
+		t.Errorf("coordinates must be omitted: %s", body)
+	}
+}
+
+func TestMessageHostSendsValidJSON(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer server.Close()
+
+	provider := llm.NewFastAPIProvider(server.URL, llm.NewTagWhitelist())
+	text, err := provider.Describe(context.Background(), map[string]string{"name": `a\"b` + "\n\x01", "lat": "1"})
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if text != "ok" || received["name"] != `a\"b`+"\n\x01" {
+		t.Fatalf("unexpected result %q, received %v", text, received)
+	}
+	if _, ok := received["lat"]; ok {
+		t.Errorf("fastapi must not receive coordinates")
+	}
+}
+
+// FuzzDescribeRequestBody проверяет, что при любых тегах тело запроса остаётся корректным JSON
+// и значения доходят до сервиса без искажений
+func FuzzDescribeRequestBody(f *testing.F) {
+	f.Add("name", `Кафе "У моста"`, "wikipedia", "en:Bridge", "prompt")
+	f.Add("description", "a\\b\nc\td", "name:en", "\x00\x1f ", "")
+	f.Add("inscription", `"`, "addr:city", `"}{"`, `{"message":"x"}`)
+	f.Add("", "", "name", "", "")
+	f.Add("name:*", "\xff\xfe", "lat", "1e999", "интересы")
+
+	whitelist := llm.ParseTagWhitelist("wikipedia,name:*")
+	f.Fuzz(func(t *testing.T, key, value, extraKey, extraValue, prompt string) {
+		place := map[string]string{
+			"addr:street":   value,
+			key:             value,
+			extraKey:        extraValue,
+			llm.PromptField: prompt,
+		}
+		body, err := llm.NewDescribeRequest(place, whitelist, true).Body()
+		if err != nil {
+			t.Fatalf("Body: %v", err)
+		}
+		if !json.Valid(body) {
+			t.Fatalf("invalid JSON: %q", body)
+		}
+
+		var got map[string]string
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("Unmarshal: %v\n%q", err, body)
+		}
+		for k, v := range place {
+			if !utf8.ValidString(k) || !utf8.ValidString(v) {
+				continue // encoding/json заменяет неверные байты на U+FFFD
+			}
+			sent, ok := got[k]
+			if !ok {
+				continue
+			}
+			if sent != v {
+				t.Fatalf("%q: sent %q, expected %q", k, sent, v)
+			}
+		}
+		service := map[string]bool{"lat": true, "lon": true, llm.PromptField: true, llm.InterestsField: true, llm.LanguageField: true}
+		for k := range got {
+			if !service[k] && !whitelist.Allowed(k) {
+				t.Fatalf("tag %q is not in whitelist", k)
+			}
+		}
+	})
+}
+
+func TestOpenAIReportsStatusForNonJSONBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>502 Bad Gateway</html>" + strings.Repeat("x", 1000)))
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.URL, "", "", nil)
+	_, err := provider.Ask(context.Background(), "вопрос")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "Bad Gateway") {
+		t.Errorf("error must keep the status and the body: %v", err)
+	}
+	if len(err.Error()) > 700 {
+		t.Errorf("body must be truncated, got %d bytes", len(err.Error()))
+	}
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"new/dto"
+	"new/services"
+	"new/services/llm"
+	"new/services/tts"
+)
+
+// recordingLLM запоминает данные мест, переданные на описание
+type recordingLLM struct {
+	places []map[string]string
+}
+
+func (r *recordingLLM) Name() string { return "recording" }
+
+func (r *recordingLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	r.places = append(r.places, place)
+	return "Описание: " + place["name"], nil
+}
+
+func (r *recordingLLM) Ask(ctx context.Context, question string) (string, error) {
+	return question, nil
+}
+
+// voiceTTS запоминает параметры синтеза
+type voiceTTS struct {
+	opts []tts.Options
+}
+
+func (v *voiceTTS) Name() string { return "voice" }
+
+func (v *voiceTTS) Synthesize(ctx context.Context, text string, opts tts.Options) (*tts.Audio, error) {
+	v.opts = append(v.opts, opts)
+	return &tts.Audio{Data: []byte(text), Format: opts.Format, ContentType: tts.ContentType(opts.Format)}, nil
+}
+
+func TestProcessJSONNoAuthUsesLocalizedNames(t *testing.T) {
+	recorder := &recordingLLM{}
+	service := newTestPlaceService(recorder)
+	objects := []dto.OSMObject{
+		{ID: 1, Type: "node", Tags: map[string]string{"name": "Эрмитаж", "name:en": "Hermitage", "name:pt": "Hermitage PT"}},
+		{ID: 2, Type: "node", Tags: map[string]string{"name": "Кунсткамера"}},
+	}
+
+	results, err := service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{Language: "en"}, objects)
+	if err != nil {
+		t.Fatalf("ProcessJSONNoAuth: %v", err)
+	}
+	if results[0].PlaceName != "Hermitage" || results[1].PlaceName != "Кунсткамера" {
+		t.Fatalf("unexpected names: %q, %q", results[0].PlaceName, results[1].PlaceName)
+	}
+	for _, place := range recorder.places {
+		if place[llm.LanguageField] != "en" {
+			t.Errorf("language must be passed to LLM, got %q", place[llm.LanguageField])
+		}
+	}
+	if recorder.places[0]["name"] != "Hermitage" {
+		t.Errorf("LLM must receive localized name, got %q", recorder.places[0]["name"])
+	}
+
+	// pt-br без собственного перевода берёт name:pt
+	results, _ = service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{Language: "pt-br"}, objects[:1])
+	if results[0].PlaceName != "Hermitage PT" {
+		t.Errorf("expected fallback to name:pt, got %q", results[0].PlaceName)
+	}
+
+	// Без языка используется язык по умолчанию и исходное название
+	recorder.places = nil
+	results, _ = service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{}, objects[:1])
+	if results[0].PlaceName != "Эрмитаж" || recorder.places[0][llm.LanguageField] != services.DefaultLanguage {
+		t.Errorf("unexpected default: %q, %v", results[0].PlaceName, recorder.places[0])
+	}
+}
+
+func TestResolveLanguage(t *testing.T) {
+	for input, want := range map[string]string{"": services.DefaultLanguage, "EN": "en", "pt_BR": "pt-br"} {
+		got, err := services.ResolveLanguage(input)
+		if err != nil || got != want {
+			t.Errorf("ResolveLanguage(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := services.ResolveLanguage("english please"); err == nil {
+		t.Error("expected error for invalid language")
+	}
+}
+
+func TestRequestLanguage(t *testing.T) {
+	cases := []struct {
+		lang, header, want string
+	}{
+		{"EN", "de", "en"},
+		{"", "zh-Hant-TW,zh;q=0.9", "zh-hant"},
+		{"", "de;q=0.5, pt-BR", "pt-br"},
+		{"", "!!!, en;q=0.8", "en"},
+		{"", "fr;q=0, en;q=0.1", "en"},
+		{"", "*", ""},
+		{"", "x;q=abc", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		got, err := services.RequestLanguage(c.lang, c.header)
+		if err != nil || got != c.want {
+			t.Errorf("RequestLanguage(%q, %q) = %q, %v; want %q", c.lang, c.header, got, err, c.want)
+		}
+	}
+
+	// Ошибка — только для явно указанного некорректного языка
+	if _, err := services.RequestLanguage("english please", "en"); !errors.Is(err, services.ErrInvalidLanguage) {
+		t.Errorf("expected ErrInvalidLanguage, got %v", err)
+	}
+}
+
+func TestTTSVoiceSelectedByLanguage(t *testing.T) {
+	voices, err := tts.ParseVoices("ru=ru-RU-Svetlana, en=en-US-Aria, pt_BR=pt-BR-Francisca")
+	if err != nil {
+		t.Fatalf("ParseVoices: %v", err)
+	}
+	provider := &voiceTTS{}
+	registry := tts.NewRegistry()
+	registry.Register(provider)
+	registry.Voices = voices
+	registry.Defaults.Voice = "default-voice"
+
+	cases := map[string]string{
+		"en":    "en-US-Aria",
+		"en-GB": "en-US-Aria",
+		"pt-br": "pt-BR-Francisca",
+		"de":    "default-voice",
+	}
+	for language, want := range cases {
+		if _, err := registry.Synthesize(context.Background(), "", "текст", tts.Options{Language: language}); err != nil {
+			t.Fatalf("Synthesize: %v", err)
+		}
+		if got := provider.opts[len(provider.opts)-1].Voice; got != want {
+			t.Errorf("%s: expected voice %q, got %q", language, want, got)
+		}
+	}
+
+	// Явно указанный голос важнее голоса языка
+	registry.Synthesize(context.Background(), "", "текст", tts.Options{Language: "en", Voice: "custom"})
+	if got := provider.opts[len(provider.opts)-1].Voice; got != "custom" {
+		t.Errorf("explicit voice must win, got %q", got)
+	}
+
+	if _, err := tts.ParseVoices("ru"); err == nil {
+		t.Error("expected error for pair without voice")
+	}
+}
+
+func TestAudioHashIncludesLanguage(t *testing.T) {
+	base := tts.Options{Voice: "v", Format: tts.FormatMP3, Speed: 1}
+	withLanguage := base
+	withLanguage.Language = "en"
+	if services.AudioHash("текст", base) == services.AudioHash("текст", withLanguage) {
+		t.Error("language must change audio hash")
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"new/database"
+
+	"gorm.io/gorm"
+)
+
+func TestLoadMigrationsAreOrderedAndPaired(t *testing.T) {
+	migrations, err := database.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		// Версии идут подряд с 1: пропуск обычно означает потерянный файл
+		if m.Version != int64(i+1) {
+			t.Errorf("expected version %d at position %d, got %04d_%s", i+1, i, m.Version, m.Name)
+		}
+		if m.Name == "" || strings.TrimSpace(m.Up) == "" {
+			t.Errorf("%04d_%s: missing name or up script", m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("%04d_%s: every migration must be reversible", m.Version, m.Name)
+		}
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	db := newTestDB(t)
+	// Драйвер SQLite читает время только из колонок TIMESTAMP, а не TIMESTAMPTZ, как создает CheckSchema
+	db.Exec("CREATE TABLE schema_migrations (version BIGINT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	migrations, err := database.LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+
+	pending, err := database.CheckSchema(db)
+	if err != nil || pending != len(migrations) {
+		t.Fatalf("empty database: expected %d pending, got %d %v", len(migrations), pending, err)
+	}
+
+	for _, m := range migrations[:len(migrations)-1] {
+		db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+	}
+	if pending, err = database.CheckSchema(db); err != nil || pending != 1 {
+		t.Fatalf("expected 1 pending migration, got %d %v", pending, err)
+	}
+
+	last := migrations[len(migrations)-1]
+	db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", last.Version, last.Name)
+	if pending, err = database.CheckSchema(db); err != nil || pending != 0 {
+		t.Fatalf("expected an up-to-date schema, got %d %v", pending, err)
+	}
+
+	// Схема новее кода: сервер не должен запускаться
+	db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", last.Version+1, "from_the_future")
+	if _, err = database.CheckSchema(db); err == nil || !strings.Contains(err.Error(), "from_the_future") {
+		t.Errorf("expected an error about the unknown migration, got %v", err)
+	}
+}
+
+func TestWithMigrationLockRunsWithoutPostgres(t *testing.T) {
+	db := newTestDB(t)
+	called := false
+	err := database.WithMigrationLock(db, func(conn *gorm.DB) error {
+		called = true
+		_, err := database.CheckSchema(conn)
+		return err
+	})
+	if err != nil || !called {
+		t.Errorf("expected fn to run without an advisory lock, got %v %v", called, err)
+	}
+}
@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"new/dto"
+	"new/models"
+	"new/services"
+	"new/services/geo"
+)
+
+func TestGeohashAndDistance(t *testing.T) {
+	if got := geo.Geohash(geo.Point{Lat: 42.605, Lon: -5.603}, 5); got != "ezs42" {
+		t.Errorf("expected ezs42, got %s", got)
+	}
+	if got := geo.Geohash(geo.Point{Lat: 59.9398, Lon: 30.3146}, geo.GeohashPrecision); len(got) != geo.GeohashPrecision || !strings.HasPrefix(got, "udt") {
+		t.Errorf("unexpected geohash for Saint Petersburg: %s", got)
+	}
+
+	moscow := geo.Point{Lat: 55.7558, Lon: 37.6173}
+	petersburg := geo.Point{Lat: 59.9343, Lon: 30.3351}
+	if d := geo.Distance(moscow, petersburg); math.Abs(d-634_000) > 5_000 {
+		t.Errorf("expected about 634 km, got %.0f m", d)
+	}
+	if d := geo.Distance(moscow, moscow); d != 0 {
+		t.Errorf("expected zero distance, got %f", d)
+	}
+}
+
+func TestCoverPrefixesContainAllPointsInRadius(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	centers := []geo.Point{
+		{Lat: 59.9398, Lon: 30.3146},
+		{Lat: 0.0001, Lon: 0.0001},  // Граница ячеек на экваторе и нулевом меридиане
+		{Lat: -33.86, Lon: 179.999}, // Рядом с антимеридианом
+		{Lat: 69.0, Lon: 33.0},      // Высокие широты, ячейки сужаются
+	}
+	for _, center := range centers {
+		for _, radius := range []float64{30, 500, 5000} {
+			prefixes := geo.CoverPrefixes(center, radius)
+			if len(prefixes) == 0 || len(prefixes) > 9 {
+				t.Fatalf("unexpected number of prefixes: %v", prefixes)
+			}
+			for i := 0; i < 500; i++ {
+				// Случайная точка внутри круга
+				bearing := rng.Float64() * 2 * math.Pi
+				distance := radius * math.Sqrt(rng.Float64())
+				p := geo.Point{
+					Lat: center.Lat + distance*math.Cos(bearing)/111320,
+					Lon: center.Lon + distance*math.Sin(bearing)/(111320*math.Cos(center.Lat*math.Pi/180)),
+				}
+				if p.Lon > 180 {
+					p.Lon -= 360
+				}
+				if geo.Distance(center, p) > radius {
+					continue
+				}
+				hash := geo.Geohash(p, geo.GeohashPrecision)
+				covered := false
+				for _, prefix := range prefixes {
+					covered = covered || strings.HasPrefix(hash, prefix)
+				}
+				if !covered {
+					t.Fatalf("point %+v (%s) at %.0f m from %+v is not covered by %v", p, hash, distance, center, prefixes)
+				}
+			}
+		}
+	}
+}
+
+func TestResolveNearbyQuery(t *testing.T) {
+	q, err := services.ResolveNearbyQuery(dto.NearbyQuery{Lat: 59.9, Lon: 30.3, Language: "EN"})
+	if err != nil {
+		t.Fatalf("ResolveNearbyQuery: %v", err)
+	}
+	if q.Radius != services.DefaultNearbyRadius || q.Limit != services.DefaultNearbyLimit || q.Language != "en" {
+		t.Errorf("unexpected defaults: %+v", q)
+	}
+
+	invalid := []dto.NearbyQuery{
+		{Lat: 91, Lon: 0},
+		{Lat: 0, Lon: -181},
+		{Lat: math.NaN(), Lon: 0},
+		{Lat: 0, Lon: 0, Radius: -1},
+		{Lat: 0, Lon: 0, Radius: services.MaxNearbyRadius + 1},
+		{Lat: 0, Lon: 0, Limit: services.MaxNearbyLimit + 1},
+	}
+	for _, query := range invalid {
+		if _, err := services.ResolveNearbyQuery(query); !errors.Is(err, services.ErrInvalidNearbyQuery) {
+			t.Errorf("%+v: expected ErrInvalidNearbyQuery, got %v", query, err)
+		}
+	}
+	if _, err := services.ResolveNearbyQuery(dto.NearbyQuery{Language: "not a language"}); !errors.Is(err, services.ErrInvalidLanguage) {
+		t.Errorf("expected ErrInvalidLanguage, got %v", err)
+	}
+}
+
+func rememberedResult(lat, lon float64) dto.PlaceResult {
+	return dto.PlaceResult{
+		PlaceID:     "1",
+		OSMType:     "node",
+		PlaceName:   "Памятник",
+		Coordinates: &dto.Coordinates{Lat: lat, Lon: lon},
+		Status:      dto.PlaceStatusSuccess,
+	}
+}
+
+func TestRememberDoesNotReplaceStoredPlace(t *testing.T) {
+	places := &services.OSMPlaceService{DB: newTestDB(t, &models.OSMPlace{}, &models.OSMPlaceDescription{})}
+	ctx := context.Background()
+	tags := map[string]string{"name": "Памятник", "historic": "monument"}
+	original := &models.OSMPlaceDescription{Language: "ru", Provider: "fake", Text: "Исходное описание"}
+	if err := places.Remember(ctx, rememberedResult(59.93, 30.30), tags, original); err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+
+	// Другие теги и координаты того же объекта не меняют запись и описание
+	forged := map[string]string{"name": "Памятник", "historic": "castle"}
+	err := places.Remember(ctx, rememberedResult(10, 10), forged, &models.OSMPlaceDescription{Language: "ru", Provider: "fake", Text: "Подмена"})
+	if !errors.Is(err, services.ErrPlaceTagsMismatch) {
+		t.Fatalf("expected ErrPlaceTagsMismatch, got %v", err)
+	}
+	nearby, err := places.Nearby(ctx, dto.NearbyQuery{Lat: 59.93, Lon: 30.30, Language: "ru"})
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if len(nearby) != 1 || nearby[0].Response != "Исходное описание" || nearby[0].Tags["historic"] != "monument" {
+		t.Fatalf("stored place must stay unchanged, got %+v", nearby)
+	}
+
+	// Описание по сохраненным тегам обновляется
+	if err := places.Remember(ctx, rememberedResult(59.93, 30.30), tags, &models.OSMPlaceDescription{Language: "ru", Provider: "fake", Text: "Новое описание"}); err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+	if nearby, _ = places.Nearby(ctx, dto.NearbyQuery{Lat: 59.93, Lon: 30.30, Language: "ru"}); len(nearby) != 1 || nearby[0].Response != "Новое описание" {
+		t.Errorf("description for stored tags must be updated, got %+v", nearby)
+	}
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"new/services/osm"
+)
+
+const overpassJSON = `{
+  "version": 0.6,
+  "generator": "Overpass API",
+  "elements": [
+    {"type": "node", "id": 101, "lat": 59.9398, "lon": 30.3146, "tags": {"name": "Эрмитаж", "tourism": "museum"}},
+    {"type": "way", "id": 202, "center": {"lat": 59.95, "lon": 30.31}, "nodes": [1, 2, 3],
+     "geometry": [{"lat": 59.9, "lon": 30.3}, null, {"lat": 59.91, "lon": 30.31}], "tags": {"building": "yes"}},
+    {"type": "relation", "id": 303, "members": [{"type": "way", "ref": 202, "role": "outer"}], "tags": {"type": "multipolygon"}}
+  ]
+}`
+
+const osmXML = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6" generator="Overpass API">
+  <note>The data included in this document is from www.openstreetmap.org.</note>
+  <meta osm_base="2024-01-01T00:00:00Z"/>
+  <node id="101" lat="59.9398" lon="30.3146">
+    <tag k="name" v="Эрмитаж &quot;Главный&quot;"/>
+  </node>
+  <way id="202">
+    <center lat="59.95" lon="30.31"/>
+    <nd ref="1" lat="59.9" lon="30.3"/>
+    <nd ref="2" lat="59.91" lon="30.31"/>
+    <tag k="building" v="yes"/>
+  </way>
+  <relation id="303">
+    <member type="way" ref="202" role="outer"/>
+    <tag k="type" v="multipolygon"/>
+  </relation>
+</osm>`
+
+func TestDecodeOverpassJSON(t *testing.T) {
+	objects, err := osm.Decode("application/json; charset=utf-8", []byte(overpassJSON))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objects))
+	}
+	node, way, relation := objects[0], objects[1], objects[2]
+	if node.Type != "node" || node.ID != 101 || node.Lat != 59.9398 || node.Tags["name"] != "Эрмитаж" {
+		t.Errorf("unexpected node: %+v", node)
+	}
+	if way.Center == nil || way.Center.Lat != 59.95 || len(way.Nodes) != 3 || len(way.Geometry) != 2 {
+		t.Errorf("unexpected way: %+v", way)
+	}
+	if len(relation.Members) != 1 || relation.Members[0].Ref != 202 || relation.Members[0].Role != "outer" {
+		t.Errorf("unexpected relation: %+v", relation)
+	}
+}
+
+func TestDecodeOSMXML(t *testing.T) {
+	objects, err := osm.Decode("application/osm+xml", []byte(osmXML))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objects))
+	}
+	if objects[0].Tags["name"] != `Эрмитаж "Главный"` || objects[0].Lon != 30.3146 {
+		t.Errorf("unexpected node: %+v", objects[0])
+	}
+	if objects[1].Center == nil || len(objects[1].Nodes) != 2 || len(objects[1].Geometry) != 2 {
+		t.Errorf("unexpected way: %+v", objects[1])
+	}
+	if objects[2].Members[0].Type != "way" {
+		t.Errorf("unexpected relation: %+v", objects[2])
+	}
+}
+
+func TestDecodeDetectsFormatByContent(t *testing.T) {
+	inputs := map[string]string{
+		"xml":       osmXML,
+		"overpass":  overpassJSON,
+		"json_data": `{"json_data": [{"ID": 1, "Type": "node", "Tags": {"name": "Музей"}}]}`,
+		"array":     `[{"id": 1, "type": "node", "tags": {"name": "Музей"}}]`,
+	}
+	for name, body := range inputs {
+		if _, err := osm.Decode("", []byte(body)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := osm.Decode("text/csv", []byte("id,name")); !errors.Is(err, osm.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for csv, got %v", err)
+	}
+	if _, err := osm.Decode("application/json", []byte(`{"data": []}`)); !errors.Is(err, osm.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat without elements, got %v", err)
+	}
+}
+
+func TestDecodeReportsInvalidElements(t *testing.T) {
+	body := `{"elements": [
+		{"type": "node", "id": 1, "lat": 10, "lon": 20},
+		{"type": "node", "id": 2, "lat": 95, "lon": 20},
+		{"type": "area", "id": 3},
+		{"type": "way", "id": 4, "tags": {"name": 5}},
+		{"type": "relation", "id": 5, "members": [{"type": "street", "ref": 0}]}
+	]}`
+	_, err := osm.Decode("application/json", []byte(body))
+
+	var validationErr *osm.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	byIndex := make(map[int][]osm.ElementError)
+	for _, e := range validationErr.Elements {
+		byIndex[e.Index] = append(byIndex[e.Index], e)
+	}
+	if len(byIndex[0]) != 0 {
+		t.Errorf("valid element reported: %v", byIndex[0])
+	}
+	if len(byIndex[1]) != 1 || byIndex[1][0].ID != 2 || !strings.Contains(byIndex[1][0].Message, "latitude") {
+		t.Errorf("expected latitude error for element 1, got %v", byIndex[1])
+	}
+	if len(byIndex[2]) != 1 || byIndex[2][0].Field != "type" {
+		t.Errorf("expected type error for element 2, got %v", byIndex[2])
+	}
+	if len(byIndex[3]) != 1 || byIndex[3][0].ID != 4 || byIndex[3][0].Field != "tags.name" {
+		t.Errorf("expected tags error for element 3, got %v", byIndex[3])
+	}
+	if len(byIndex[4]) != 2 {
+		t.Errorf("expected member type and ref errors for element 4, got %v", byIndex[4])
+	}
+}
+
+func TestDecodeXMLReportsInvalidElements(t *testing.T) {
+	body := `<osm><node id="1" lat="10" lon="20"/><node id="x" lat="1" lon="2"/><way id="3"><nd ref="-1"/></way></osm>`
+	_, err := osm.Decode("text/xml", []byte(body))
+
+	var validationErr *osm.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Elements) != 2 {
+		t.Fatalf("expected 2 errors, got %v", validationErr.Elements)
+	}
+	if e := validationErr.Elements[0]; e.Index != 1 || e.Field != "id" {
+		t.Errorf("unexpected first error: %+v", e)
+	}
+	if e := validationErr.Elements[1]; e.Index != 2 || e.ID != 3 || e.Field != "nodes[0]" {
+		t.Errorf("unexpected second error: %+v", e)
+	}
+
+	if _, err := osm.Decode("application/xml", []byte(`<osm><node id="1"`)); err == nil {
+		t.Error("expected error for truncated XML")
+	}
+	if _, err := osm.Decode("application/xml", []byte(`<gpx></gpx>`)); !errors.Is(err, osm.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for non-osm root, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"new/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newPlaceRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	controller := &controllers.PlaceController{Service: newTestPlaceService(&fakeLLM{})}
+	r.POST("/process-json-noauth", controller.ProcessJSONNoAuth)
+	return r
+}
+
+// postPlaces отправляет объекты OSM на /process-json-noauth
+func postPlaces(r *gin.Engine, query, acceptLanguage, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/process-json-noauth"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+const placesBody = `{"json_data": [{"id": 1, "type": "node", "tags": {"name": "Музей"}}]}`
+
+func TestProcessJSONNoAuthIgnoresUnusableAcceptLanguage(t *testing.T) {
+	r := newPlaceRouter()
+	for _, header := range []string{"zh-Hant-TW,zh;q=0.9", "!!!", "*"} {
+		if w := postPlaces(r, "", header, placesBody); w.Code != http.StatusOK {
+			t.Errorf("Accept-Language %q: expected 200, got %d %s", header, w.Code, w.Body.String())
+		}
+	}
+	if w := postPlaces(r, "?lang=english+please", "en", placesBody); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid explicit lang: expected 400, got %d", w.Code)
+	}
+}
+
+func TestProcessJSONNoAuthLimitsBodySize(t *testing.T) {
+	t.Setenv("OSM_MAX_BODY_BYTES", "100")
+	r := newPlaceRouter()
+
+	if w := postPlaces(r, "", "", placesBody); w.Code != http.StatusOK {
+		t.Fatalf("body under the limit: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	large := `{"json_data": [{"id": 1, "type": "node", "tags": {"name": "` + strings.Repeat("м", 100) + `"}}]}`
+	if w := postPlaces(r, "", "", large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body over the limit: expected 413, got %d %s", w.Code, w.Body.String())
+	}
+}
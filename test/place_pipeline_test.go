@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"new/dto"
+	"new/services"
+	"new/services/llm"
+	"new/services/tts"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeLLM описывает место его названием и падает на местах из failOn
+type fakeLLM struct {
+	failOn map[string]bool
+}
+
+func (f *fakeLLM) Name() string { return "fake" }
+
+func (f *fakeLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	if f.failOn[place["name"]] {
+		return "", fmt.Errorf("LLM недоступна")
+	}
+	return "Описание: " + place["name"] + place["addr:street"], nil
+}
+
+func (f *fakeLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+type fakeTTS struct{}
+
+func (fakeTTS) Name() string { return "fake" }
+
+func (fakeTTS) Synthesize(ctx context.Context, text string, opts tts.Options) (*tts.Audio, error) {
+	return &tts.Audio{Data: []byte(text), Format: opts.Format, ContentType: tts.ContentType(opts.Format)}, nil
+}
+
+func newTestPlaceService(llmProvider llm.Provider) *services.PlaceService {
+	llmRegistry := llm.NewRegistry()
+	llmRegistry.Register(llmProvider)
+	ttsRegistry := tts.NewRegistry()
+	ttsRegistry.Register(fakeTTS{})
+	return &services.PlaceService{LLM: llmRegistry, TTS: ttsRegistry}
+}
+
+func mixedOSMObjects() []dto.OSMObject {
+	return []dto.OSMObject{
+		{ID: 1, Type: "node", Tags: map[string]string{"amenity": "bench"}}, // пустой
+		{ID: 2, Type: "node", Tags: map[string]string{"name": "Музей"}, Lat: 55.75, Lon: 37.61},
+		{ID: 3, Type: "way", Tags: map[string]string{}, Nodes: []int64{10, 11}}, // пустой
+		{ID: 4, Type: "way", Tags: map[string]string{"addr:street": "Тверская", "addr:housenumber": "1"}, Nodes: []int64{20, 21}},
+		{ID: 5, Type: "node", Tags: nil}, // пустой
+		{ID: 6, Type: "node", Tags: map[string]string{"name": "Театр"}},
+	}
+}
+
+func TestProcessJSONNoAuthKeepsResultsAlignedWithObjects(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{})
+	objects := mixedOSMObjects()
+
+	results, err := service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(objects) {
+		t.Fatalf("expected %d results, got %d", len(objects), len(results))
+	}
+
+	for i, obj := range objects {
+		result := results[i]
+		if result.Index != i {
+			t.Errorf("result %d: expected index %d, got %d", i, i, result.Index)
+		}
+		if result.PlaceID != fmt.Sprintf("%d", obj.ID) || result.OSMType != obj.Type {
+			t.Errorf("result %d: expected %s/%d, got %s/%s", i, obj.Type, obj.ID, result.OSMType, result.PlaceID)
+		}
+	}
+
+	for _, i := range []int{0, 2, 4} {
+		if results[i].Status != dto.PlaceStatusSkipped || results[i].Reason == "" {
+			t.Errorf("result %d: expected skipped with reason, got %q (%q)", i, results[i].Status, results[i].Reason)
+		}
+	}
+
+	expected := map[int]string{1: "Музей", 3: "Тверская", 5: "Театр"}
+	for i, fragment := range expected {
+		if results[i].Status != dto.PlaceStatusSuccess {
+			t.Errorf("result %d: expected success, got %q", i, results[i].Status)
+		}
+		if !strings.Contains(results[i].Response, fragment) {
+			t.Errorf("result %d: description %q does not belong to %q", i, results[i].Response, fragment)
+		}
+	}
+
+	if results[1].Coordinates == nil || results[1].Coordinates.Lat != 55.75 {
+		t.Errorf("result 1: expected coordinates of the museum, got %+v", results[1].Coordinates)
+	}
+	if len(results[3].Nodes) != 2 || results[3].Nodes[0] != 20 {
+		t.Errorf("result 3: expected nodes of way 4, got %v", results[3].Nodes)
+	}
+}
+
+func TestProcessJSONNoAuthAlignsFailedPlaces(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{failOn: map[string]bool{"Музей": true}})
+	objects := mixedOSMObjects()
+
+	results, err := service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if results[1].Status != dto.PlaceStatusLLMError || results[1].PlaceID != "2" {
+		t.Errorf("expected llm_error for node 2, got %q for %s", results[1].Status, results[1].PlaceID)
+	}
+	if results[1].Error == nil || results[1].Error.Code != dto.PlaceStatusLLMError {
+		t.Errorf("expected structured llm error, got %+v", results[1].Error)
+	}
+	if results[5].Status != dto.PlaceStatusSuccess || !strings.Contains(results[5].Response, "Театр") {
+		t.Errorf("expected success for node 6 after failure, got %q %q", results[5].Status, results[5].Response)
+	}
+}
+
+func TestProcessJSONNoAuthAllSkipped(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{})
+	objects := []dto.OSMObject{{ID: 7, Type: "node"}, {ID: 8, Type: "relation"}}
+
+	results, err := service.ProcessJSONNoAuth(context.Background(), services.DescribeOptions{}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if result.Status != dto.PlaceStatusSkipped || result.PlaceID != fmt.Sprintf("%d", objects[i].ID) {
+			t.Errorf("result %d: expected skipped %d, got %q %s", i, objects[i].ID, result.Status, result.PlaceID)
+		}
+	}
+}
+
+// describeFor описывает объекты от имени пользователя и возвращает результаты
+func describeFor(service *services.PlaceService, userID uint, objects []dto.OSMObject) []dto.PlaceResult {
+	resultChan := make(chan dto.PlaceResult)
+	go func() {
+		defer close(resultChan)
+		service.StreamProcessJSON(context.Background(), userID, services.DescribeOptions{}, objects, resultChan)
+	}()
+	var results []dto.PlaceResult
+	for result := range resultChan {
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestSharedDescriptionDependsOnTags(t *testing.T) {
+	withRedis(t)
+	provider := &countingLLM{}
+	service := newTestPlaceService(provider)
+	museum := dto.OSMObject{ID: 1, Type: "node", Tags: map[string]string{"name": "Музей"}, Lat: 55.75, Lon: 37.61}
+	forged := museum
+	forged.Tags = map[string]string{"name": "Подмена"}
+
+	if results := describeFor(service, 1, []dto.OSMObject{museum}); len(results) != 1 || results[0].Response != "Описание: Музей" {
+		t.Fatalf("unexpected first description %+v", results)
+	}
+	// Другие теги того же объекта не получают и не заменяют общее описание
+	if results := describeFor(service, 2, []dto.OSMObject{forged}); len(results) != 1 || results[0].Response != "Описание: Подмена" {
+		t.Fatalf("forged tags must be described separately, got %+v", results)
+	}
+	if results := describeFor(service, 3, []dto.OSMObject{museum}); len(results) != 1 || results[0].Response != "Описание: Музей" {
+		t.Fatalf("shared description must stay intact, got %+v", results)
+	}
+	if calls := atomic.LoadInt32(&provider.calls); calls != 2 {
+		t.Errorf("expected the shared description to be reused, got %d LLM calls", calls)
+	}
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"new/dto"
+	"new/models"
+	"new/services"
+	"new/services/llm"
+	"new/services/prompt"
+)
+
+func TestRenderPromptTemplate(t *testing.T) {
+	body := `Расскажи о месте {{.Name}} ({{default "город не указан" .City}}) на языке {{.Language}}.
+{{- if .Interests}} Интересы: {{join .Interests ", "}}.{{end}}
+{{tags .Tags}}`
+
+	text, err := prompt.Render(body, prompt.Data{
+		Name:      "Музей",
+		Tags:      map[string]string{"tourism": "museum", "name": "Музей"},
+		Interests: []string{"История", "Искусство"},
+		Language:  "ru",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{"Музей (город не указан)", "Интересы: История, Искусство.", "name: Музей\ntourism: museum"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered prompt %q does not contain %q", text, want)
+		}
+	}
+}
+
+func TestRenderPromptTemplateErrors(t *testing.T) {
+	if _, err := prompt.Render("{{.Name", prompt.SampleData()); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err := prompt.Render("{{.Unknown}}", prompt.SampleData()); err == nil {
+		t.Error("expected execution error for unknown field")
+	}
+}
+
+func newPromptService(t *testing.T) *services.PromptTemplateService {
+	return &services.PromptTemplateService{DB: newTestDB(t, &models.PromptTemplate{})}
+}
+
+// createTemplate сохраняет версию шаблона описания
+func createTemplate(t *testing.T, s *services.PromptTemplateService, language, audience, body string, activate bool) *models.PromptTemplate {
+	tpl, err := s.Create(0, dto.CreatePromptTemplateDTO{
+		Name: services.DescribeTemplate, Language: language, Audience: audience, Body: body, Activate: activate,
+	})
+	if err != nil {
+		t.Fatalf("Create %s/%s: %v", language, audience, err)
+	}
+	return tpl
+}
+
+func TestPromptTemplateVersionsAndActivation(t *testing.T) {
+	s := newPromptService(t)
+
+	first := createTemplate(t, s, "RU", "", "Первая версия {{.Name}}", true)
+	second := createTemplate(t, s, "ru", "", "Вторая версия {{.Name}}", false)
+	other := createTemplate(t, s, "en", "", "English {{.Name}}", true)
+	if first.Version != 1 || second.Version != 2 || other.Version != 1 || first.Language != "ru" {
+		t.Fatalf("unexpected versions: %d, %d, %d (%q)", first.Version, second.Version, other.Version, first.Language)
+	}
+
+	// Неактивная версия не выбирается
+	if tpl, err := s.Resolve(services.DescribeTemplate, "ru", ""); err != nil || tpl == nil || tpl.ID != first.ID {
+		t.Fatalf("expected first version, got %+v, %v", tpl, err)
+	}
+	// Активация снимает активность с других версий того же варианта, но не трогает другие варианты
+	if _, err := s.Activate(second.ID); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if tpl, err := s.Resolve(services.DescribeTemplate, "ru", ""); err != nil || tpl == nil || tpl.ID != second.ID {
+		t.Fatalf("expected second version after activation, got %+v, %v", tpl, err)
+	}
+	templates, err := s.List(services.DescribeTemplate)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	active := make(map[uint]bool)
+	for _, tpl := range templates {
+		active[tpl.ID] = tpl.Active
+	}
+	if active[first.ID] || !active[second.ID] || !active[other.ID] {
+		t.Errorf("unexpected active versions: %v", active)
+	}
+
+	if _, err := s.Create(0, dto.CreatePromptTemplateDTO{Name: services.DescribeTemplate, Body: "{{.Name"}); !errors.Is(err, services.ErrInvalidTemplate) {
+		t.Errorf("expected ErrInvalidTemplate, got %v", err)
+	}
+	if _, err := s.Preview(dto.PreviewPromptDTO{}); !errors.Is(err, services.ErrInvalidTemplate) {
+		t.Errorf("empty preview: expected ErrInvalidTemplate, got %v", err)
+	}
+}
+
+func TestResolvePromptTemplateFallback(t *testing.T) {
+	s := newPromptService(t)
+	resolve := func(language, audience string) *models.PromptTemplate {
+		tpl, err := s.Resolve(services.DescribeTemplate, language, audience)
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		return tpl
+	}
+
+	// Шаблонов нет — используется встроенная инструкция
+	if tpl := resolve("en", "kids"); tpl != nil {
+		t.Fatalf("expected no template, got %+v", tpl)
+	}
+
+	fallback := createTemplate(t, s, "", "", "По умолчанию {{.Name}}", true)
+	kids := createTemplate(t, s, "", "kids", "Детям {{.Name}}", true)
+	english := createTemplate(t, s, "en", "", "English {{.Name}}", true)
+	englishKids := createTemplate(t, s, "en", "kids", "English for kids {{.Name}}", true)
+
+	cases := []struct {
+		language, audience string
+		want               uint
+	}{
+		{"en", "kids", englishKids.ID},
+		{"en", "", english.ID},
+		{"en", "experts", english.ID},
+		{"ru", "kids", kids.ID},
+		{"ru", "", fallback.ID},
+	}
+	for _, c := range cases {
+		if tpl := resolve(c.language, c.audience); tpl == nil || tpl.ID != c.want {
+			t.Errorf("%s/%s: expected template %d, got %+v", c.language, c.audience, c.want, tpl)
+		}
+	}
+}
+
+// promptLLM запоминает готовую инструкцию из шаблона для каждого места
+type promptLLM struct {
+	mu      sync.Mutex
+	prompts map[string]string
+}
+
+func (l *promptLLM) Name() string { return "fake" }
+
+func (l *promptLLM) Describe(ctx context.Context, place map[string]string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prompts[place["name"]] = place[llm.PromptField]
+	return "Описание: " + place["name"], nil
+}
+
+func (l *promptLLM) Ask(ctx context.Context, question string) (string, error) { return question, nil }
+
+func TestPlacePromptUsesResolvedTemplate(t *testing.T) {
+	provider := &promptLLM{prompts: make(map[string]string)}
+	service := newTestPlaceService(provider)
+	service.Prompts = newPromptService(t)
+	describe := func(name, language string) string {
+		place := map[string]string{"name": name, "place_name": name, "language": language}
+		if _, err := service.ProcessPlaces(context.Background(), 0, []map[string]string{place}); err != nil {
+			t.Fatalf("ProcessPlaces: %v", err)
+		}
+		return provider.prompts[name]
+	}
+
+	// Без шаблонов провайдер получает место без инструкции и использует встроенную
+	if got := describe("Музей", "en"); got != "" {
+		t.Fatalf("expected built-in prompt, got %q", got)
+	}
+
+	createTemplate(t, service.Prompts, "", "", "По умолчанию: {{.Name}}", true)
+	if got := describe("Театр", "en"); got != "По умолчанию: Театр" {
+		t.Errorf("expected default template, got %q", got)
+	}
+	createTemplate(t, service.Prompts, "en", "", "About {{.Name}} in {{.Language}}", true)
+	if got := describe("Собор", "en"); got != "About Собор in en" {
+		t.Errorf("expected language template, got %q", got)
+	}
+	if got := describe("Парк", "ru"); got != "По умолчанию: Парк" {
+		t.Errorf("other languages must keep the default template, got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"new/middleware"
+	"new/models"
+	"new/services"
+	"new/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAdminRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(&services.TokenService{}), middleware.RequireRole(models.RoleStaff, models.RoleAdmin))
+	admin.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("userRole")) })
+	return r
+}
+
+func requestWithRole(t *testing.T, r *gin.Engine, role string) *httptest.ResponseRecorder {
+	t.Helper()
+	withTestKeyring(t)
+	token, _, err := utils.GenerateAccessToken(1, role, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/admin/ping", nil)
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireRoleAllowsStaffAndAdmin(t *testing.T) {
+	r := newAdminRouter()
+	for _, role := range []string{models.RoleStaff, models.RoleAdmin} {
+		if w := requestWithRole(t, r, role); w.Code != http.StatusOK || w.Body.String() != role {
+			t.Fatalf("role %s: got %d %q", role, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRequireRoleRejectsUser(t *testing.T) {
+	r := newAdminRouter()
+	if w := requestWithRole(t, r, models.RoleUser); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for user role, got %d", w.Code)
+	}
+}
+
+func TestAdminGroupRequiresToken(t *testing.T) {
+	r := newAdminRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/ping", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+}
+
+func TestRequirePermissionHonoursPersonalGrants(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.UserPermission{})
+	access := &services.AccessService{DB: db}
+	db.Create(&models.User{ID: 1, Username: "user", Email: "user@example.com", Role: models.RoleUser})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(&services.TokenService{}))
+	admin.GET("/ping", middleware.RequirePermission(access, models.PermInspectCache), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userRole"))
+	})
+
+	if w := requestWithRole(t, r, models.RoleUser); w.Code != http.StatusForbidden {
+		t.Fatalf("user without a grant: expected 403, got %d", w.Code)
+	}
+	if w := requestWithRole(t, r, models.RoleStaff); w.Code != http.StatusOK {
+		t.Fatalf("staff role grants the permission: expected 200, got %d", w.Code)
+	}
+	if _, err := access.SetPermissions(1, []string{models.PermInspectCache}); err != nil {
+		t.Fatalf("SetPermissions: %v", err)
+	}
+	if w := requestWithRole(t, r, models.RoleUser); w.Code != http.StatusOK {
+		t.Errorf("personal grant must give access to a user role: got %d %s", w.Code, w.Body.String())
+	}
+}
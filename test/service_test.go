@@ -49,7 +49,7 @@ func BenchmarkProcessPlaces(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		results, err := service.ProcessPlaces(userID, places)
+		results, err := service.ProcessPlaces(ctx, userID, places)
 		if err != nil {
 			b.Fatalf("error in benchmark: %v", err)
 		}
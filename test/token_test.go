@@ -0,0 +1,225 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"new/models"
+	"new/services"
+	"new/utils"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	withTestKeyring(t)
+	token, issued, err := utils.GenerateAccessToken(42, "user", "family-1")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	claims, err := utils.ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.ID == "" || claims.ID != issued.ID {
+		t.Fatalf("jti mismatch: got %q, want %q", claims.ID, issued.ID)
+	}
+	if claims.Family != "family-1" {
+		t.Fatalf("family mismatch: got %q", claims.Family)
+	}
+	userID, err := claims.UserID()
+	if err != nil || userID != 42 {
+		t.Fatalf("UserID() = %d, %v; want 42", userID, err)
+	}
+}
+
+func TestAccessTokensHaveUniqueIDs(t *testing.T) {
+	withTestKeyring(t)
+	_, first, err := utils.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := utils.GenerateAccessToken(1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Fatal("expected distinct jti for every access token")
+	}
+}
+
+func TestParseAccessTokenRejectsTampered(t *testing.T) {
+	withTestKeyring(t)
+	token, _, err := utils.GenerateAccessToken(7, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ParseAccessToken(token + "x"); err == nil {
+		t.Fatal("expected tampered token to be rejected")
+	}
+}
+
+// newTokenTest создает TokenService на SQLite и miniredis и пользователя с ID 1
+func newTokenTest(t *testing.T) *services.TokenService {
+	t.Helper()
+	withRedis(t)
+	withTestKeyring(t)
+	db := newTestDB(t, &models.User{}, &models.RefreshToken{})
+	if err := db.Create(&models.User{ID: 1, Username: "user", Email: "user@example.com"}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return &services.TokenService{DB: db}
+}
+
+// authenticated проверяет токен так же, как AuthMiddleware и рукопожатие /ws
+func authenticated(tokens *services.TokenService, access string) bool {
+	_, _, err := tokens.Authenticate(access)
+	return err == nil
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	tokens := newTokenTest(t)
+	first, err := tokens.IssueTokens(1)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	second, err := tokens.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("refresh must issue a new pair")
+	}
+	if !authenticated(tokens, second.AccessToken) {
+		t.Error("new access token must be accepted")
+	}
+
+	third, err := tokens.Refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh of the rotated token: %v", err)
+	}
+	if third.RefreshToken == second.RefreshToken {
+		t.Error("every refresh must rotate the token")
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	tokens := newTokenTest(t)
+	first, err := tokens.IssueTokens(1)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	second, err := tokens.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	other, err := tokens.IssueTokens(1)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	// Украденный старый токен предъявляется повторно
+	if _, err := tokens.Refresh(first.RefreshToken); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, err := tokens.Refresh(second.RefreshToken); err == nil {
+		t.Error("the whole family must be revoked after reuse")
+	}
+	if authenticated(tokens, second.AccessToken) {
+		t.Error("access tokens of the revoked family must be denied")
+	}
+
+	// Другие сессии пользователя не затрагиваются
+	if !authenticated(tokens, other.AccessToken) {
+		t.Error("access token of another family must stay valid")
+	}
+	if _, err := tokens.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("refresh token of another family must stay valid: %v", err)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	tokens := newTokenTest(t)
+	if _, err := tokens.Refresh("unknown"); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestLogoutRevokesAccessAndFamily(t *testing.T) {
+	tokens := newTokenTest(t)
+	pair, err := tokens.IssueTokens(1)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	other, err := tokens.IssueTokens(1)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	_, claims, err := tokens.Authenticate(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if err := tokens.Logout(claims, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if authenticated(tokens, pair.AccessToken) {
+		t.Error("access token must be denied after logout")
+	}
+	if _, err := tokens.Refresh(pair.RefreshToken); err == nil {
+		t.Error("refresh token must be revoked after logout")
+	}
+	if !authenticated(tokens, other.AccessToken) {
+		t.Error("logout must not end other sessions")
+	}
+}
+
+func TestAuthenticateChecksDenyList(t *testing.T) {
+	tokens := newTokenTest(t)
+	access, claims, err := utils.GenerateAccessToken(1, "user", "family-1")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	sibling, _, err := utils.GenerateAccessToken(1, "user", "family-1")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	if !authenticated(tokens, access) {
+		t.Fatal("fresh token must be accepted")
+	}
+
+	if err := tokens.DenyAccessToken(claims); err != nil {
+		t.Fatalf("DenyAccessToken: %v", err)
+	}
+	if authenticated(tokens, access) {
+		t.Error("denied token must be rejected")
+	}
+	if !authenticated(tokens, sibling) {
+		t.Error("only the denied jti must be rejected")
+	}
+
+	if err := tokens.RevokeFamily("family-1"); err != nil {
+		t.Fatalf("RevokeFamily: %v", err)
+	}
+	if authenticated(tokens, sibling) {
+		t.Error("tokens of a revoked family must be rejected")
+	}
+}
+
+func TestCheckDenyListRequiresRedis(t *testing.T) {
+	tokens := &services.TokenService{}
+	t.Setenv("AUTH_ALLOW_NO_DENY_LIST", "")
+	if err := tokens.CheckDenyList(); err == nil {
+		t.Error("startup without Redis must fail unless explicitly allowed")
+	}
+	t.Setenv("AUTH_ALLOW_NO_DENY_LIST", "true")
+	if err := tokens.CheckDenyList(); err != nil {
+		t.Errorf("explicit opt-in must allow startup: %v", err)
+	}
+
+	withRedis(t)
+	t.Setenv("AUTH_ALLOW_NO_DENY_LIST", "")
+	if err := tokens.CheckDenyList(); err != nil {
+		t.Errorf("CheckDenyList with Redis: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"new/dto"
+	"new/services"
+	"new/services/geo"
+	"new/services/tour"
+)
+
+// pathLength возвращает длину пути через точки в порядке order, начиная со start (если задан)
+func pathLength(start *geo.Point, points []geo.Point, order []int) float64 {
+	var path []geo.Point
+	if start != nil {
+		path = append(path, *start)
+	}
+	for _, i := range order {
+		path = append(path, points[i])
+	}
+	total := 0.0
+	for k := 1; k < len(path); k++ {
+		total += geo.Distance(path[k-1], path[k])
+	}
+	return total
+}
+
+func TestPlanOrdersStopsAlongStreet(t *testing.T) {
+	// Точки на одной широте, примерно через 110 м, в перемешанном порядке
+	points := []geo.Point{
+		{Lat: 59.93, Lon: 30.304}, {Lat: 59.93, Lon: 30.300}, {Lat: 59.93, Lon: 30.308},
+		{Lat: 59.93, Lon: 30.302}, {Lat: 59.93, Lon: 30.306},
+	}
+	start := geo.Point{Lat: 59.93, Lon: 30.299}
+	route := tour.Plan(&start, points, tour.Budget{})
+
+	want := []int{1, 3, 0, 4, 2}
+	if len(route.Order) != len(want) {
+		t.Fatalf("expected %d stops, got %v", len(want), route.Order)
+	}
+	for i := range want {
+		if route.Order[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, route.Order)
+		}
+	}
+	if math.Abs(route.Distance-pathLength(&start, points, route.Order)) > 1e-6 {
+		t.Errorf("distance %f does not match legs", route.Distance)
+	}
+	if route.Legs[0] == 0 {
+		t.Errorf("first leg from start must not be zero")
+	}
+	if route.Duration != (tour.Budget{}).Total(route.Distance, len(route.Order)) {
+		t.Errorf("unexpected duration %v", route.Duration)
+	}
+}
+
+func TestPlanRespectsBudget(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	points := make([]geo.Point, 30)
+	for i := range points {
+		points[i] = geo.Point{Lat: 59.93 + rng.Float64()*0.02, Lon: 30.30 + rng.Float64()*0.04}
+	}
+
+	budgets := []tour.Budget{
+		{Duration: 45 * time.Minute, StopDuration: 5 * time.Minute},
+		{Distance: 1500},
+		{MaxStops: 3},
+		{Duration: time.Minute, StopDuration: 5 * time.Minute}, // Не помещается ни одна остановка
+	}
+	for _, budget := range budgets {
+		route := tour.Plan(nil, points, budget)
+		if !budget.Fits(route.Distance, len(route.Order)) {
+			t.Errorf("%+v: route does not fit: %.0f m, %d stops, %v", budget, route.Distance, len(route.Order), route.Duration)
+		}
+		if len(route.Order) > 0 && (route.Order[0] != 0 || route.Legs[0] != 0) {
+			t.Errorf("%+v: without start the route must begin at the first point, got %v", budget, route.Order)
+		}
+		seen := make(map[int]bool)
+		for _, i := range route.Order {
+			if seen[i] {
+				t.Fatalf("stop %d visited twice: %v", i, route.Order)
+			}
+			seen[i] = true
+		}
+	}
+	if route := tour.Plan(nil, points, budgets[3]); len(route.Order) != 0 {
+		t.Errorf("expected empty route, got %v", route.Order)
+	}
+}
+
+func TestPlanLeavesNoImprovingTwoOptMove(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for round := 0; round < 20; round++ {
+		points := make([]geo.Point, 12)
+		for i := range points {
+			points[i] = geo.Point{Lat: 55.75 + rng.Float64()*0.01, Lon: 37.61 + rng.Float64()*0.02}
+		}
+		start := geo.Point{Lat: 55.75, Lon: 37.61}
+		route := tour.Plan(&start, points, tour.Budget{})
+		if len(route.Order) != len(points) {
+			t.Fatalf("unlimited budget must visit every point, got %d", len(route.Order))
+		}
+
+		length := pathLength(&start, points, route.Order)
+		for i := 0; i < len(route.Order); i++ {
+			for j := i + 1; j < len(route.Order); j++ {
+				candidate := append([]int(nil), route.Order...)
+				for a, b := i, j; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				if pathLength(&start, points, candidate) < length-1e-3 {
+					t.Fatalf("reversing %d..%d shortens the route", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestResolveTourQuery(t *testing.T) {
+	q, err := services.ResolveTourQuery(dto.TourQuery{Language: "EN"})
+	if err != nil {
+		t.Fatalf("ResolveTourQuery: %v", err)
+	}
+	if q.DurationMinutes != services.DefaultTourMinutes || q.StopMinutes != services.DefaultTourStopMinutes ||
+		q.MaxStops != services.DefaultTourStops || q.Radius != services.DefaultTourRadius || q.Language != "en" {
+		t.Errorf("unexpected defaults: %+v", q)
+	}
+
+	// Бюджет расстояния без бюджета времени не ограничивается временем по умолчанию
+	q, _ = services.ResolveTourQuery(dto.TourQuery{DistanceMeters: 2000})
+	if q.DurationMinutes != 0 {
+		t.Errorf("duration must stay unlimited, got %v", q.DurationMinutes)
+	}
+
+	invalid := []dto.TourQuery{
+		{DurationMinutes: -1},
+		{DistanceMeters: math.NaN()},
+		{MaxStops: services.MaxTourStops + 1},
+		{Radius: services.MaxNearbyRadius + 1},
+		{Start: &dto.Coordinates{Lat: 100}},
+	}
+	for _, query := range invalid {
+		if _, err := services.ResolveTourQuery(query); !errors.Is(err, services.ErrInvalidTour) {
+			t.Errorf("%+v: expected ErrInvalidTour, got %v", query, err)
+		}
+	}
+}
+
+func TestPlanTourRequiresObjectsOrStart(t *testing.T) {
+	service := newTestPlaceService(&fakeLLM{})
+	if _, err := service.PlanTour(context.Background(), 0, dto.TourQuery{}, nil); !errors.Is(err, services.ErrInvalidTour) {
+		t.Errorf("expected ErrInvalidTour, got %v", err)
+	}
+}
+
+func TestBearingAndCompass(t *testing.T) {
+	origin := geo.Point{Lat: 59.93, Lon: 30.30}
+	cases := map[string]geo.Point{
+		"N":  {Lat: 59.94, Lon: 30.30},
+		"E":  {Lat: 59.93, Lon: 30.32},
+		"S":  {Lat: 59.92, Lon: 30.30},
+		"W":  {Lat: 59.93, Lon: 30.28},
+		"NE": {Lat: 59.94, Lon: 30.32},
+	}
+	for want, target := range cases {
+		if got := geo.Compass(geo.Bearing(origin, target)); got != want {
+			t.Errorf("expected %s, got %s (%.1f°)", want, got, geo.Bearing(origin, target))
+		}
+	}
+	if geo.Compass(359) != "N" || geo.Compass(0) != "N" {
+		t.Error("bearings around 0° must be north")
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"new/dto"
+	"new/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWebSocket подключается к обработчику WebSocket с параметрами query
+func dialWebSocket(t *testing.T, query string) *websocket.Conn {
+	t.Helper()
+	withTestKeyring(t)
+	handler := services.NewWebSocketHandler(newTestPlaceService(&fakeLLM{}), &services.TokenService{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws"+query, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWebSocketRejectsMissingAndInvalidToken(t *testing.T) {
+	for _, query := range []string{"", "?token=not-a-jwt"} {
+		conn := dialWebSocket(t, query)
+
+		var envelope dto.WSEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			t.Fatalf("%q: read: %v", query, err)
+		}
+		if envelope.Version != dto.WSProtocolVersion || envelope.Type != dto.WSMessageError {
+			t.Fatalf("%q: expected error envelope, got %+v", query, envelope)
+		}
+		var payload dto.WSError
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			t.Fatalf("%q: payload: %v", query, err)
+		}
+		if payload.Code != dto.WSErrorUnauthorized || payload.Message == "" {
+			t.Errorf("%q: unexpected error payload %+v", query, payload)
+		}
+
+		// После ошибки рукопожатия сервер закрывает соединение
+		if _, _, err := conn.ReadMessage(); err == nil {
+			t.Errorf("%q: connection must be closed after an unauthorized error", query)
+		}
+	}
+}
+
+func TestWebSocketLimitsMessageSize(t *testing.T) {
+	t.Setenv("OSM_MAX_BODY_BYTES", "1000")
+	conn, server := dialAuthenticated(t, newTestPlaceService(&fakeLLM{}))
+	defer server.Close()
+	defer conn.Close()
+
+	// Сообщение в пределах лимита обрабатывается
+	ping := dto.WSEnvelope{Type: dto.WSMessagePing, RequestID: "small"}
+	if err := conn.WriteJSON(ping); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	readUntil(t, conn, dto.WSMessagePong)
+
+	payload, _ := json.Marshal([]dto.OSMObject{{ID: 1, Type: "node", Tags: map[string]string{"name": strings.Repeat("м", 1000)}}})
+	if err := conn.WriteJSON(dto.WSEnvelope{Type: dto.WSMessageProcess, RequestID: "large", Payload: payload}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Errorf("expected close code %d, got %v", websocket.CloseMessageTooBig, err)
+		}
+		break
+	}
+}
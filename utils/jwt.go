@@ -1,85 +1,90 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// JWT секретный ключ
-var jwtSecret = []byte("your_secret_key")
+// AccessTokenTTL — время жизни access-токена (ACCESS_TOKEN_TTL, по умолчанию 15 минут)
+func AccessTokenTTL() time.Duration {
+	return EnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
 
-// GenerateJWT — генерирует JWT токен для пользователя
-func GenerateJWT(userID uint) (string, error) {
-	// Определяем срок действия токена (например, 24 часа)
-	expirationTime := time.Now().Add(24 * time.Hour)
+// AccessClaims — claims access-токена: стандартные поля, роль и семейство refresh-токенов
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	Role   string `json:"role,omitempty"`
+	Family string `json:"fam,omitempty"`
+}
 
-	// Создаем claims токена
-	claims := &jwt.RegisteredClaims{
-		Subject:   fmt.Sprintf("%d", userID), // Преобразуем userID в строку с использованием fmt.Sprintf
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// GenerateAccessToken — генерирует короткоживущий access-токен с уникальным jti и ролью,
+// привязанный к семейству refresh-токенов
+func GenerateAccessToken(userID uint, role, family string) (string, *AccessClaims, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", nil, err
 	}
 
-	// Создаем токен
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	now := time.Now()
+	claims := &AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   fmt.Sprintf("%d", userID), // Преобразуем userID в строку с использованием fmt.Sprintf
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Role:   role,
+		Family: family,
+	}
 
-	// Подписываем токен секретным ключом
-	return token.SignedString(jwtSecret)
+	// Подписываем токен активным ключом из набора
+	signed, err := CurrentKeyring().Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-// ValidateToken — проверяет JWT токен
-func ValidateToken(tokenString string) (jwt.Claims, error) {
-	// Парсим и проверяем токен
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Проверка типа токена
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return jwtSecret, nil
-	})
+// ParseAccessToken — проверяет access-токен и возвращает его claims
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, CurrentKeyring().Keyfunc)
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
-
-	// Возвращаем claims из токена
-	return token.Claims, nil
+	return claims, nil
 }
 
-// ExtractUserIDFromToken — извлекает userID из JWT токена
-func ExtractUserIDFromToken(tokenString string) (uint, error) {
-	// Парсим токен
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Проверка метода подписи токена
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method") // Ошибка при некорректном методе подписи
-		}
-		return jwtSecret, nil // Возвращаем секретный ключ для проверки подписи
-	})
-	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token") // Ошибка, если токен невалидный
-	}
-
-	// Извлекаем claims из токена
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return 0, errors.New("invalid token claims") // Ошибка при некорректных claims
+// UserID — возвращает userID из поля sub
+func (c *AccessClaims) UserID() (uint, error) {
+	userID, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, errors.New("failed to parse userID")
 	}
+	return uint(userID), nil
+}
 
-	// Извлекаем userID из claims
-	userIDStr, ok := claims["sub"].(string) // Достаем поле "sub" (userID)
-	if !ok {
-		return 0, errors.New("invalid userID in token claims") // Ошибка, если поле "sub" отсутствует или неверного типа
+// RandomToken — возвращает случайную hex-строку из n байт
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации случайного токена: %v", err)
 	}
+	return hex.EncodeToString(buf), nil
+}
 
-	// Преобразуем userID из строки в uint
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		return 0, errors.New("failed to parse userID") // Ошибка при преобразовании userID
+// EnvDuration читает длительность из переменной окружения, возвращая def при отсутствии или ошибке
+func EnvDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
 	}
-
-	return uint(userID), nil // Возвращаем userID как uint
+	return value
 }
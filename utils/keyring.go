@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey — ключ подписи JWT с идентификатором kid
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   interface{} // Секрет HMAC или закрытый ключ; nil для ключей только для проверки
+	VerifyKey interface{} // Секрет HMAC или открытый ключ
+	RetiredAt time.Time   // Нулевое значение — ключ не выведен из оборота
+}
+
+// Keyring — набор ключей: активный подписывает новые токены,
+// выведенные из оборота принимаются при проверке в течение Grace
+type Keyring struct {
+	Active *SigningKey
+	Keys   map[string]*SigningKey
+	Grace  time.Duration
+}
+
+// NewKeyring создает набор ключей с активным ключом active
+func NewKeyring(active *SigningKey, grace time.Duration) *Keyring {
+	return &Keyring{
+		Active: active,
+		Keys:   map[string]*SigningKey{active.ID: active},
+		Grace:  grace,
+	}
+}
+
+// Add добавляет ключ в набор (например, выведенный из оборота)
+func (k *Keyring) Add(key *SigningKey) {
+	k.Keys[key.ID] = key
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	if k.Active.SignKey == nil {
+		return "", fmt.Errorf("ключ %s не может подписывать токены", k.Active.ID)
+	}
+	token := jwt.NewWithClaims(k.Active.Method, claims)
+	token.Header["kid"] = k.Active.ID
+	return token.SignedString(k.Active.SignKey)
+}
+
+// Keyfunc выбирает ключ проверки по kid и сверяет алгоритм с ожидаемым для ключа
+func (k *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := k.Active
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, ok = k.Keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	if !key.RetiredAt.IsZero() && time.Now().After(key.RetiredAt.Add(k.Grace)) {
+		return nil, errors.New("signing key retired")
+	}
+	return key.VerifyKey, nil
+}
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet — набор открытых ключей для /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые ключи набора. Секреты HMAC не публикуются,
+// ключи с истекшим периодом Grace не включаются
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.sortedKeys() {
+		if !key.RetiredAt.IsZero() && time.Now().After(key.RetiredAt.Add(k.Grace)) {
+			continue
+		}
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// sortedKeys возвращает ключи в стабильном порядке: активный первым, затем по kid
+func (k *Keyring) sortedKeys() []*SigningKey {
+	keys := []*SigningKey{k.Active}
+	var rest []string
+	for kid := range k.Keys {
+		if kid != k.Active.ID {
+			rest = append(rest, kid)
+		}
+	}
+	sort.Strings(rest)
+	for _, kid := range rest {
+		keys = append(keys, k.Keys[kid])
+	}
+	return keys
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   *Keyring
+)
+
+// SetKeyring устанавливает набор ключей, используемый для выпуска и проверки токенов
+func SetKeyring(k *Keyring) {
+	keyringMu.Lock()
+	keyring = k
+	keyringMu.Unlock()
+}
+
+// CurrentKeyring возвращает текущий набор ключей. Набор должен быть установлен через SetKeyring
+// при запуске: без него выпускать и проверять токены нельзя
+func CurrentKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	if keyring == nil {
+		panic("набор ключей JWT не установлен, вызовите SetKeyring")
+	}
+	return keyring
+}
+
+// devKeyring — HMAC-ключ со случайным секретом для локальной разработки.
+// Секрет живет до перезапуска процесса, поэтому выданные токены после перезапуска недействительны
+func devKeyring() (*Keyring, error) {
+	secret, err := RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(NewHMACKey("dev", []byte(secret)), 0), nil
+}
+
+// NewHMACKey создает ключ HS256
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// KeyConfig — описание ключа в файле JWT_KEYS_FILE
+type KeyConfig struct {
+	ID             string    `json:"kid"`
+	Alg            string    `json:"alg"`                        // HS256, RS256 или EdDSA
+	SecretFile     string    `json:"secret_file,omitempty"`      // Для HS256
+	PrivateKeyFile string    `json:"private_key_file,omitempty"` // PEM, для RS256/EdDSA
+	PublicKeyFile  string    `json:"public_key_file,omitempty"`  // PEM, если закрытый ключ уже удалён
+	RetiredAt      time.Time `json:"retired_at,omitempty"`
+}
+
+// KeyringConfig — содержимое файла JWT_KEYS_FILE
+type KeyringConfig struct {
+	Active string      `json:"active"`
+	Keys   []KeyConfig `json:"keys"`
+}
+
+// LoadKeyringFromEnv загружает ключи подписи из конфигурации:
+//   - JWT_KEYS_FILE — JSON-файл с набором ключей (см. KeyringConfig);
+//   - иначе один ключ: JWT_ALG (HS256 по умолчанию), JWT_KEY_ID,
+//     JWT_SECRET / JWT_SECRET_FILE для HS256 или JWT_PRIVATE_KEY_FILE для RS256/EdDSA.
+//
+// JWT_KEY_GRACE задает, сколько принимаются токены выведенных из оборота ключей (по умолчанию 24 часа).
+// Если ключи не настроены, возвращается ошибка. Для локальной разработки JWT_DEV_MODE=true
+// включает случайный ключ, действующий до перезапуска
+func LoadKeyringFromEnv() (*Keyring, error) {
+	grace := EnvDuration("JWT_KEY_GRACE", 24*time.Hour)
+
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения JWT_KEYS_FILE: %v", err)
+		}
+		var cfg KeyringConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("ошибка разбора JWT_KEYS_FILE: %v", err)
+		}
+		return BuildKeyring(cfg, filepath.Dir(path), grace)
+	}
+
+	alg := os.Getenv("JWT_ALG")
+	if alg == "" {
+		alg = "HS256"
+	}
+	kc := KeyConfig{
+		ID:             os.Getenv("JWT_KEY_ID"),
+		Alg:            alg,
+		SecretFile:     os.Getenv("JWT_SECRET_FILE"),
+		PrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" && kc.SecretFile == "" && strings.EqualFold(alg, "HS256") {
+		if kc.ID == "" {
+			kc.ID = fingerprint([]byte(secret))
+		}
+		return NewKeyring(NewHMACKey(kc.ID, []byte(secret)), grace), nil
+	}
+	if kc.SecretFile == "" && kc.PrivateKeyFile == "" {
+		if os.Getenv("JWT_DEV_MODE") != "true" {
+			return nil, errors.New("ключи не настроены: задайте JWT_KEYS_FILE, JWT_SECRET, JWT_SECRET_FILE или JWT_PRIVATE_KEY_FILE")
+		}
+		log.Println("JWT-ключи не настроены, используется случайный ключ для разработки (JWT_DEV_MODE)")
+		return devKeyring()
+	}
+
+	key, err := loadKey(kc, "")
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(key, grace), nil
+}
+
+// BuildKeyring собирает набор ключей из конфигурации; относительные пути считаются от dir
+func BuildKeyring(cfg KeyringConfig, dir string, grace time.Duration) (*Keyring, error) {
+	keys := make(map[string]*SigningKey, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return nil, errors.New("у ключа не задан kid")
+		}
+		if _, ok := keys[kc.ID]; ok {
+			return nil, fmt.Errorf("ключ %s описан дважды", kc.ID)
+		}
+		key, err := loadKey(kc, dir)
+		if err != nil {
+			return nil, err
+		}
+		keys[kc.ID] = key
+	}
+
+	active, ok := keys[cfg.Active]
+	if !ok {
+		return nil, fmt.Errorf("активный ключ %q не найден", cfg.Active)
+	}
+	if active.SignKey == nil {
+		return nil, fmt.Errorf("для активного ключа %s нужен закрытый ключ", active.ID)
+	}
+	if !active.RetiredAt.IsZero() {
+		return nil, fmt.Errorf("активный ключ %s выведен из оборота", active.ID)
+	}
+
+	k := NewKeyring(active, grace)
+	for _, key := range keys {
+		k.Add(key)
+	}
+	return k, nil
+}
+
+// loadKey читает ключ из файлов, указанных в конфигурации
+func loadKey(kc KeyConfig, dir string) (*SigningKey, error) {
+	read := func(name string) ([]byte, error) {
+		if dir != "" && !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения ключа %s: %v", kc.ID, err)
+		}
+		return data, nil
+	}
+
+	key := &SigningKey{ID: kc.ID, RetiredAt: kc.RetiredAt}
+	switch strings.ToUpper(kc.Alg) {
+	case "HS256":
+		if kc.SecretFile == "" {
+			return nil, fmt.Errorf("для ключа %s не задан secret_file", kc.ID)
+		}
+		secret, err := read(kc.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		secret = []byte(strings.TrimSpace(string(secret)))
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("секрет ключа %s пуст", kc.ID)
+		}
+		key.Method, key.SignKey, key.VerifyKey = jwt.SigningMethodHS256, secret, secret
+
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := read(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора ключа %s: %v", kc.ID, err)
+			}
+			key.SignKey, key.VerifyKey = priv, &priv.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			data, err := read(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора ключа %s: %v", kc.ID, err)
+			}
+			key.VerifyKey = pub
+		}
+
+	case "EDDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			data, err := read(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора ключа %s: %v", kc.ID, err)
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("ключ %s не является ключом Ed25519", kc.ID)
+			}
+			key.SignKey, key.VerifyKey = edPriv, edPriv.Public()
+		} else if kc.PublicKeyFile != "" {
+			data, err := read(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора ключа %s: %v", kc.ID, err)
+			}
+			key.VerifyKey = pub
+		}
+
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм %q для ключа %s", kc.Alg, kc.ID)
+	}
+
+	if key.VerifyKey == nil {
+		return nil, fmt.Errorf("для ключа %s не задан файл ключа", kc.ID)
+	}
+	if key.ID == "" {
+		key.ID = keyFingerprint(key)
+	}
+	return key, nil
+}
+
+// keyFingerprint вычисляет kid по ключу проверки, если он не задан явно
+func keyFingerprint(key *SigningKey) string {
+	switch pub := key.VerifyKey.(type) {
+	case []byte:
+		return fingerprint(pub)
+	case *rsa.PublicKey:
+		return fingerprint(pub.N.Bytes())
+	case ed25519.PublicKey:
+		return fingerprint(pub)
+	}
+	return "default"
+}
+
+// fingerprint — короткий sha256-отпечаток данных
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}